@@ -0,0 +1,429 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+	"pocket-wallet/internal/export"
+	"pocket-wallet/internal/metrics"
+	"pocket-wallet/internal/openapi"
+	"pocket-wallet/internal/wallet"
+	"pocket-wallet/pkg/config"
+)
+
+// The REST API mirrors the Wails-bound methods for scripts and other
+// clients. Handlers only adapt HTTP to the App methods and wallet service
+// the desktop frontend uses, and apiRoutes is the single description of
+// the API.
+
+const (
+	apiPrefix       = "/api/v1"
+	maxAPIBodyBytes = 1 << 20
+)
+
+// noInput marks a route that takes no parameters or body
+type noInput struct{}
+
+// noContent marks a route that answers 204 with an empty body
+type noContent struct{}
+
+// fileDownload is a response streamed as a file instead of JSON
+type fileDownload struct {
+	ContentType string
+	Filename    string
+	Write       func(w io.Writer) error
+}
+
+// eventStream is a response streamed as Server-Sent Events
+type eventStream struct {
+	userID      string
+	lastEventID int64
+}
+
+// apiCall is one request being served
+type apiCall struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	session string // User ID, empty on public routes
+	token   string
+}
+
+// apiRoute is one endpoint. Input holds query parameters for GET and the
+// JSON body otherwise.
+type apiRoute struct {
+	Method  string
+	Path    string
+	Summary string
+	Auth    bool
+	Status  int
+	Input   reflect.Type
+	Output  reflect.Type
+	serve   func(c *apiCall) (any, error)
+}
+
+// newAPIRoute builds a route whose documented types are those of its handler
+func newAPIRoute[In, Out any](method, path, summary string, auth bool, status int, handle func(c *apiCall, in *In) (Out, error)) apiRoute {
+	return apiRoute{
+		Method:  method,
+		Path:    apiPrefix + path,
+		Summary: summary,
+		Auth:    auth,
+		Status:  status,
+		Input:   reflect.TypeFor[In](),
+		Output:  reflect.TypeFor[Out](),
+		serve: func(c *apiCall) (any, error) {
+			in := new(In)
+			if err := decodeAPIInput(c, in); err != nil {
+				return nil, err
+			}
+			return handle(c, in)
+		},
+	}
+}
+
+func (a *App) apiRoutes() []apiRoute {
+	return []apiRoute{
+		newAPIRoute(http.MethodPost, "/register", "Create an account", false, http.StatusCreated, a.apiRegister),
+		newAPIRoute(http.MethodGet, "/login/salt", "Get the salt for deriving a password hash", false, http.StatusOK, a.apiLoginSalt),
+		newAPIRoute(http.MethodPost, "/login", "Exchange a password hash for a session token", false, http.StatusOK, a.apiLogin),
+		newAPIRoute(http.MethodPost, "/logout", "Revoke the session token", true, http.StatusNoContent, a.apiLogout),
+		newAPIRoute(http.MethodGet, "/balance", "Get the encrypted balance", true, http.StatusOK, a.apiGetBalance),
+		newAPIRoute(http.MethodPut, "/balance", "Replace the encrypted balance", true, http.StatusNoContent, a.apiUpdateBalance),
+		newAPIRoute(http.MethodGet, "/balances", "Get ledger balances per currency", true, http.StatusOK, a.apiGetBalances),
+		newAPIRoute(http.MethodGet, "/transactions", "List transactions", true, http.StatusOK, a.apiListTransactions),
+		newAPIRoute(http.MethodGet, "/transactions/export", "Download transactions as CSV, JSON Lines, OFX or QIF", true, http.StatusOK, a.apiExportTransactions),
+		newAPIRoute(http.MethodPost, "/topups", "Start a Stripe top-up", true, http.StatusCreated, a.apiTopUp),
+		newAPIRoute(http.MethodGet, "/events", "Stream transaction and payment updates as Server-Sent Events", true, http.StatusOK, a.apiEvents),
+	}
+}
+
+// registerAPIRoutes mounts the REST API on mux
+func (a *App) registerAPIRoutes(mux *http.ServeMux) {
+	for _, route := range a.apiRoutes() {
+		mux.HandleFunc(route.Method+" "+route.Path, a.serveAPI(route))
+	}
+	mux.HandleFunc(apiPrefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, r, errcode.New(errcode.NotFound, "no such endpoint"))
+	})
+
+	doc, err := a.openAPIDocument()
+	if err != nil {
+		slog.Warn("Could not generate OpenAPI document", "error", err)
+		return
+	}
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, doc)
+	})
+}
+
+func (a *App) serveAPI(route apiRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := &apiCall{w: w, r: r}
+		if route.Auth {
+			if err := a.authenticate(c); err != nil {
+				writeAPIError(w, r, err)
+				return
+			}
+		}
+
+		out, err := route.serve(c)
+		if err != nil {
+			writeAPIError(w, r, err)
+			return
+		}
+
+		switch v := out.(type) {
+		case noContent:
+			w.WriteHeader(http.StatusNoContent)
+		case *fileDownload:
+			w.Header().Set("Content-Type", v.ContentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", v.Filename))
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(route.Status)
+			// Headers are gone by now, so a failure can only be logged
+			if err := v.Write(w); err != nil {
+				slog.ErrorContext(r.Context(), "API download failed", "path", route.Path, "error", err)
+			}
+		case *eventStream:
+			a.streamEvents(w, r, v)
+		default:
+			writeJSON(w, route.Status, out)
+		}
+	}
+}
+
+// authenticate resolves the bearer token of a call to its session
+func (a *App) authenticate(c *apiCall) error {
+	token, ok := strings.CutPrefix(c.r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return errcode.New(errcode.Unauthorized, "missing bearer token")
+	}
+	if !a.dbAvailable() {
+		return errDatabaseUnavailable
+	}
+
+	session, err := a.wallet.Authenticate(c.r.Context(), token)
+	if err != nil {
+		return err
+	}
+	c.session = session.UserID
+	c.token = token
+	return nil
+}
+
+func (a *App) apiRegister(c *apiCall, in *RegisterRequest) (*AccountResponse, error) {
+	user, err := a.register(c.r.Context(), *in)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountResponse{
+		UserID:    user.UserID,
+		Login:     user.Login,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}, nil
+}
+
+// apiSaltKey returns the key fake login salts are derived from. Without a
+// configured secret a random one is used, so an unknown login's salt only
+// stays the same until the app restarts.
+func apiSaltKey(cfg *config.Config) []byte {
+	if cfg.APISaltSecret != "" {
+		return []byte(cfg.APISaltSecret)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+func (a *App) apiLoginSalt(c *apiCall, in *LoginSaltParams) (*LoginSaltResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	salt, err := a.wallet.LoginSalt(c.r.Context(), in.Login)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginSaltResponse{Salt: salt}, nil
+}
+
+func (a *App) apiLogin(c *apiCall, in *LoginRequest) (*LoginResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	login, err := a.wallet.Login(c.r.Context(), in.Login, in.PasswordHash)
+	if err != nil {
+		var loginErr *wallet.LoginError
+		if errors.As(err, &loginErr) {
+			reason := metrics.LoginWrongPassword
+			if loginErr.UnknownLogin {
+				reason = metrics.LoginUnknownUser
+			}
+			metrics.LoginFailures.WithLabelValues(reason).Inc()
+		}
+		return nil, err
+	}
+	return &LoginResponse{
+		Token:            login.Token,
+		ExpiresAt:        login.Session.ExpiresAt,
+		UserID:           login.User.UserID,
+		Login:            login.User.Login,
+		EncryptedBalance: login.User.EncryptedBalance,
+	}, nil
+}
+
+func (a *App) apiLogout(c *apiCall, in *noInput) (noContent, error) {
+	return noContent{}, a.wallet.Logout(c.r.Context(), c.token)
+}
+
+func (a *App) apiGetBalance(c *apiCall, in *noInput) (*BalanceResponse, error) {
+	return a.getBalance(c.r.Context(), c.session)
+}
+
+func (a *App) apiUpdateBalance(c *apiCall, in *BalanceUpdateRequest) (noContent, error) {
+	return noContent{}, a.updateBalance(c.r.Context(), BalanceRequest{
+		UserID:           c.session,
+		EncryptedBalance: in.EncryptedBalance,
+		ExpectedBalance:  in.ExpectedBalance,
+		CreditedEventID:  in.CreditedEventID,
+	})
+}
+
+func (a *App) apiGetBalances(c *apiCall, in *noInput) (*CurrencyBalancesResponse, error) {
+	return a.getCurrencyBalances(c.r.Context(), c.session)
+}
+
+func (a *App) apiListTransactions(c *apiCall, in *TransactionListParams) (*TransactionListResponse, error) {
+	return a.queryTransactions(c.r.Context(), TransactionQueryRequest{
+		UserID:    c.session,
+		Types:     in.Types,
+		Statuses:  in.Statuses,
+		Currency:  in.Currency,
+		DateFrom:  in.DateFrom,
+		DateTo:    in.DateTo,
+		MinAmount: in.MinAmount,
+		MaxAmount: in.MaxAmount,
+		Cursor:    in.Cursor,
+		Limit:     in.Limit,
+	})
+}
+
+func (a *App) apiExportTransactions(c *apiCall, in *ExportParams) (*fileDownload, error) {
+	format, err := export.ParseFormat(in.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate everything up front, while an error can still be reported
+	dbFilter, opts, err := a.prepareExport(c.r.Context(), c.session, format, in.ExportFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileDownload{
+		ContentType: format.ContentType(),
+		Filename:    fmt.Sprintf("pocket-wallet-%s.%s", time.Now().Format("2006-01-02"), format.Extension()),
+		Write: func(w io.Writer) error {
+			_, err := a.writeTransactions(c.r.Context(), w, format, opts, dbFilter)
+			return err
+		},
+	}, nil
+}
+
+func (a *App) apiTopUp(c *apiCall, in *TopUpRequest) (*StripePaymentIntentResponse, error) {
+	return a.createPaymentIntent(c.r.Context(), StripePaymentIntentRequest{
+		UserID:   c.session,
+		Amount:   in.Amount,
+		Currency: in.Currency,
+	})
+}
+
+func (a *App) apiEvents(c *apiCall, in *EventStreamParams) (*eventStream, error) {
+	stream := &eventStream{userID: c.session, lastEventID: in.LastEventID}
+	// Browsers send the header when they reconnect on their own
+	if header := c.r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			return nil, invalidInput("invalid Last-Event-ID header")
+		}
+		stream.lastEventID = id
+	}
+	return stream, nil
+}
+
+func writeAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	code := errcode.Of(err)
+	if !isCoded(err) {
+		slog.ErrorContext(r.Context(), "API request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	writeJSON(w, code.HTTPStatus(), APIError{Error: errorDetail(err, requestLanguage(r))})
+}
+
+// requestLanguage picks the client's preferred language from
+// Accept-Language, ignoring weights; messages default to English
+func requestLanguage(r *http.Request) string {
+	first, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	language, _, _ := strings.Cut(first, ";")
+	return strings.TrimSpace(language)
+}
+
+// decodeAPIInput fills in from the query string of a GET request or the
+// JSON body of any other. Unknown parameters and fields are rejected so
+// typos do not silently widen a query.
+func decodeAPIInput(c *apiCall, in any) error {
+	if _, ok := in.(*noInput); ok {
+		return nil
+	}
+
+	if c.r.Method == http.MethodGet {
+		if err := decodeQuery(c.r.URL.Query(), reflect.ValueOf(in).Elem()); err != nil {
+			return errcode.Wrap(errcode.InvalidInput, "invalid query", err)
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(c.w, c.r.Body, maxAPIBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(in); err != nil {
+		return errcode.Wrap(errcode.InvalidInput, "invalid JSON body", err)
+	}
+	return nil
+}
+
+// decodeQuery sets struct fields from query parameters. Lists are comma
+// separated and may also be given by repeating the parameter.
+func decodeQuery(values url.Values, v reflect.Value) error {
+	known := make(map[string]bool)
+	for _, field := range openapi.Fields(v.Type()) {
+		known[field.Name] = true
+		raw, ok := values[field.Name]
+		if !ok {
+			continue
+		}
+		if err := setQueryValue(v.FieldByIndex(field.Index), raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", field.Name, err)
+		}
+	}
+
+	for name := range values {
+		if !known[name] {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	return nil
+}
+
+func setQueryValue(v reflect.Value, raw []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		var items []string
+		for _, value := range raw {
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	}
+
+	value := raw[len(raw)-1]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("not an integer")
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("not a number")
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("not a boolean")
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
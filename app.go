@@ -3,18 +3,32 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"strings"
+	"sync"
+	"time"
 
+	"pocket-wallet/internal/currency"
 	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/fx"
+	"pocket-wallet/internal/health"
+	"pocket-wallet/internal/lifecycle"
+	"pocket-wallet/internal/logging"
+	"pocket-wallet/internal/metrics"
+	"pocket-wallet/internal/pubsub"
+	"pocket-wallet/internal/reconcile"
+	"pocket-wallet/internal/scheduler"
+	"pocket-wallet/internal/tracing"
+	"pocket-wallet/internal/wallet"
 
 	stripeService "pocket-wallet/internal/stripe"
 	"pocket-wallet/pkg/config"
 
 	"github.com/stripe/stripe-go/v76"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // App struct
@@ -23,7 +37,25 @@ type App struct {
 	config        *config.Config
 	db            *database.MongoDB
 	stripeService *stripeService.StripeService
+	wallet        *wallet.Service
+	reconciler    *reconcile.Reconciler
+	scheduler     *scheduler.Scheduler
+	lifecycle     *lifecycle.Manager
 	server        *http.Server
+	eventHub      *pubsub.Hub
+
+	stopTracing func(context.Context) error
+
+	// Closed when the HTTP server shuts down, ending event streams
+	serverClosing <-chan struct{}
+
+	// What the HTTP server is doing, for the frontend
+	serverMu     sync.Mutex
+	serverStatus ServerStatus
+
+	// User logged in to the window, who receives transaction events
+	eventsMu   sync.Mutex
+	activeUser string
 }
 
 // NewApp creates a new App application struct
@@ -38,21 +70,51 @@ func (a *App) startup(ctx context.Context) {
 
 	// Load configuration
 	a.config = config.Load()
+	setupLogging(a.config)
 
-	// Initialize database connection
+	// Clients are created up front and connect lazily, so a subsystem that
+	// comes up late needs no rewiring; only a bad configuration stops one
 	var err error
-	a.db, err = database.NewMongoDB(a.config)
+	a.db, err = database.OpenMongoDB(a.config)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		slog.Error("MongoDB disabled", "error", err)
 	}
 
 	// Initialize Stripe service
 	a.stripeService = stripeService.NewStripeService(a.config)
 
-	// Start HTTP server for webhooks
-	a.startHTTPServer()
+	// Initialize currency exchange; without a rate source quotes are refused
+	var quoter wallet.Quoter
+	rateSource, err := newRateSource(a.config)
+	if err != nil {
+		slog.Warn("Currency exchange disabled", "error", err)
+	} else {
+		quoter = fx.NewExchanger(rateSource, a.config.FXSpreadBps, a.config.FXQuoteTTL)
+	}
+
+	if a.db != nil {
+		// Business operations shared by the bindings, REST API and CLI
+		a.wallet = wallet.New(a.db, a.stripeService, quoter, wallet.Options{
+			SessionTTL: a.config.APISessionTTL,
+			SaltKey:    apiSaltKey(a.config),
+		})
+		a.reconciler = reconcile.New(a.db, a.stripeService)
+		a.reconciler.Changed = a.publishChange
+		a.scheduler = a.newScheduler()
+	}
+
+	a.eventHub = pubsub.NewHub()
 
-	log.Println("Application started successfully")
+	// Bring up tracing, MongoDB, the HTTP server and background jobs. One
+	// that fails leaves the app degraded and is retried.
+	a.lifecycle, err = lifecycle.New(lifecycle.Options{OnChange: a.subsystemChanged}, a.subsystems()...)
+	if err != nil {
+		slog.Error("Invalid subsystem setup", "error", err)
+		return
+	}
+	a.lifecycle.Start()
+
+	slog.Info("Application started")
 }
 
 // OnDomReady is called after front-end resources have been loaded
@@ -62,109 +124,104 @@ func (a *App) OnDomReady(ctx context.Context) {
 
 // OnBeforeClose is called when the application is about to quit
 func (a *App) OnBeforeClose(ctx context.Context) (prevent bool) {
-	if a.db != nil {
-		a.db.Close()
-	}
-	if a.server != nil {
-		a.server.Shutdown(ctx)
+	if a.lifecycle != nil {
+		stopCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := a.lifecycle.Stop(stopCtx); err != nil {
+			slog.Warn("Shutdown incomplete", "error", err)
+		}
 	}
 	return false
 }
 
 // OnShutdown is called when the application is shutting down
 func (a *App) OnShutdown(ctx context.Context) {
-	log.Println("Application shutting down")
+	slog.Info("Application shutting down")
 }
 
 // Register creates a new user account with real data validation
-func (a *App) Register(req RegisterRequest) (*User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database connection not available")
-	}
+func (a *App) Register(req RegisterRequest) (_ *User, err error) {
+	ctx, done := observe("Register")
+	defer done(&err)
 
-	// Validate input data
-	if req.Login == "" || req.Email == "" || req.Salt == "" || req.PasswordHash == "" {
-		return nil, fmt.Errorf("all fields are required")
-	}
+	return a.register(ctx, req)
+}
 
-	// Validate email format
-	if !strings.Contains(req.Email, "@") || !strings.Contains(req.Email, ".") {
-		return nil, fmt.Errorf("invalid email format")
+// register implements Register
+func (a *App) register(ctx context.Context, req RegisterRequest) (*User, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
 	}
 
-	// Convert to database type
-	dbReq := &database.RegisterRequest{
+	user, err := a.wallet.Register(ctx, database.RegisterRequest{
 		Login:        req.Login,
 		Email:        req.Email,
 		Salt:         req.Salt,
 		PasswordHash: req.PasswordHash,
-	}
-
-	// Create user with real data
-	dbUser, err := a.db.CreateUser(dbReq)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, err
 	}
+	return userFromDB(user), nil
+}
 
-	// Convert back to main type
-	user := &User{
-		UserID:           dbUser.UserID,
-		Login:            dbUser.Login,
-		Email:            dbUser.Email,
-		Salt:             dbUser.Salt,
-		PasswordHash:     dbUser.PasswordHash,
-		EncryptedBalance: dbUser.EncryptedBalance,
-		CreatedAt:        dbUser.CreatedAt,
-		UpdatedAt:        dbUser.UpdatedAt,
-	}
+// GetUserMeta retrieves real user metadata for login process
+func (a *App) GetUserMeta(login string) (_ *UserMetaResponse, err error) {
+	ctx, done := observe("GetUserMeta")
+	defer done(&err)
 
-	log.Printf("User registered successfully: %s", req.Login)
-	return user, nil
+	return a.getUserMeta(ctx, login)
 }
 
-// GetUserMeta retrieves real user metadata for login process
-func (a *App) GetUserMeta(login string) (*UserMetaResponse, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database connection not available")
+// getUserMeta implements GetUserMeta
+func (a *App) getUserMeta(ctx context.Context, login string) (*UserMetaResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
 	}
 
-	if login == "" {
-		return nil, fmt.Errorf("login is required")
+	user, err := a.wallet.UserByLogin(ctx, login)
+	if errors.Is(err, wallet.ErrUserNotFound) {
+		metrics.LoginFailures.WithLabelValues(metrics.LoginUnknownUser).Inc()
 	}
-
-	// Get full user data to include user_id
-	dbUser, err := a.db.GetUserByLogin(login)
 	if err != nil {
-		return nil, fmt.Errorf("user not found or database error: %w", err)
+		return nil, err
 	}
 
-	// Convert to main type
-	meta := &UserMetaResponse{
-		UserID:       dbUser.UserID,
-		Salt:         dbUser.Salt,
-		PasswordHash: dbUser.PasswordHash,
-	}
+	return &UserMetaResponse{
+		UserID:       user.UserID,
+		Salt:         user.Salt,
+		PasswordHash: user.PasswordHash,
+	}, nil
+}
 
-	return meta, nil
+// ReportLoginFailure counts a login whose password did not match. The
+// desktop client checks the password hash itself, so only it can tell.
+func (a *App) ReportLoginFailure() {
+	_, done := observe("ReportLoginFailure")
+	defer done(nil)
+
+	metrics.LoginFailures.WithLabelValues(metrics.LoginWrongPassword).Inc()
 }
 
 // GetUserByLogin retrieves full user data by login
-func (a *App) GetUserByLogin(login string) (*User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database connection not available")
-	}
+func (a *App) GetUserByLogin(login string) (_ *User, err error) {
+	ctx, done := observe("GetUserByLogin")
+	defer done(&err)
 
-	if login == "" {
-		return nil, fmt.Errorf("login is required")
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
 	}
 
-	dbUser, err := a.db.GetUserByLogin(login)
+	user, err := a.wallet.UserByLogin(ctx, login)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, err
 	}
+	return userFromDB(user), nil
+}
 
-	// Convert to main type
-	user := &User{
+// userFromDB converts a database user to the main type
+func userFromDB(dbUser *database.User) *User {
+	return &User{
 		UserID:           dbUser.UserID,
 		Login:            dbUser.Login,
 		Email:            dbUser.Email,
@@ -174,122 +231,132 @@ func (a *App) GetUserByLogin(login string) (*User, error) {
 		CreatedAt:        dbUser.CreatedAt,
 		UpdatedAt:        dbUser.UpdatedAt,
 	}
-
-	return user, nil
 }
 
 // UpdateBalance updates user's real encrypted balance
-func (a *App) UpdateBalance(req BalanceRequest) error {
-	if a.db == nil {
-		return fmt.Errorf("database connection not available")
-	}
+func (a *App) UpdateBalance(req BalanceRequest) (err error) {
+	ctx, done := observe("UpdateBalance")
+	defer done(&err)
 
-	if req.UserID == "" || req.EncryptedBalance == "" {
-		return fmt.Errorf("user_id and encrypted_balance are required")
-	}
+	return a.updateBalance(ctx, req)
+}
 
-	err := a.db.UpdateUserBalance(req.UserID, req.EncryptedBalance)
-	if err != nil {
-		return fmt.Errorf("failed to update balance: %w", err)
+// updateBalance implements UpdateBalance
+func (a *App) updateBalance(ctx context.Context, req BalanceRequest) error {
+	if !a.dbAvailable() {
+		return errDatabaseUnavailable
 	}
-
-	log.Printf("Balance updated for user: %s", req.UserID)
-	return nil
+	return a.wallet.UpdateBalance(ctx, req.UserID, req.EncryptedBalance, req.ExpectedBalance, req.CreditedEventID)
 }
 
 // GetBalance retrieves user's real encrypted balance
-func (a *App) GetBalance(userID string) (*BalanceResponse, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database connection not available")
-	}
+func (a *App) GetBalance(userID string) (_ *BalanceResponse, err error) {
+	ctx, done := observe("GetBalance")
+	defer done(&err)
+
+	return a.getBalance(ctx, userID)
+}
 
-	if userID == "" {
-		return nil, fmt.Errorf("user_id is required")
+// getBalance implements GetBalance
+func (a *App) getBalance(ctx context.Context, userID string) (*BalanceResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
 	}
 
-	user, err := a.db.GetUserByID(userID)
+	balance, credited, err := a.wallet.Balance(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, err
 	}
-
-	return &BalanceResponse{
-		EncryptedBalance: user.EncryptedBalance,
-	}, nil
+	return &BalanceResponse{EncryptedBalance: balance, CreditedEventID: credited}, nil
 }
 
 // CreatePaymentIntent creates a real Stripe payment intent
-func (a *App) CreatePaymentIntent(req StripePaymentIntentRequest) (*StripePaymentIntentResponse, error) {
-	if req.UserID == "" || req.Amount <= 0 {
-		return nil, fmt.Errorf("valid user_id and amount are required")
-	}
+func (a *App) CreatePaymentIntent(req StripePaymentIntentRequest) (_ *StripePaymentIntentResponse, err error) {
+	ctx, done := observe("CreatePaymentIntent")
+	defer done(&err)
 
-	// Verify user exists
-	_, err := a.db.GetUserByID(req.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
-	}
+	return a.createPaymentIntent(ctx, req)
+}
 
-	// Convert to stripe type
-	stripeReq := &stripeService.StripePaymentIntentRequest{
-		UserID: req.UserID,
-		Amount: req.Amount,
+// createPaymentIntent implements CreatePaymentIntent
+func (a *App) createPaymentIntent(ctx context.Context, req StripePaymentIntentRequest) (*StripePaymentIntentResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
 	}
 
-	stripeResponse, err := a.stripeService.CreatePaymentIntent(stripeReq)
+	topUp, err := a.wallet.TopUp(ctx, req.UserID, req.Amount, req.Currency)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment intent: %w", err)
-	}
-
-	// Create transaction record
-	transactionReq := &database.TransactionRequest{
-		UserID:      req.UserID,
-		Type:        "deposit",
-		Amount:      float64(req.Amount) / 100.0, // Convert cents to PLN
-		Currency:    "PLN",
-		Description: fmt.Sprintf("Doładowanie portfela - %.2f PLN", float64(req.Amount)/100.0),
-		PaymentID:   stripeResponse.PaymentID,
+		return nil, err
 	}
+	metrics.PaymentIntents.WithLabelValues(metrics.PaymentCreated).Inc()
+	trace.SpanFromContext(ctx).SetAttributes(tracing.PaymentID(topUp.PaymentID))
 
-	_, err = a.db.CreateTransaction(transactionReq)
-	if err != nil {
-		log.Printf("Warning: Failed to create transaction record: %v", err)
-		// Don't fail the payment intent creation if transaction record fails
-	}
+	return &StripePaymentIntentResponse{
+		ClientSecret: topUp.ClientSecret,
+		PaymentID:    topUp.PaymentID,
+	}, nil
+}
 
-	// Convert back to main type
-	response := &StripePaymentIntentResponse{
-		ClientSecret: stripeResponse.ClientSecret,
-		PaymentID:    stripeResponse.PaymentID,
+// GetSupportedCurrencies lists the currencies accepted for top-ups
+func (a *App) GetSupportedCurrencies() []CurrencyInfo {
+	_, done := observe("GetSupportedCurrencies")
+	defer done(nil)
+
+	supported := currency.Supported()
+	currencies := make([]CurrencyInfo, len(supported))
+	for i, c := range supported {
+		currencies[i] = CurrencyInfo{
+			Code:          c.Code,
+			MinorUnits:    c.MinorUnits,
+			MinimumAmount: c.StripeMin,
+		}
 	}
-
-	log.Printf("Payment intent created for user %s, amount: %d cents", req.UserID, req.Amount)
-	return response, nil
+	return currencies
 }
 
-// startHTTPServer starts the real HTTP server for Stripe webhooks
-func (a *App) startHTTPServer() {
+// startHTTPServer starts the real HTTP server for Stripe webhooks and the REST API
+func (a *App) startHTTPServer() error {
 	mux := http.NewServeMux()
 
 	// Real Stripe webhook endpoint
 	mux.HandleFunc("/stripe/webhook", a.handleStripeWebhook)
 
-	// Health check endpoint
+	// Health check endpoints: /health is kept for existing monitors,
+	// /livez and /readyz report structured status
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
+	mux.HandleFunc("/livez", a.handleLivez)
+	mux.HandleFunc("/readyz", a.handleReadyz)
+
+	// Prometheus metrics
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	// REST API mirroring the Wails-bound methods
+	a.registerAPIRoutes(mux)
 
+	cfg := a.config
 	a.server = &http.Server{
-		Addr:    ":" + a.config.ServerPort,
-		Handler: mux,
+		Handler:           logging.Middleware(tracing.Middleware(mux)),
+		ReadHeaderTimeout: cfg.ServerReadTimeout,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout, // Event streams lift it for themselves
+		IdleTimeout:       cfg.ServerIdleTimeout,
 	}
 
-	go func() {
-		log.Printf("HTTP server started on port %s for webhooks", a.config.ServerPort)
-		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("HTTP server error: %v", err)
-		}
-	}()
+	// Shutdown waits for idle connections, which streams never become
+	closing, closeStreams := context.WithCancel(context.Background())
+	a.server.RegisterOnShutdown(closeStreams)
+	a.serverClosing = closing.Done()
+
+	listener, err := a.listen()
+	if err != nil {
+		return err
+	}
+
+	go a.serve(a.server, listener)
+	return nil
 }
 
 // handleStripeWebhook handles real Stripe webhook events
@@ -299,16 +366,29 @@ func (a *App) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	// Stripe retries deliveries we cannot store
+	if !a.dbAvailable() {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := r.Context()
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, a.config.WebhookMaxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		slog.WarnContext(ctx, "Webhook body too large", "limit_bytes", tooLarge.Limit)
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
-		log.Printf("Error reading webhook body: %v", err)
+		slog.WarnContext(ctx, "Could not read webhook body", "error", err)
 		http.Error(w, "Error reading request body", http.StatusBadRequest)
 		return
 	}
 
 	signature := r.Header.Get("Stripe-Signature")
 	if signature == "" {
-		log.Println("Missing Stripe signature in webhook")
+		slog.WarnContext(ctx, "Missing Stripe signature in webhook")
 		http.Error(w, "Missing Stripe signature", http.StatusBadRequest)
 		return
 	}
@@ -316,7 +396,7 @@ func (a *App) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 	// Verify real webhook signature
 	err = a.stripeService.VerifyWebhookSignature(body, signature)
 	if err != nil {
-		log.Printf("Webhook signature verification failed: %v", err)
+		slog.WarnContext(ctx, "Webhook signature verification failed", "error", err)
 		http.Error(w, "Signature verification failed", http.StatusBadRequest)
 		return
 	}
@@ -325,28 +405,29 @@ func (a *App) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 	var event stripe.Event
 	err = json.Unmarshal(body, &event)
 	if err != nil {
-		log.Printf("Error parsing webhook JSON: %v", err)
+		slog.WarnContext(ctx, "Could not parse webhook JSON", "error", err)
 		http.Error(w, "Error parsing JSON", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Received Stripe webhook event: %s", event.Type)
+	ctx = logging.With(ctx, "event_id", event.ID, "event_type", string(event.Type))
+	slog.InfoContext(ctx, "Received Stripe webhook event")
 
-	// Handle real payment success
-	if event.Type == "payment_intent.succeeded" {
-		err = a.handlePaymentSuccess(&event)
-		if err != nil {
-			log.Printf("Error handling payment success: %v", err)
-			http.Error(w, "Error processing payment", http.StatusInternalServerError)
-			return
-		}
+	// Keep the raw event so a failure can be inspected and replayed
+	if err := a.db.RecordWebhookEvent(ctx, a.stripeService.Name(), event.ID, string(event.Type), body); err != nil {
+		slog.WarnContext(ctx, "Could not record webhook event", "error", err)
+	}
+
+	if err := a.processStripeEvent(ctx, &event); err != nil {
+		http.Error(w, "Error processing event", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
 // handlePaymentSuccess processes real successful payment
-func (a *App) handlePaymentSuccess(event *stripe.Event) error {
+func (a *App) handlePaymentSuccess(ctx context.Context, event *stripe.Event) error {
 	var paymentIntent stripe.PaymentIntent
 	err := json.Unmarshal(event.Data.Raw, &paymentIntent)
 	if err != nil {
@@ -359,135 +440,322 @@ func (a *App) handlePaymentSuccess(event *stripe.Event) error {
 	}
 
 	// Verify user exists in database
-	user, err := a.db.GetUserByID(userID)
+	user, err := a.db.GetUserByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("user not found in database: %w", err)
 	}
 
-	// Update transaction status to completed
-	transaction, err := a.db.GetTransactionByPaymentID(paymentIntent.ID)
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(tracing.PaymentID(paymentIntent.ID))
+
+	// Errors are returned so the event is recorded failed and can be
+	// replayed; Stripe also retries it
+	transaction, err := a.db.GetTransactionByPaymentID(ctx, paymentIntent.ID)
 	if err != nil {
-		log.Printf("Warning: Could not find transaction for payment ID %s: %v", paymentIntent.ID, err)
-	} else {
-		err = a.db.UpdateTransactionStatus(transaction.TransactionID, "completed")
-		if err != nil {
-			log.Printf("Warning: Could not update transaction status: %v", err)
+		return fmt.Errorf("failed to get transaction for payment %s: %w", paymentIntent.ID, err)
+	}
+	// Tie the webhook to the top-up that created the payment
+	if link, ok := tracing.LinkTo(transaction.TraceParent); ok {
+		span.AddLink(link)
+	}
+
+	settled, err := a.db.UpdateTransactionStatus(ctx, transaction.TransactionID, "completed")
+	if err != nil {
+		return err
+	}
+	if !settled {
+		// A redelivery, or reconciliation completed the deposit first.
+		// Events a failed delivery did not log are recorded now.
+		if transaction.EventsPending {
+			return a.publishTransaction(ctx, transaction)
 		}
+		return nil
 	}
 
-	log.Printf("Payment of %d cents successful for user %s (%s)",
-		paymentIntent.Amount, userID, user.Login)
+	metrics.PaymentIntents.WithLabelValues(metrics.PaymentSucceeded).Inc()
+	slog.InfoContext(ctx, "Payment succeeded",
+		"payment_id", paymentIntent.ID, "amount_minor", paymentIntent.Amount, "user_id", userID, "login", user.Login)
 
 	// Note: The actual balance update will be handled by the frontend
-	// since only the frontend has access to the user's encryption key
-	// This webhook serves as confirmation that the payment was processed
+	// since only the frontend has access to the user's encryption key.
+	// It credits the balance from the payment:succeeded event, so failing
+	// to log it fails the webhook, to be retried.
+	return a.publishPayment(ctx, paymentIntent.ID)
+}
 
-	return nil
+// handlePaymentFailure marks the deposit of a declined payment failed. The
+// customer may still retry with another card; a later success completes it.
+func (a *App) handlePaymentFailure(ctx context.Context, event *stripe.Event) error {
+	var paymentIntent stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &paymentIntent); err != nil {
+		return fmt.Errorf("error parsing payment intent: %w", err)
+	}
+	trace.SpanFromContext(ctx).SetAttributes(tracing.PaymentID(paymentIntent.ID))
+
+	reason := "payment failed"
+	if paymentIntent.LastPaymentError != nil && paymentIntent.LastPaymentError.Msg != "" {
+		reason += ": " + paymentIntent.LastPaymentError.Msg
+	}
+
+	transaction, err := a.db.GetTransactionByPaymentID(ctx, paymentIntent.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not find transaction for payment", "payment_id", paymentIntent.ID, "error", err)
+		metrics.PaymentIntents.WithLabelValues(metrics.PaymentFailed).Inc()
+		return nil
+	}
+
+	changed, err := a.db.TransitionTransactionStatus(ctx, transaction.TransactionID, "pending", "failed", reason)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		// Already settled, or a redelivery of this event
+		if transaction.EventsPending {
+			return a.publishTransaction(ctx, transaction)
+		}
+		return nil
+	}
+
+	metrics.PaymentIntents.WithLabelValues(metrics.PaymentFailed).Inc()
+	slog.InfoContext(ctx, "Payment failed", "payment_id", paymentIntent.ID, "user_id", transaction.UserID, "reason", reason)
+	return a.publishPayment(ctx, paymentIntent.ID)
 }
 
 // GetStripePublishableKey returns the real Stripe publishable key for frontend
 func (a *App) GetStripePublishableKey() string {
-	log.Printf("Debug: Stripe publishable key: '%s'", a.config.StripePublishableKey)
+	_, done := observe("GetStripePublishableKey")
+	defer done(nil)
+
 	if a.config.StripePublishableKey == "" {
-		log.Printf("Warning: Stripe publishable key is empty!")
+		slog.Warn("Stripe publishable key is empty")
 	}
 	return a.config.StripePublishableKey
 }
 
 // ValidateUserSession validates if user session is active (helper method)
-func (a *App) ValidateUserSession(userID string) (bool, error) {
-	if userID == "" {
-		return false, fmt.Errorf("user_id is required")
+func (a *App) ValidateUserSession(userID string) (_ bool, err error) {
+	ctx, done := observe("ValidateUserSession")
+	defer done(&err)
+
+	return a.validateSession(ctx, userID)
+}
+
+// validateSession implements ValidateUserSession
+func (a *App) validateSession(ctx context.Context, userID string) (bool, error) {
+	if !a.dbAvailable() {
+		return false, errDatabaseUnavailable
 	}
 
-	_, err := a.db.GetUserByID(userID)
-	if err != nil {
+	if _, err := a.wallet.User(ctx, userID); err != nil {
 		return false, fmt.Errorf("invalid user session: %w", err)
 	}
-
 	return true, nil
 }
 
-// ConvertAmountToCents converts PLN amount to cents for Stripe
-func (a *App) ConvertAmountToCents(amount float64) int64 {
-	return int64(amount * 100)
+// ConvertAmountToCents converts a major-unit amount to the minor units
+// Stripe charges in, e.g. 12.34 PLN to 1234 and 500 JPY to 500
+func (a *App) ConvertAmountToCents(amount float64, code string) (_ int64, err error) {
+	_, done := observe("ConvertAmountToCents")
+	defer done(&err)
+
+	code, err = currency.Normalize(code)
+	if err != nil {
+		return 0, err
+	}
+	return currency.ToMinor(amount, code), nil
 }
 
-// ConvertCentsToAmount converts cents back to PLN amount
-func (a *App) ConvertCentsToAmount(cents int64) float64 {
-	return float64(cents) / 100.0
+// ConvertCentsToAmount converts minor units of a currency back to a
+// major-unit amount
+func (a *App) ConvertCentsToAmount(cents int64, code string) (_ float64, err error) {
+	_, done := observe("ConvertCentsToAmount")
+	defer done(&err)
+
+	code, err = currency.Normalize(code)
+	if err != nil {
+		return 0, err
+	}
+	return currency.FromMinor(cents, code), nil
 }
 
 // GetUserTransactions retrieves transaction history for a user
-func (a *App) GetUserTransactions(userID string, limit int) (*TransactionListResponse, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database connection not available")
+func (a *App) GetUserTransactions(userID string, limit int) (_ *TransactionListResponse, err error) {
+	ctx, done := observe("GetUserTransactions")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
 	}
 
-	if userID == "" {
-		return nil, fmt.Errorf("user_id is required")
+	dbTransactions, total, err := a.wallet.RecentTransactions(ctx, userID, limit)
+	if err != nil {
+		return nil, err
 	}
 
-	// Verify user exists
-	_, err := a.db.GetUserByID(userID)
+	transactions := make([]Transaction, len(dbTransactions))
+	for i, dbTx := range dbTransactions {
+		transactions[i] = *transactionFromDB(dbTx)
+	}
+
+	return &TransactionListResponse{
+		Transactions: transactions,
+		Total:        int(total),
+	}, nil
+}
+
+// QueryTransactions retrieves one page of filtered transaction history.
+// Pages are ordered newest first; pass NextCursor back to continue.
+func (a *App) QueryTransactions(req TransactionQueryRequest) (_ *TransactionListResponse, err error) {
+	ctx, done := observe("QueryTransactions")
+	defer done(&err)
+
+	return a.queryTransactions(ctx, req)
+}
+
+// queryTransactions implements QueryTransactions
+func (a *App) queryTransactions(ctx context.Context, req TransactionQueryRequest) (*TransactionListResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	filter, err := transactionFilterFromRequest(req)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, err
 	}
 
-	// Get transactions from database
-	dbTransactions, err := a.db.GetUserTransactions(userID, limit)
+	page, err := a.wallet.QueryTransactions(ctx, &database.TransactionQuery{
+		TransactionFilter: *filter,
+		Cursor:            req.Cursor,
+		Limit:             req.Limit,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+		return nil, err
 	}
 
-	// Convert database transactions to main types
-	transactions := make([]Transaction, len(dbTransactions))
-	for i, dbTx := range dbTransactions {
-		transactions[i] = Transaction{
-			TransactionID: dbTx.TransactionID,
-			UserID:        dbTx.UserID,
-			Type:          dbTx.Type,
-			Amount:        dbTx.Amount,
-			Currency:      dbTx.Currency,
-			Status:        dbTx.Status,
-			Description:   dbTx.Description,
-			PaymentID:     dbTx.PaymentID,
-			CreatedAt:     dbTx.CreatedAt,
-			UpdatedAt:     dbTx.UpdatedAt,
-		}
+	transactions := make([]Transaction, len(page.Transactions))
+	for i, dbTx := range page.Transactions {
+		transactions[i] = *transactionFromDB(dbTx)
 	}
 
-	response := &TransactionListResponse{
+	return &TransactionListResponse{
 		Transactions: transactions,
-		Total:        len(transactions),
+		Total:        int(page.Total),
+		NextCursor:   page.NextCursor,
+	}, nil
+}
+
+// transactionFilterFromRequest validates query parameters and converts them to a database filter
+func transactionFilterFromRequest(req TransactionQueryRequest) (*database.TransactionFilter, error) {
+	filter := &database.TransactionFilter{
+		UserID:    req.UserID,
+		Types:     req.Types,
+		Statuses:  req.Statuses,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
 	}
 
-	log.Printf("Retrieved %d transactions for user %s", len(transactions), userID)
-	return response, nil
-}
+	if req.Currency != "" {
+		code, err := currency.Normalize(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		filter.Currency = code
+	}
 
-// GetDatabaseStatus returns current database connection status
-func (a *App) GetDatabaseStatus() map[string]interface{} {
-	status := map[string]interface{}{
-		"connected": false,
-		"error":     "",
+	if req.MinAmount < 0 || req.MaxAmount < 0 || (req.MaxAmount > 0 && req.MinAmount > req.MaxAmount) {
+		return nil, invalidInput("invalid amount range")
 	}
 
-	if a.db == nil {
-		status["error"] = "Database not initialized"
-		return status
+	var err error
+	if req.DateFrom != "" {
+		if filter.From, err = parseDate(req.DateFrom, false); err != nil {
+			return nil, invalidInput("invalid date_from: %v", err)
+		}
 	}
+	if req.DateTo != "" {
+		if filter.To, err = parseDate(req.DateTo, true); err != nil {
+			return nil, invalidInput("invalid date_to: %v", err)
+		}
+	}
+
+	return filter, nil
+}
 
-	// Try to perform a simple operation to check connection
-	_, err := a.db.GetUserByID("health-check")
+// parseDate accepts RFC 3339 timestamps or plain dates; a plain end date
+// covers the whole day
+func parseDate(value string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
 	if err != nil {
-		// This is expected for health check, but if we get a connection error, that's bad
-		if strings.Contains(err.Error(), "connection") || strings.Contains(err.Error(), "timeout") {
-			status["error"] = err.Error()
-			return status
-		}
+		return time.Time{}, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// transactionFromDB converts a database transaction to the main type
+func transactionFromDB(dbTx *database.Transaction) *Transaction {
+	return &Transaction{
+		TransactionID: dbTx.TransactionID,
+		UserID:        dbTx.UserID,
+		Type:          dbTx.Type,
+		Amount:        dbTx.Amount,
+		Currency:      dbTx.Currency,
+		Status:        dbTx.Status,
+		Description:   dbTx.Description,
+		Counterparty:  dbTx.Counterparty,
+		Notes:         dbTx.Notes,
+		PaymentID:     dbTx.PaymentID,
+		ExchangeID:    dbTx.ExchangeID,
+		BankReference: dbTx.BankReference,
+		StatusHistory: statusHistoryFromDB(dbTx.StatusHistory),
+		CreatedAt:     dbTx.CreatedAt,
+		UpdatedAt:     dbTx.UpdatedAt,
+	}
+}
+
+// statusHistoryFromDB converts a transaction's status history
+func statusHistoryFromDB(changes []database.StatusChange) []StatusChange {
+	if len(changes) == 0 {
+		return nil
 	}
+	history := make([]StatusChange, len(changes))
+	for i, c := range changes {
+		history[i] = StatusChange{Status: c.Status, ChangedAt: c.ChangedAt, Reason: c.Reason}
+	}
+	return history
+}
 
-	status["connected"] = true
-	return status
+// GetDatabaseStatus pings MongoDB and checks the other components the
+// app depends on, reporting per-component status and latency
+func (a *App) GetDatabaseStatus() *HealthReport {
+	ctx, done := observe("GetDatabaseStatus")
+	defer done(nil)
+
+	report := a.newHealthChecker().Run(ctx)
+
+	result := &HealthReport{
+		Status:     string(report.Status),
+		Components: make([]HealthComponent, len(report.Components)),
+		CheckedAt:  report.CheckedAt,
+	}
+	for i, c := range report.Components {
+		result.Components[i] = HealthComponent{
+			Name:      c.Name,
+			Status:    string(c.Status),
+			Critical:  c.Critical,
+			LatencyMs: c.LatencyMs,
+			Error:     c.Error,
+			Details:   c.Details,
+		}
+		if c.Name == "mongodb" {
+			result.Connected = c.Status == health.StatusOK
+			result.Error = c.Error
+		}
+	}
+	return result
 }
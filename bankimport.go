@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"pocket-wallet/internal/bankimport"
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ImportBankStatement lets the user pick an MT940 or CAMT.053 file and
+// records its entries as external transactions for reconciliation
+func (a *App) ImportBankStatement(userID string) (_ *BankImportResult, err error) {
+	ctx, done := observe("ImportBankStatement")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	if _, err := a.validateSession(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import wyciągu bankowego",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Wyciągi bankowe (*.sta, *.mt940, *.txt, *.xml)", Pattern: "*.sta;*.mt940;*.txt;*.xml"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file dialog: %w", err)
+	}
+	if path == "" {
+		return &BankImportResult{Cancelled: true}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement file: %w", err)
+	}
+
+	result, err := a.importBankStatement(ctx, userID, data)
+	if err != nil {
+		return nil, err
+	}
+	result.Path = path
+
+	slog.Info("Bank statement imported",
+		"user_id", userID, "imported", result.Imported, "booked", result.Booked, "duplicates", result.Duplicates, "errors", len(result.Errors))
+	return result, nil
+}
+
+// importBankStatement parses statement data and stores new entries
+func (a *App) importBankStatement(ctx context.Context, userID string, data []byte) (*BankImportResult, error) {
+	stmt, err := bankimport.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BankImportResult{
+		Format:    string(stmt.Format),
+		AccountID: stmt.AccountID,
+		Errors:    make([]ImportLineError, 0, len(stmt.Errors)),
+	}
+	for _, e := range stmt.Errors {
+		result.Errors = append(result.Errors, ImportLineError{Line: e.Line, Text: e.Text, Reason: e.Reason})
+	}
+
+	for _, entry := range stmt.Entries {
+		code, err := currency.Normalize(entry.Currency)
+		if err != nil || entry.Currency == "" {
+			result.Errors = append(result.Errors, ImportLineError{
+				Line:   entry.Line,
+				Text:   entry.BankReference,
+				Reason: fmt.Sprintf("unsupported or missing currency %q", entry.Currency),
+			})
+			continue
+		}
+
+		amount := entry.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		status := "completed"
+		if !entry.Booked {
+			status = "pending"
+		}
+
+		_, outcome, err := a.db.CreateExternalTransaction(ctx, &database.ExternalTransactionRequest{
+			UserID:        userID,
+			Credit:        entry.Amount > 0,
+			Amount:        float64(amount) / 100.0, // Statement amounts are in hundredths
+			Currency:      code,
+			Status:        status,
+			Description:   entry.Description,
+			Counterparty:  entry.Counterparty,
+			BankReference: stmt.AccountID + "/" + entry.BankReference,
+			BookedAt:      entry.BookingDate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to import entry %s: %w", entry.BankReference, err)
+		}
+		switch outcome {
+		case database.ExternalCreated:
+			result.Imported++
+		case database.ExternalBooked:
+			result.Booked++
+		default:
+			result.Duplicates++
+		}
+	}
+
+	return result, nil
+}
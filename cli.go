@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/reconcile"
+	stripeService "pocket-wallet/internal/stripe"
+	"pocket-wallet/pkg/config"
+)
+
+// runCommand runs a command-line subcommand instead of the desktop app and
+// returns the process exit code; ok is false for unknown commands
+func runCommand(args []string) (code int, ok bool) {
+	switch args[0] {
+	case "reconcile":
+		return runReconcileCommand(args[1:]), true
+	case "webhooks":
+		return runWebhooksCommand(args[1:]), true
+	case "openapi":
+		return runOpenAPICommand(), true
+	}
+	return 0, false
+}
+
+// runReconcileCommand reconciles payments with Stripe once and prints the
+// discrepancy report. It exits with 2 when discrepancies remain unresolved.
+func runReconcileCommand(args []string) int {
+	cfg := config.Load()
+	setupLogging(cfg)
+
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	from := flags.String("from", "", "window start, YYYY-MM-DD or RFC 3339 (default: now minus -window)")
+	to := flags.String("to", "", "window end, YYYY-MM-DD (inclusive) or RFC 3339 (default: now)")
+	window := flags.Duration("window", cfg.ReconcileWindow, "window length when -from is not given")
+	dryRun := flags.Bool("dry-run", false, "report discrepancies without fixing them")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	end := time.Now()
+	if *to != "" {
+		t, err := parseDate(*to, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -to: %v\n", err)
+			return 1
+		}
+		end = t
+	}
+	start := end.Add(-*window)
+	if *from != "" {
+		t, err := parseDate(*from, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -from: %v\n", err)
+			return 1
+		}
+		start = t
+	}
+
+	if cfg.StripeSecretKey == "" {
+		fmt.Fprintln(os.Stderr, "STRIPE_SECRET_KEY is not set")
+		return 1
+	}
+
+	db, err := database.NewMongoDB(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to MongoDB: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	reconciler := reconcile.New(db, stripeService.NewStripeService(cfg))
+	reconciler.Changed = (&App{db: db}).publishChange
+	report, err := reconciler.Run(ctx, start, end, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reconciliation failed: %v\n", err)
+		return 1
+	}
+
+	if err := db.SaveReconciliationReport(ctx, report); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			return 1
+		}
+	} else {
+		printReconciliationReport(report)
+	}
+
+	if reconcile.Unresolved(report) > 0 {
+		return 2
+	}
+	return 0
+}
+
+func printReconciliationReport(report *database.ReconciliationReport) {
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run)"
+	}
+	fmt.Printf("%s reconciliation %s - %s%s\n", report.Provider,
+		report.From.Format(time.RFC3339), report.To.Format(time.RFC3339), mode)
+	fmt.Printf("Checked: %d  Matched: %d  Fixed: %d  Unresolved: %d\n\n",
+		report.Checked, report.Matched, report.Fixed, reconcile.Unresolved(report))
+
+	if len(report.Discrepancies) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tPAYMENT\tTRANSACTION\tFIXED\tDETAIL")
+	for _, d := range report.Discrepancies {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", d.Kind, d.PaymentID, d.TransactionID, d.Fixed, d.Detail)
+	}
+	w.Flush()
+}
+
+// runWebhooksCommand inspects and replays stored webhook events:
+//
+//	webhooks list [-status failed] [-limit 50]
+//	webhooks replay <event-id>...
+func runWebhooksCommand(args []string) int {
+	usage := "usage: webhooks list [-status failed|processed|ignored|received|all] [-limit n]\n       webhooks replay <event-id>..."
+	if len(args) == 0 || (args[0] != "list" && args[0] != "replay") {
+		fmt.Fprintln(os.Stderr, usage)
+		return 1
+	}
+
+	cfg := config.Load()
+	setupLogging(cfg)
+	db, err := database.NewMongoDB(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to MongoDB: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	app := &App{config: cfg, db: db, stripeService: stripeService.NewStripeService(cfg)}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("webhooks list", flag.ContinueOnError)
+		status := flags.String("status", database.WebhookStatusFailed, "only events with this status, or \"all\"")
+		limit := flags.Int("limit", 50, "maximum number of events")
+		if err := flags.Parse(args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 1
+		}
+		if *status == "all" {
+			*status = ""
+		}
+
+		events, err := app.getWebhookEvents(context.Background(), *status, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "EVENT\tTYPE\tSTATUS\tATTEMPTS\tRECEIVED\tERROR")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				e.EventID, e.Type, e.Status, e.Attempts, e.ReceivedAt.Local().Format(time.DateTime), e.Error)
+		}
+		w.Flush()
+		return 0
+
+	case "replay":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, usage)
+			return 1
+		}
+		code := 0
+		for _, id := range args[1:] {
+			event, err := app.ReplayWebhookEvent(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+				code = 2
+				continue
+			}
+			fmt.Printf("%s: %s\n", id, event.Status)
+		}
+		return code
+	}
+
+	return 1
+}
+
+// runOpenAPICommand prints the REST API's OpenAPI document, for generating
+// clients without starting the app
+func runOpenAPICommand() int {
+	doc, err := (&App{}).openAPIDocument()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"pocket-wallet/internal/currency"
+)
+
+// GetPendingCredits lists settled deposits not yet added to the user's
+// encrypted balance, oldest first. Only the frontend holds the encryption
+// key, so it credits each with UpdateBalance, passing the event ID. Called
+// after login it catches up on deposits settled while the user was away.
+// The encrypted balance is kept in the default currency; deposits in other
+// currencies only count towards the ledger balances.
+func (a *App) GetPendingCredits(userID string) (_ []*PendingCredit, err error) {
+	ctx, done := observe("GetPendingCredits")
+	defer done(&err)
+
+	return a.getPendingCredits(ctx, userID)
+}
+
+// getPendingCredits implements GetPendingCredits
+func (a *App) getPendingCredits(ctx context.Context, userID string) ([]*PendingCredit, error) {
+	const batch = 100
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	user, err := a.wallet.User(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deposits settled while their events could not be logged are credited
+	// too
+	if err := a.publishPendingEvents(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	credits := []*PendingCredit{}
+	if user.CreditedEventID == nil {
+		// Deposits of accounts from before the marker were credited as
+		// their events arrived, so tracking starts after the newest event
+		last, err := a.db.LastUserEventID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return credits, a.db.InitUserCreditMarker(ctx, userID, last)
+	}
+
+	after := *user.CreditedEventID
+	for {
+		events, err := a.db.GetUserEventsAfter(ctx, userID, after, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			after = event.ID
+			if event.Type != eventPaymentSucceeded {
+				continue
+			}
+
+			var transaction Transaction
+			if err := json.Unmarshal([]byte(event.Data), &transaction); err != nil {
+				slog.WarnContext(ctx, "Could not decode deposit event", "event_id", event.ID, "error", err)
+				continue
+			}
+			if transaction.Currency != currency.Default {
+				continue
+			}
+			credits = append(credits, &PendingCredit{EventID: event.ID, Transaction: &transaction})
+		}
+		if len(events) < batch {
+			return credits, nil
+		}
+	}
+}
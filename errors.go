@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"pocket-wallet/internal/errcode"
+)
+
+// Returned while a subsystem a call needs is not running
+var (
+	errDatabaseUnavailable  = errcode.New(errcode.Unavailable, "database connection not available")
+	errSchedulerUnavailable = errcode.New(errcode.Unavailable, "scheduler not running")
+)
+
+// invalidInput reports a request refused during validation
+func invalidInput(format string, args ...any) error {
+	return errcode.New(errcode.InvalidInput, fmt.Sprintf(format, args...))
+}
+
+// errorDetail describes err in the given language. Only coded errors
+// carry their own description; anything else may hold internal detail
+// such as driver messages, so it is reported as a generic failure
+func errorDetail(err error, language string) ErrorDetail {
+	code := errcode.Of(err)
+	detail := code.Message(errcode.DefaultLanguage)
+	if isCoded(err) {
+		detail = err.Error()
+	}
+	return ErrorDetail{
+		Code:    string(code),
+		Message: code.Message(language),
+		Detail:  detail,
+	}
+}
+
+// isCoded reports whether err carries an errcode.Error
+func isCoded(err error) bool {
+	var coded *errcode.Error
+	return errors.As(err, &coded)
+}
+
+// formatError makes bound methods reject their promise with an
+// ErrorDetail instead of a bare string, so the frontend can act on codes.
+// Messages are in the configured interface language.
+func (a *App) formatError(err error) any {
+	if !isCoded(err) {
+		slog.Error("Request failed", "error", err)
+	}
+	language := errcode.DefaultLanguage
+	if a.config != nil {
+		language = a.config.UILanguage
+	}
+	return errorDetail(err, language)
+}
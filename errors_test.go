@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"pocket-wallet/internal/errcode"
+	"pocket-wallet/pkg/config"
+)
+
+func TestFormatErrorLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *config.Config
+		err      error
+		wantCode errcode.Code
+		wantMsg  string
+	}{
+		{"default Polish", nil, errDatabaseUnavailable, errcode.Unavailable, errcode.Unavailable.Message("pl")},
+		{"configured English", &config.Config{UILanguage: "en"}, errSchedulerUnavailable, errcode.Unavailable, errcode.Unavailable.Message("en")},
+		{"uncoded", &config.Config{UILanguage: "en"}, errors.New("driver: socket closed"), errcode.RequestFailed, errcode.RequestFailed.Message("en")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{config: tt.config}
+			detail, ok := a.formatError(tt.err).(ErrorDetail)
+			if !ok {
+				t.Fatalf("formatError() returned %T, want ErrorDetail", a.formatError(tt.err))
+			}
+			if detail.Code != string(tt.wantCode) || detail.Message != tt.wantMsg {
+				t.Errorf("formatError() = %s %q, want %s %q", detail.Code, detail.Message, tt.wantCode, tt.wantMsg)
+			}
+			if detail.Detail == "driver: socket closed" {
+				t.Error("internal detail of an uncoded error reached the client")
+			}
+		})
+	}
+}
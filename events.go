@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"pocket-wallet/internal/database"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Wails events sent to the frontend when a webhook, reconciliation or
+// deposit expiry changes a transaction. The payload of each is the updated
+// Transaction.
+const (
+	eventPaymentSucceeded   = "payment:succeeded"
+	eventPaymentFailed      = "payment:failed"
+	eventTransactionUpdated = "transaction:updated"
+)
+
+// SetActiveUser tells the backend which user is logged in to the window,
+// so only their transaction events reach it
+func (a *App) SetActiveUser(userID string) (err error) {
+	ctx, done := observe("SetActiveUser")
+	defer done(&err)
+
+	if _, err := a.validateSession(ctx, userID); err != nil {
+		return err
+	}
+
+	a.eventsMu.Lock()
+	a.activeUser = userID
+	a.eventsMu.Unlock()
+	return nil
+}
+
+// ClearActiveUser stops transaction events after logout
+func (a *App) ClearActiveUser() {
+	_, done := observe("ClearActiveUser")
+	defer done(nil)
+
+	a.eventsMu.Lock()
+	a.activeUser = ""
+	a.eventsMu.Unlock()
+}
+
+// publishPayment records the events of the transaction a payment created
+// and sends them to the window, see publishTransaction
+func (a *App) publishPayment(ctx context.Context, paymentID string) error {
+	t, err := a.db.GetTransactionByPaymentID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to load transaction for events: %w", err)
+	}
+	return a.publishTransaction(ctx, t)
+}
+
+// publishChange announces a transaction changed without a webhook, e.g. by
+// reconciliation or deposit expiry. A failure is only logged: the events
+// stay pending and are recorded before the user's next credits are listed.
+func (a *App) publishChange(ctx context.Context, transactionID string) {
+	t, err := a.db.GetTransactionByID(ctx, transactionID)
+	if err == nil {
+		err = a.publishTransaction(ctx, t)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Could not record transaction events", "transaction_id", transactionID, "error", err)
+	}
+}
+
+// publishPendingEvents records the events of the user's transactions whose
+// status changed without them being logged
+func (a *App) publishPendingEvents(ctx context.Context, userID string) error {
+	transactions, err := a.db.GetTransactionsWithPendingEvents(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		if err := a.publishTransaction(ctx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paymentEvent names the event a Stripe deposit's status calls for: the
+// window credits the balance on payment:succeeded. Other transactions and
+// statuses have none.
+func paymentEvent(t *database.Transaction) string {
+	if t.Type != "deposit" || t.PaymentID == "" {
+		return ""
+	}
+	switch t.Status {
+	case "completed":
+		return eventPaymentSucceeded
+	case "failed":
+		return eventPaymentFailed
+	}
+	return ""
+}
+
+// publishTransaction records t's payment event, if its status has one, and
+// transaction:updated in the user's event log, for event streams and
+// credits, and sends both to the window when the user is logged in to it.
+// The payment event is logged at most once per transaction and status, so
+// a retry does not credit a deposit twice. Only a failure to log it is
+// returned: the credit depends on it, while transaction:updated just
+// refreshes views.
+func (a *App) publishTransaction(ctx context.Context, t *database.Transaction) error {
+	payload := transactionFromDB(t)
+	event := paymentEvent(t)
+	if event != "" {
+		err := a.recordEvent(ctx, t.UserID, event, event+":"+t.TransactionID, payload)
+		if errors.Is(err, database.ErrEventRecorded) {
+			// Published before, only clearing the flag failed
+			return a.db.ClearTransactionEventsPending(ctx, t.TransactionID, t.Status)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := a.recordEvent(ctx, t.UserID, eventTransactionUpdated, "", payload); err != nil {
+		slog.WarnContext(ctx, "Could not record event", "type", eventTransactionUpdated, "user_id", t.UserID, "error", err)
+	}
+	if err := a.db.ClearTransactionEventsPending(ctx, t.TransactionID, t.Status); err != nil {
+		// The payment event is keyed, so publishing again is harmless
+		slog.WarnContext(ctx, "Could not clear pending events", "transaction_id", t.TransactionID, "error", err)
+	}
+
+	if a.isActiveUser(t.UserID) {
+		if event != "" {
+			runtime.EventsEmit(a.ctx, event, payload)
+		}
+		runtime.EventsEmit(a.ctx, eventTransactionUpdated, payload)
+		slog.DebugContext(ctx, "Transaction events sent", "transaction_id", t.TransactionID, "event", event)
+	}
+	return nil
+}
+
+// recordEvent appends an event to the user's log and wakes their streams.
+// A non-empty key makes it idempotent, see database.AppendUserEvent.
+func (a *App) recordEvent(ctx context.Context, userID, eventType, key string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	if _, err := a.db.AppendUserEvent(ctx, userID, eventType, key, data); err != nil {
+		return err
+	}
+	// The CLI has no streams; the app's pick its events up when polling
+	if a.eventHub != nil {
+		a.eventHub.Notify(userID)
+	}
+	return nil
+}
+
+// isActiveUser reports whether userID is logged in to the window. Outside
+// the desktop app, e.g. a replay from the command line, there is no window
+// to notify.
+func (a *App) isActiveUser(userID string) bool {
+	if a.ctx == nil {
+		return false
+	}
+	a.eventsMu.Lock()
+	defer a.eventsMu.Unlock()
+	return a.activeUser != "" && a.activeUser == userID
+}
+
+// Event streams poll the log this often, for events other processes
+// recorded, and send a comment to keep idle connections open
+const eventStreamPollInterval = 15 * time.Second
+
+// streamEvents sends the user's events as Server-Sent Events until the
+// client goes away or the server shuts down. Every event carries its log
+// ID, so a client that reconnects with Last-Event-ID misses nothing.
+func (a *App) streamEvents(w http.ResponseWriter, r *http.Request, stream *eventStream) {
+	ctx := r.Context()
+
+	// Subscribe before reading the log, so nothing slips in between
+	wake, unsubscribe := a.eventHub.Subscribe(stream.userID)
+	defer unsubscribe()
+
+	after := stream.lastEventID
+	if after == 0 {
+		last, err := a.db.LastUserEventID(ctx, stream.userID)
+		if err != nil {
+			writeAPIError(w, r, err)
+			return
+		}
+		after = last
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering
+	w.WriteHeader(http.StatusOK)
+
+	// A write timeout would cut the stream off
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	fmt.Fprintf(w, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	rc.Flush()
+
+	slog.DebugContext(ctx, "Event stream opened", "user_id", stream.userID, "after", after)
+	ticker := time.NewTicker(eventStreamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := a.sendEvents(ctx, w, stream.userID, &after)
+		if err != nil {
+			// The client resumes from the last ID it received
+			slog.WarnContext(ctx, "Event stream failed", "user_id", stream.userID, "error", err)
+			return
+		}
+		if sent > 0 {
+			rc.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.serverClosing:
+			return
+		case <-wake:
+		case <-ticker.C:
+			if sent == 0 {
+				if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				rc.Flush()
+			}
+		}
+	}
+}
+
+// sendEvents writes every logged event after *after and advances it
+func (a *App) sendEvents(ctx context.Context, w io.Writer, userID string, after *int64) (int, error) {
+	const batch = 100
+
+	sent := 0
+	for {
+		events, err := a.db.GetUserEventsAfter(ctx, userID, *after, batch)
+		if err != nil {
+			return sent, err
+		}
+		for _, event := range events {
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data); err != nil {
+				return sent, err
+			}
+			*after = event.ID
+			sent++
+		}
+		if len(events) < batch {
+			return sent, nil
+		}
+	}
+}
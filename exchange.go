@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/fx"
+	"pocket-wallet/pkg/config"
+)
+
+// newRateSource builds the configured exchange rate source
+func newRateSource(cfg *config.Config) (fx.RateSource, error) {
+	switch cfg.FXRateSource {
+	case "file":
+		if cfg.FXRatesFile == "" {
+			return nil, fmt.Errorf("FX_RATES_FILE is required for the file rate source")
+		}
+		return fx.NewFileSource(cfg.FXRatesFile, cfg.FXBaseCurrency), nil
+	case "static", "":
+		rates, err := fx.ParseStaticRates(cfg.FXStaticRates)
+		if err != nil {
+			return nil, err
+		}
+		return fx.NewStaticSource(cfg.FXBaseCurrency, rates), nil
+	}
+	return nil, fmt.Errorf("unknown FX rate source: %s", cfg.FXRateSource)
+}
+
+// QuoteExchange prices a conversion between two of the user's sub-balances.
+// The quote must be executed with ExecuteExchange before it expires.
+func (a *App) QuoteExchange(req ExchangeQuoteRequest) (_ *ExchangeQuote, err error) {
+	ctx, done := observe("QuoteExchange")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbQuote, err := a.wallet.QuoteExchange(ctx, req.UserID, req.FromCurrency, req.ToCurrency, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeQuote{
+		QuoteID:      dbQuote.QuoteID,
+		FromCurrency: dbQuote.FromCurrency,
+		ToCurrency:   dbQuote.ToCurrency,
+		SellAmount:   dbQuote.SellAmount,
+		BuyAmount:    dbQuote.BuyAmount,
+		MidRate:      dbQuote.MidRate,
+		Rate:         dbQuote.Rate,
+		SpreadBps:    dbQuote.SpreadBps,
+		RateSource:   dbQuote.RateSource,
+		RateDate:     dbQuote.RateDate,
+		ExpiresAt:    dbQuote.ExpiresAt,
+	}, nil
+}
+
+// ExecuteExchange books a quoted conversion as a debit and a credit transaction
+func (a *App) ExecuteExchange(req ExchangeExecuteRequest) (_ *ExchangeConversion, err error) {
+	ctx, done := observe("ExecuteExchange")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	conversion, err := a.wallet.ExecuteExchange(ctx, req.UserID, req.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversionFromDB(conversion), nil
+}
+
+// GetUserConversions lists the user's executed conversions, newest first
+func (a *App) GetUserConversions(userID string, limit int) (_ []ExchangeConversion, err error) {
+	ctx, done := observe("GetUserConversions")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbConversions, err := a.wallet.Conversions(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	conversions := make([]ExchangeConversion, len(dbConversions))
+	for i, dbConversion := range dbConversions {
+		conversions[i] = *conversionFromDB(dbConversion)
+	}
+
+	return conversions, nil
+}
+
+// conversionFromDB converts a database conversion record to the main type
+func conversionFromDB(c *database.FXConversion) *ExchangeConversion {
+	return &ExchangeConversion{
+		ConversionID:        c.ConversionID,
+		QuoteID:             c.QuoteID,
+		FromCurrency:        c.FromCurrency,
+		ToCurrency:          c.ToCurrency,
+		SellAmount:          c.SellAmount,
+		BuyAmount:           c.BuyAmount,
+		MidRate:             c.MidRate,
+		Rate:                c.Rate,
+		SpreadBps:           c.SpreadBps,
+		RateSource:          c.RateSource,
+		RateDate:            c.RateDate,
+		DebitTransactionID:  c.DebitTransactionID,
+		CreditTransactionID: c.CreditTransactionID,
+		ExecutedAt:          c.ExecutedAt,
+	}
+}
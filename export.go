@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/export"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ExportTransactions asks the user where to save and writes their
+// transaction history in the requested format: "csv", "jsonl", or the
+// "ofx" and "qif" statements understood by GnuCash, Moneydance and HomeBank
+func (a *App) ExportTransactions(userID string, format string, filter ExportFilter) (_ *ExportResult, err error) {
+	ctx, done := observe("ExportTransactions")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	if _, err := a.validateSession(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	exportFormat, err := export.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the filter before bothering the user with a dialog
+	dbFilter, opts, err := a.prepareExport(ctx, userID, exportFormat, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Eksport historii transakcji",
+		DefaultFilename: fmt.Sprintf("pocket-wallet-%s.%s", time.Now().Format("2006-01-02"), exportFormat.Extension()),
+		Filters: []runtime.FileFilter{
+			{DisplayName: fmt.Sprintf("%s (*.%s)", exportFormat, exportFormat.Extension()), Pattern: "*." + exportFormat.Extension()},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	if path == "" {
+		return &ExportResult{Format: string(exportFormat), Cancelled: true}, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	count, err := a.writeTransactions(ctx, file, exportFormat, opts, dbFilter)
+	if err == nil {
+		err = file.Close()
+		if err != nil {
+			err = fmt.Errorf("failed to write export file: %w", err)
+		}
+	} else {
+		file.Close()
+	}
+	if err != nil {
+		// Don't leave a truncated export behind for the user to import
+		if removeErr := os.Remove(path); removeErr != nil {
+			slog.Warn("Could not remove incomplete export file", "path", path, "error", removeErr)
+		}
+		return nil, err
+	}
+
+	slog.Info("Transactions exported", "user_id", userID, "count", count, "path", path)
+	return &ExportResult{Path: path, Format: string(exportFormat), Count: count}, nil
+}
+
+// writeTransactions streams every transaction matching the filter into w
+func (a *App) writeTransactions(ctx context.Context, w io.Writer, format export.Format, opts export.Options, filter *database.TransactionFilter) (int, error) {
+	writer, err := export.NewWriter(w, format, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = a.db.StreamTransactions(ctx, filter, func(t *database.Transaction) error {
+		count++
+		return writer.Write(t)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to export transactions: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, fmt.Errorf("failed to export transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// prepareExport validates the filter and works out writer options. Statement
+// formats default to completed entries in one currency, leave out imported
+// bank movements and carry the ledger balance at the end of the exported
+// period.
+func (a *App) prepareExport(ctx context.Context, userID string, format export.Format, filter ExportFilter) (*database.TransactionFilter, export.Options, error) {
+	if format.IsStatement() {
+		if len(filter.Statuses) == 0 {
+			filter.Statuses = []string{"completed"}
+		}
+		code, err := currency.Normalize(filter.Currency)
+		if err != nil {
+			return nil, export.Options{}, err
+		}
+		filter.Currency = code
+	}
+
+	dbFilter, err := exportFilterToDB(userID, filter)
+	if err != nil {
+		return nil, export.Options{}, err
+	}
+	opts, err := exportOptions(filter)
+	if err != nil {
+		return nil, export.Options{}, err
+	}
+
+	if format.IsStatement() {
+		dbFilter.WalletOnly = true
+
+		balance, err := a.ledgerBalanceAsOf(ctx, userID, dbFilter.Currency, dbFilter.To)
+		if err != nil {
+			return nil, export.Options{}, err
+		}
+		opts.AccountID = export.AccountID(userID)
+		opts.Currency = dbFilter.Currency
+		opts.From = dbFilter.From
+		opts.To = dbFilter.To
+		opts.LedgerBalance = &balance
+	}
+
+	return dbFilter, opts, nil
+}
+
+// ledgerBalanceAsOf sums completed wallet transactions in one currency
+// before a point in time; a zero time means the current balance
+func (a *App) ledgerBalanceAsOf(ctx context.Context, userID, code string, asOf time.Time) (int64, error) {
+	if asOf.IsZero() {
+		summary, err := a.db.GetBalanceSummary(ctx, userID, code)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get balance: %w", err)
+		}
+		return summary.Total, nil
+	}
+
+	var balance int64
+	err := a.db.StreamTransactions(ctx, &database.TransactionFilter{
+		UserID:     userID,
+		Statuses:   []string{"completed"},
+		Currency:   code,
+		To:         asOf,
+		WalletOnly: true,
+	}, func(t *database.Transaction) error {
+		balance += export.SignedAmount(t)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// exportFilterToDB validates an export filter and scopes it to the user
+func exportFilterToDB(userID string, filter ExportFilter) (*database.TransactionFilter, error) {
+	return transactionFilterFromRequest(TransactionQueryRequest{
+		UserID:    userID,
+		Types:     filter.Types,
+		Statuses:  filter.Statuses,
+		Currency:  filter.Currency,
+		DateFrom:  filter.DateFrom,
+		DateTo:    filter.DateTo,
+		MinAmount: filter.MinAmount,
+		MaxAmount: filter.MaxAmount,
+	})
+}
+
+// exportOptions converts the CSV settings of an export filter
+func exportOptions(filter ExportFilter) (export.Options, error) {
+	opts := export.Options{Language: filter.Language}
+
+	switch filter.Delimiter {
+	case "":
+	case "\\t", "tab":
+		opts.Delimiter = '\t'
+	default:
+		delimiter, size := utf8.DecodeRuneInString(filter.Delimiter)
+		if size != len(filter.Delimiter) {
+			return opts, invalidInput("delimiter must be a single character")
+		}
+		opts.Delimiter = delimiter
+	}
+
+	return opts, nil
+}
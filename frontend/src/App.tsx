@@ -1,4 +1,4 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import {
   ThemeProvider,
   createTheme,
@@ -20,7 +20,8 @@ import {
   CircularProgress,
   Divider,
   InputAdornment,
-  ButtonGroup
+  ButtonGroup,
+  MenuItem
 } from '@mui/material';
 import {
   AccountBalanceWallet,
@@ -30,9 +31,10 @@ import {
   Add,
   Euro
 } from '@mui/icons-material';
-import { apiClient, Transaction, TransactionListResponse } from './api';
+import { apiClient, BackendError, CurrencyBalance, CurrencyInfo, ServerStatus, SubsystemStatus, Transaction, TransactionListResponse } from './api';
 import { generateSalt, generatePasswordHash, deriveEncryptionKey, encryptData, decryptData, verifyPassword } from './crypto';
 import StripePaymentDialog from './StripePayment';
+import { EventsOn } from '../wailsjs/runtime/runtime';
 
 // Material-UI theme
 const theme = createTheme({
@@ -66,8 +68,30 @@ interface AppState {
   stripePublishableKey: string;
   loading: boolean;
   transactions: Transaction[];
+  currencies: CurrencyInfo[];
+  currencyBalances: CurrencyBalance[];
 }
 
+// The encrypted balance is kept in the wallet's default currency; other
+// currencies only have ledger balances
+const DEFAULT_CURRENCY = 'PLN';
+
+// Digits after the decimal point, e.g. 0 for JPY. The backend takes and
+// returns amounts in minor units.
+const minorUnits = (code: string, currencies: CurrencyInfo[]): number =>
+  currencies.find(c => c.code === code)?.minor_units ?? 2;
+
+const toMinor = (amount: number, units: number): number => Math.round(amount * 10 ** units);
+
+const fromMinor = (amount: number, units: number): number => amount / 10 ** units;
+
+const formatCurrency = (amount: number, currency: string = DEFAULT_CURRENCY): string => {
+  return new Intl.NumberFormat('pl-PL', {
+    style: 'currency',
+    currency
+  }).format(amount);
+};
+
 // Notification component
 interface NotificationProps {
   open: boolean;
@@ -297,30 +321,27 @@ const RegisterForm: React.FC<RegisterFormProps> = ({ onRegister, onShowLogin, lo
 interface DashboardProps {
   user: User;
   balance: string;
+  currencyBalances: CurrencyBalance[];
+  currencies: CurrencyInfo[];
   transactions: Transaction[];
   onLogout: () => void;
-  onTopUp: (amount: number) => Promise<void>;
+  onTopUp: (amount: number, currency: string) => Promise<void>;
 }
 
-const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLogout, onTopUp }) => {
+const Dashboard: React.FC<DashboardProps> = ({ user, balance, currencyBalances, currencies, transactions, onLogout, onTopUp }) => {
   const [topUpAmount, setTopUpAmount] = useState<number>(0);
+  const [topUpCurrency, setTopUpCurrency] = useState<string>(DEFAULT_CURRENCY);
   const [isTopUpLoading, setIsTopUpLoading] = useState(false);
 
-  const formatCurrency = (amount: number): string => {
-    return new Intl.NumberFormat('pl-PL', {
-      style: 'currency',
-      currency: 'PLN'
-    }).format(amount);
-  };
-
   const quickAmounts = [5, 10, 20, 50, 100, 200];
+  const topUpUnits = minorUnits(topUpCurrency, currencies);
 
   const handleTopUp = async () => {
     if (topUpAmount <= 0) return;
     
     setIsTopUpLoading(true);
     try {
-      await onTopUp(topUpAmount);
+      await onTopUp(topUpAmount, topUpCurrency);
       setTopUpAmount(0);
     } finally {
       setIsTopUpLoading(false);
@@ -371,6 +392,30 @@ const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLo
                 <Typography variant="h3" component="div" sx={{ fontWeight: 'bold', mb: 1 }}>
                   {formatCurrency(parseFloat(balance))}
                 </Typography>
+                {currencyBalances.length > 0 && (
+                  <Typography variant="body2" sx={{ opacity: 0.8 }}>
+                    Salda według walut:
+                  </Typography>
+                )}
+                {currencyBalances.length > 0 && (
+                  <Box sx={{ display: 'flex', flexWrap: 'wrap', gap: 3, mb: 1 }}>
+                    {currencyBalances.map((b) => {
+                      const units = minorUnits(b.currency, currencies);
+                      return (
+                        <Box key={b.currency}>
+                          <Typography variant="h6" component="div">
+                            {formatCurrency(fromMinor(b.total, units), b.currency)}
+                          </Typography>
+                          {b.held > 0 && (
+                            <Typography variant="body2" sx={{ opacity: 0.8 }}>
+                              Dostępne: {formatCurrency(fromMinor(b.available, units), b.currency)}
+                            </Typography>
+                          )}
+                        </Box>
+                      );
+                    })}
+                  </Box>
+                )}
                 <Typography variant="body2" sx={{ opacity: 0.8 }}>
                   Ostatnia aktualizacja: {new Date().toLocaleString('pl-PL')}
                 </Typography>
@@ -389,9 +434,24 @@ const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLo
                   </Typography>
                 </Box>
                 
+                <TextField
+                  select
+                  fullWidth
+                  label="Waluta"
+                  value={topUpCurrency}
+                  onChange={(e) => setTopUpCurrency(e.target.value)}
+                  sx={{ mb: 2 }}
+                >
+                  {(currencies.length > 0 ? currencies.map(c => c.code) : [DEFAULT_CURRENCY]).map((code) => (
+                    <MenuItem key={code} value={code}>
+                      {code}
+                    </MenuItem>
+                  ))}
+                </TextField>
+
                 <TextField
                   fullWidth
-                  label="Kwota (PLN)"
+                  label={`Kwota (${topUpCurrency})`}
                   type="number"
                   value={topUpAmount || ''}
                   onChange={(e) => setTopUpAmount(parseFloat(e.target.value) || 0)}
@@ -399,7 +459,7 @@ const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLo
                     startAdornment: <InputAdornment position="start"><Euro /></InputAdornment>,
                   }}
                   sx={{ mb: 2 }}
-                  inputProps={{ min: 1, step: 0.01 }}
+                  inputProps={{ min: 1, step: 10 ** -topUpUnits }}
                 />
 
                 <Typography variant="body2" sx={{ mb: 1 }}>
@@ -414,7 +474,7 @@ const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLo
                         onClick={() => setTopUpAmount(amount)}
                         sx={{ mb: 1 }}
                       >
-                        {amount} zł
+                        {formatCurrency(amount, topUpCurrency)}
                       </Button>
                     ))}
                   </ButtonGroup>
@@ -429,7 +489,7 @@ const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLo
                   startIcon={isTopUpLoading ? <CircularProgress size={20} /> : <Add />}
                   sx={{ mt: 2 }}
                 >
-                  {isTopUpLoading ? 'Przetwarzanie...' : `Doładuj ${formatCurrency(topUpAmount)}`}
+                  {isTopUpLoading ? 'Przetwarzanie...' : `Doładuj ${formatCurrency(topUpAmount, topUpCurrency)}`}
                 </Button>
               </CardContent>
             </Card>
@@ -499,7 +559,7 @@ const Dashboard: React.FC<DashboardProps> = ({ user, balance, transactions, onLo
                           fontWeight="bold"
                           color={transaction.type === 'deposit' ? 'success.main' : 'error.main'}
                         >
-                          {transaction.type === 'deposit' ? '+' : '-'}{formatCurrency(transaction.amount)}
+                          {transaction.type === 'deposit' ? '+' : '-'}{formatCurrency(transaction.amount, transaction.currency)}
                         </Typography>
                       </Box>
                     ))}
@@ -524,7 +584,9 @@ const App: React.FC = () => {
     encryptionKey: null,
     stripePublishableKey: '',
     loading: false,
-    transactions: []
+    transactions: [],
+    currencies: [],
+    currencyBalances: []
   });
 
   const [currentView, setCurrentView] = useState<'login' | 'register'>('login');
@@ -541,10 +603,12 @@ const App: React.FC = () => {
   const [paymentDialog, setPaymentDialog] = useState<{
     open: boolean;
     amount: number;
+    currency: string;
     clientSecret: string;
   }>({
     open: false,
     amount: 0,
+    currency: DEFAULT_CURRENCY,
     clientSecret: ''
   });
 
@@ -597,12 +661,14 @@ const App: React.FC = () => {
       const isValidPassword = await verifyPassword(password, userMeta.salt, userMeta.password_hash);
       
       if (!isValidPassword) {
+        apiClient.reportLoginFailure();
         showNotification('Nieprawidłowy login lub hasło', 'error');
         return;
       }
 
       const encryptionKey = await deriveEncryptionKey(password, userMeta.salt);
       const stripeKey = await apiClient.getStripePublishableKey();
+      const currencies = await apiClient.getSupportedCurrencies();
 
       // Use the actual user_id from database
       const userId = userMeta.user_id;
@@ -612,13 +678,21 @@ const App: React.FC = () => {
         isAuthenticated: true,
         currentUser: { login, user_id: userId },
         encryptionKey,
-        stripePublishableKey: stripeKey
+        stripePublishableKey: stripeKey,
+        currencies
       }));
 
+      await apiClient.setActiveUser(userId);
       await loadBalance(userId, encryptionKey);
+      await loadCurrencyBalances(userId);
       await loadTransactions(userId);
       showNotification('Zalogowano pomyślnie!', 'success');
     } catch (error) {
+      // Do not reveal whether the login exists
+      if (error instanceof BackendError && error.code === 'USER_NOT_FOUND') {
+        showNotification('Nieprawidłowy login lub hasło', 'error');
+        return;
+      }
       showNotification(`Błąd logowania: ${error}`, 'error');
     } finally {
       setState(prev => ({ ...prev, loading: false }));
@@ -641,47 +715,41 @@ const App: React.FC = () => {
     }
   };
 
-  const loadTransactions = async (userId: string) => {
+  const loadCurrencyBalances = async (userId: string) => {
     try {
-      const response: TransactionListResponse = await apiClient.getUserTransactions(userId, 10);
-      setState(prev => ({ ...prev, transactions: response.transactions }));
+      const balances = await apiClient.getCurrencyBalances(userId);
+      setState(prev => ({ ...prev, currencyBalances: balances }));
     } catch (error) {
-      console.error('Error loading transactions:', error);
-      setState(prev => ({ ...prev, transactions: [] }));
+      console.error('Error loading currency balances:', error);
     }
   };
 
-  const updateBalance = async (newBalance: string) => {
-    if (!state.currentUser || !state.encryptionKey) return;
-
+  const loadTransactions = async (userId: string) => {
     try {
-      const encryptedBalance = await encryptData(newBalance, state.encryptionKey);
-      
-      await apiClient.updateBalance({
-        user_id: state.currentUser.user_id,
-        encrypted_balance: encryptedBalance
-      });
-
-      setState(prev => ({ ...prev, userBalance: newBalance }));
+      const response: TransactionListResponse = await apiClient.getUserTransactions(userId, 10);
+      setState(prev => ({ ...prev, transactions: response.transactions }));
     } catch (error) {
-      showNotification(`Błąd aktualizacji salda: ${error}`, 'error');
+      console.error('Error loading transactions:', error);
+      setState(prev => ({ ...prev, transactions: [] }));
     }
   };
 
-  const handleTopUp = async (amount: number) => {
+  const handleTopUp = async (amount: number, currency: string) => {
     if (!state.currentUser || !state.stripePublishableKey) return;
 
     try {
       // Create payment intent through backend
       const paymentIntent = await apiClient.createPaymentIntent({
         user_id: state.currentUser.user_id,
-        amount: Math.round(amount * 100) // Convert to cents
+        amount: toMinor(amount, minorUnits(currency, state.currencies)),
+        currency
       });
 
       // Open Stripe payment dialog
       setPaymentDialog({
         open: true,
         amount: amount,
+        currency,
         clientSecret: paymentIntent.client_secret
       });
     } catch (error) {
@@ -689,27 +757,125 @@ const App: React.FC = () => {
     }
   };
 
+  // Stripe confirmed the card; the balance is credited once the webhook
+  // settles the payment and the backend sends payment:succeeded
   const handlePaymentSuccess = async () => {
-    const currentBalance = parseFloat(state.userBalance);
-    const newBalance = (currentBalance + paymentDialog.amount).toFixed(2);
-    
-    await updateBalance(newBalance);
-    
-    // Refresh transactions after payment
     if (state.currentUser) {
       await loadTransactions(state.currentUser.user_id);
     }
-    
-    showNotification(`Doładowano ${paymentDialog.amount.toFixed(2)} PLN!`, 'success');
-    
-    setPaymentDialog({ open: false, amount: 0, clientSecret: '' });
+
+    showNotification('Płatność przyjęta, oczekiwanie na potwierdzenie...', 'info');
+
+    setPaymentDialog({ open: false, amount: 0, currency: DEFAULT_CURRENCY, clientSecret: '' });
   };
 
+  // Add settled deposits to the encrypted balance: those reported while
+  // logged in and those that settled while logged out. Each update sends
+  // the balance it was computed from and the deposit's event ID, so a
+  // concurrent change is retried and a deposit is never credited twice,
+  // e.g. by a second window.
+  const creditPendingDeposits = async (userId: string, encryptionKey: CryptoKey) => {
+    const maxAttempts = 5;
+    try {
+      const credits = await apiClient.getPendingCredits(userId);
+      for (const credit of credits) {
+        const transaction = credit.transaction;
+        if (!transaction) continue;
+
+        for (let attempt = 1; ; attempt++) {
+          const response = await apiClient.getBalance(userId);
+          if (response.credited_event_id >= credit.event_id) break; // Credited elsewhere
+
+          const current = response.encrypted_balance
+            ? parseFloat(await decryptData(response.encrypted_balance, encryptionKey))
+            : 0;
+          const newBalance = (current + transaction.amount).toFixed(2);
+
+          try {
+            await apiClient.updateBalance({
+              user_id: userId,
+              encrypted_balance: await encryptData(newBalance, encryptionKey),
+              expected_balance: response.encrypted_balance,
+              credited_event_id: credit.event_id
+            });
+          } catch (error) {
+            if (error instanceof BackendError && error.code === 'BALANCE_CONFLICT' && attempt < maxAttempts) continue;
+            throw error;
+          }
+
+          setState(prev => ({ ...prev, userBalance: newBalance }));
+          showNotification(`Doładowano ${formatCurrency(transaction.amount, transaction.currency)}!`, 'success');
+          break;
+        }
+      }
+    } catch (error) {
+      showNotification(`Błąd aktualizacji salda: ${error}`, 'error');
+    }
+  };
+
+  // The app keeps running while MongoDB is down; the backend reconnects
+  // and reports when it is back
+  const [databaseAvailable, setDatabaseAvailable] = useState(true);
+  useEffect(() => {
+    const update = (status: SubsystemStatus) => {
+      if (status.name === 'mongodb') setDatabaseAvailable(status.state === 'running');
+    };
+
+    apiClient.getSubsystemStatus().then(statuses => statuses.forEach(update));
+    return EventsOn('subsystem:status', update);
+  }, []);
+
+  // Without the local server no payment is ever confirmed, so say so
+  useEffect(() => {
+    const warn = (status: ServerStatus) => {
+      showNotification(`Serwer płatności nie działa, doładowania nie zostaną potwierdzone: ${status.error}`, 'warning');
+    };
+
+    apiClient.getServerStatus().then(status => {
+      if (!status.running) warn(status);
+    });
+    return EventsOn('server:failed', warn);
+  }, []);
+
+  // Payment outcomes pushed by the backend while logged in. Deposits that
+  // settled while logged out are credited right after login.
+  const userId = state.currentUser?.user_id;
+  const encryptionKey = state.encryptionKey;
+  useEffect(() => {
+    if (!userId || !encryptionKey) return;
+
+    creditPendingDeposits(userId, encryptionKey);
+    const offSucceeded = EventsOn('payment:succeeded', () => creditPendingDeposits(userId, encryptionKey));
+    const offFailed = EventsOn('payment:failed', (transaction: Transaction) => {
+      const reason = transaction.status_history?.[transaction.status_history.length - 1]?.reason;
+      showNotification(`Płatność nie powiodła się${reason ? `: ${reason}` : ''}`, 'error');
+    });
+    const offUpdated = EventsOn('transaction:updated', (transaction: Transaction) => {
+      loadCurrencyBalances(userId);
+      setState(prev => {
+        if (!prev.transactions.some(t => t.transaction_id === transaction.transaction_id)) {
+          return { ...prev, transactions: [transaction, ...prev.transactions].slice(0, 10) };
+        }
+        return {
+          ...prev,
+          transactions: prev.transactions.map(t => t.transaction_id === transaction.transaction_id ? transaction : t)
+        };
+      });
+    });
+
+    return () => {
+      offSucceeded();
+      offFailed();
+      offUpdated();
+    };
+  }, [userId, encryptionKey]);
+
   const handlePaymentError = (error: string) => {
     showNotification(`Błąd płatności: ${error}`, 'error');
   };
 
   const handleLogout = () => {
+    apiClient.clearActiveUser();
     setState({
       isAuthenticated: false,
       currentUser: null,
@@ -717,7 +883,9 @@ const App: React.FC = () => {
       encryptionKey: null,
       stripePublishableKey: '',
       loading: false,
-      transactions: []
+      transactions: [],
+      currencies: [],
+      currencyBalances: []
     });
     
     setCurrentView('login');
@@ -728,6 +896,12 @@ const App: React.FC = () => {
     <ThemeProvider theme={theme}>
       <CssBaseline />
       <Box sx={{ minHeight: '100vh', backgroundColor: 'background.default' }}>
+        {!databaseAvailable && (
+          <Alert severity="error" sx={{ borderRadius: 0 }}>
+            Baza danych jest niedostępna. Trwa ponowne łączenie, do tego czasu logowanie i operacje na portfelu nie działają.
+          </Alert>
+        )}
+
         <Notification
           open={notification.open}
           message={notification.message}
@@ -754,6 +928,8 @@ const App: React.FC = () => {
             <Dashboard
               user={state.currentUser}
               balance={state.userBalance}
+              currencyBalances={state.currencyBalances}
+              currencies={state.currencies}
               transactions={state.transactions}
               onLogout={handleLogout}
               onTopUp={handleTopUp}
@@ -764,8 +940,9 @@ const App: React.FC = () => {
         {/* Stripe Payment Dialog */}
         <StripePaymentDialog
           open={paymentDialog.open}
-          onClose={() => setPaymentDialog({ open: false, amount: 0, clientSecret: '' })}
+          onClose={() => setPaymentDialog({ open: false, amount: 0, currency: DEFAULT_CURRENCY, clientSecret: '' })}
           amount={paymentDialog.amount}
+          currency={paymentDialog.currency}
           stripePublishableKey={state.stripePublishableKey}
           clientSecret={paymentDialog.clientSecret}
           onSuccess={handlePaymentSuccess}
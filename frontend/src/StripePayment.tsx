@@ -40,12 +40,13 @@ const cardElementOptions = {
 
 interface PaymentFormProps {
   amount: number;
+  currency: string;
   onSuccess: () => void;
   onError: (error: string) => void;
   clientSecret: string;
 }
 
-const PaymentForm: React.FC<PaymentFormProps> = ({ amount, onSuccess, onError, clientSecret }) => {
+const PaymentForm: React.FC<PaymentFormProps> = ({ amount, currency, onSuccess, onError, clientSecret }) => {
   const stripe = useStripe();
   const elements = useElements();
   const [isProcessing, setIsProcessing] = useState(false);
@@ -54,7 +55,7 @@ const PaymentForm: React.FC<PaymentFormProps> = ({ amount, onSuccess, onError, c
   const formatCurrency = (amount: number): string => {
     return new Intl.NumberFormat('pl-PL', {
       style: 'currency',
-      currency: 'PLN'
+      currency
     }).format(amount);
   };
 
@@ -153,6 +154,7 @@ interface StripePaymentDialogProps {
   open: boolean;
   onClose: () => void;
   amount: number;
+  currency: string;
   stripePublishableKey: string;
   clientSecret: string;
   onSuccess: () => void;
@@ -163,6 +165,7 @@ const StripePaymentDialog: React.FC<StripePaymentDialogProps> = ({
   open,
   onClose,
   amount,
+  currency,
   stripePublishableKey,
   clientSecret,
   onSuccess,
@@ -197,6 +200,7 @@ const StripePaymentDialog: React.FC<StripePaymentDialogProps> = ({
           <Elements stripe={stripePromise}>
             <PaymentForm
               amount={amount}
+              currency={currency}
               onSuccess={handleSuccess}
               onError={handleError}
               clientSecret={clientSecret}
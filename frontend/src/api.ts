@@ -7,10 +7,45 @@ export type User = main.User;
 export type UserMetaResponse = main.UserMetaResponse;
 export type BalanceRequest = main.BalanceRequest;
 export type BalanceResponse = main.BalanceResponse;
+export type PendingCredit = main.PendingCredit;
 export type StripePaymentIntentRequest = main.StripePaymentIntentRequest;
 export type StripePaymentIntentResponse = main.StripePaymentIntentResponse;
 export type Transaction = main.Transaction;
 export type TransactionListResponse = main.TransactionListResponse;
+export type ServerStatus = main.ServerStatus;
+export type SubsystemStatus = main.SubsystemStatus;
+export type CurrencyInfo = main.CurrencyInfo;
+export type CurrencyBalance = main.AvailableBalanceResponse;
+
+// Shape of a rejected backend call, see ErrorDetail in models.go
+interface ErrorDetail {
+  code: string;
+  message: string;
+  detail: string;
+}
+
+// Error raised by backend methods. The code is stable and can be checked,
+// e.g. "LOGIN_TAKEN"; the message is already localized.
+export class BackendError extends Error {
+  code: string;
+  detail: string;
+
+  constructor(error: unknown) {
+    const e = (typeof error === 'object' && error !== null ? error : {}) as Partial<ErrorDetail>;
+    const code = e.code ?? 'REQUEST_FAILED';
+    const detail = e.detail ?? String(error);
+    // Invalid input says little on its own, so show what was wrong with it.
+    // Uncoded failures carry no detail worth showing.
+    const generic = code === 'INVALID_INPUT';
+    super(e.message ? (generic ? `${e.message}: ${detail}` : e.message) : detail);
+    this.code = code;
+    this.detail = detail;
+  }
+
+  toString(): string {
+    return this.message;
+  }
+}
 
 // API Client class for backend communication
 export class ApiClient {
@@ -21,7 +56,7 @@ export class ApiClient {
       const user = await App.Register(request);
       return user;
     } catch (error) {
-      throw new Error(`Registration failed: ${error}`);
+      throw new BackendError(error);
     }
   }
 
@@ -31,17 +66,50 @@ export class ApiClient {
       const meta = await App.GetUserMeta(login);
       return meta;
     } catch (error) {
-      throw new Error(`Failed to get user metadata: ${error}`);
+      throw new BackendError(error);
+    }
+  }
+
+  // Count a wrong password in the backend metrics; never fails the login flow
+  async reportLoginFailure(): Promise<void> {
+    try {
+      await App.ReportLoginFailure();
+    } catch {
+      // Metrics are best effort
     }
   }
 
 
+  // State of the database, server and other subsystems
+  async getSubsystemStatus(): Promise<SubsystemStatus[]> {
+    return await App.GetSubsystemStatus();
+  }
+
+  // Whether the local server receiving payment webhooks is running
+  async getServerStatus(): Promise<ServerStatus> {
+    return await App.GetServerStatus();
+  }
+
+  // Route transaction events of the logged-in user to this window
+  async setActiveUser(userId: string): Promise<void> {
+    try {
+      await App.SetActiveUser(userId);
+    } catch (error) {
+      throw new BackendError(error);
+    }
+  }
+
+  // Stop transaction events after logout
+  async clearActiveUser(): Promise<void> {
+    await App.ClearActiveUser();
+  }
+
   // Update user's encrypted balance
   async updateBalance(request: BalanceRequest): Promise<void> {
     try {
       await App.UpdateBalance(request);
     } catch (error) {
-      throw new Error(`Failed to update balance: ${error}`);
+      throw new BackendError(error);
     }
   }
 
@@ -51,7 +119,16 @@ export class ApiClient {
       const balance = await App.GetBalance(userId);
       return balance;
     } catch (error) {
-      throw new Error(`Failed to get balance: ${error}`);
+      throw new BackendError(error);
+    }
+  }
+
+  // Settled deposits not yet added to the encrypted balance
+  async getPendingCredits(userId: string): Promise<PendingCredit[]> {
+    try {
+      return await App.GetPendingCredits(userId);
+    } catch (error) {
+      throw new BackendError(error);
     }
   }
 
@@ -61,7 +138,7 @@ export class ApiClient {
       const response = await App.CreatePaymentIntent(request);
       return response;
     } catch (error) {
-      throw new Error(`Failed to create payment intent: ${error}`);
+      throw new BackendError(error);
     }
   }
 
@@ -71,7 +148,22 @@ export class ApiClient {
       const key = await App.GetStripePublishableKey();
       return key;
     } catch (error) {
-      throw new Error(`Failed to get Stripe key: ${error}`);
+      throw new BackendError(error);
+    }
+  }
+
+  // Currencies accepted for top-ups, with their minor units and minimums
+  async getSupportedCurrencies(): Promise<CurrencyInfo[]> {
+    return await App.GetSupportedCurrencies();
+  }
+
+  // Ledger balance of every currency the user holds
+  async getCurrencyBalances(userId: string): Promise<CurrencyBalance[]> {
+    try {
+      const response = await App.GetCurrencyBalances(userId);
+      return response.balances ?? [];
+    } catch (error) {
+      throw new BackendError(error);
     }
   }
 
@@ -81,7 +173,7 @@ export class ApiClient {
       const transactions = await App.GetUserTransactions(userId, limit);
       return transactions;
     } catch (error) {
-      throw new Error(`Failed to get transactions: ${error}`);
+      throw new BackendError(error);
     }
   }
 }
@@ -2,26 +2,76 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
-export function ConvertAmountToCents(arg1:number):Promise<number>;
+export function CaptureHold(arg1:main.HoldActionRequest):Promise<main.Transaction>;
 
-export function ConvertCentsToAmount(arg1:number):Promise<number>;
+export function ClearActiveUser():Promise<void>;
+
+export function ConvertAmountToCents(arg1:number,arg2:string):Promise<number>;
+
+export function ConvertCentsToAmount(arg1:number,arg2:string):Promise<number>;
+
+export function CreateHold(arg1:main.HoldRequest):Promise<main.Hold>;
 
 export function CreatePaymentIntent(arg1:main.StripePaymentIntentRequest):Promise<main.StripePaymentIntentResponse>;
 
+export function ExecuteExchange(arg1:main.ExchangeExecuteRequest):Promise<main.ExchangeConversion>;
+
+export function ExportTransactions(arg1:string,arg2:string,arg3:main.ExportFilter):Promise<main.ExportResult>;
+
+export function GenerateStatement(arg1:string,arg2:main.StatementRequest):Promise<main.StatementResult>;
+
+export function GetAvailableBalance(arg1:string,arg2:string):Promise<main.AvailableBalanceResponse>;
+
 export function GetBalance(arg1:string):Promise<main.BalanceResponse>;
 
-export function GetDatabaseStatus():Promise<Record<string, any>>;
+export function GetCurrencyBalances(arg1:string):Promise<main.CurrencyBalancesResponse>;
+
+export function GetDatabaseStatus():Promise<main.HealthReport>;
+
+export function GetFailedWebhookEvents(arg1:number):Promise<Array<main.WebhookEvent>>;
+
+export function GetJobStatuses():Promise<Array<main.JobStatus>>;
+
+export function GetPendingCredits(arg1:string):Promise<Array<main.PendingCredit>>;
+
+export function GetServerStatus():Promise<main.ServerStatus>;
 
 export function GetStripePublishableKey():Promise<string>;
 
+export function GetSubsystemStatus():Promise<Array<main.SubsystemStatus>>;
+
+export function GetSupportedCurrencies():Promise<Array<main.CurrencyInfo>>;
+
 export function GetUserByLogin(arg1:string):Promise<main.User>;
 
+export function GetUserConversions(arg1:string,arg2:number):Promise<Array<main.ExchangeConversion>>;
+
+export function GetUserHolds(arg1:string):Promise<Array<main.Hold>>;
+
 export function GetUserMeta(arg1:string):Promise<main.UserMetaResponse>;
 
 export function GetUserTransactions(arg1:string,arg2:number):Promise<main.TransactionListResponse>;
 
+export function ImportBankStatement(arg1:string):Promise<main.BankImportResult>;
+
+export function QueryTransactions(arg1:main.TransactionQueryRequest):Promise<main.TransactionListResponse>;
+
+export function QuoteExchange(arg1:main.ExchangeQuoteRequest):Promise<main.ExchangeQuote>;
+
 export function Register(arg1:main.RegisterRequest):Promise<main.User>;
 
+export function ReleaseHold(arg1:main.HoldActionRequest):Promise<void>;
+
+export function ReplayWebhookEvent(arg1:string):Promise<main.WebhookEvent>;
+
+export function ReportLoginFailure():Promise<void>;
+
+export function SearchTransactions(arg1:main.TransactionSearchRequest):Promise<main.TransactionSearchResponse>;
+
+export function SetActiveUser(arg1:string):Promise<void>;
+
 export function UpdateBalance(arg1:main.BalanceRequest):Promise<void>;
 
+export function UpdateTransactionNotes(arg1:main.TransactionNotesRequest):Promise<void>;
+
 export function ValidateUserSession(arg1:string):Promise<boolean>;
@@ -2,34 +2,98 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function ConvertAmountToCents(arg1) {
-  return window['go']['main']['App']['ConvertAmountToCents'](arg1);
+export function CaptureHold(arg1) {
+  return window['go']['main']['App']['CaptureHold'](arg1);
 }
 
-export function ConvertCentsToAmount(arg1) {
-  return window['go']['main']['App']['ConvertCentsToAmount'](arg1);
+export function ClearActiveUser() {
+  return window['go']['main']['App']['ClearActiveUser']();
+}
+
+export function ConvertAmountToCents(arg1, arg2) {
+  return window['go']['main']['App']['ConvertAmountToCents'](arg1, arg2);
+}
+
+export function ConvertCentsToAmount(arg1, arg2) {
+  return window['go']['main']['App']['ConvertCentsToAmount'](arg1, arg2);
+}
+
+export function CreateHold(arg1) {
+  return window['go']['main']['App']['CreateHold'](arg1);
 }
 
 export function CreatePaymentIntent(arg1) {
   return window['go']['main']['App']['CreatePaymentIntent'](arg1);
 }
 
+export function ExecuteExchange(arg1) {
+  return window['go']['main']['App']['ExecuteExchange'](arg1);
+}
+
+export function ExportTransactions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportTransactions'](arg1, arg2, arg3);
+}
+
+export function GenerateStatement(arg1, arg2) {
+  return window['go']['main']['App']['GenerateStatement'](arg1, arg2);
+}
+
+export function GetAvailableBalance(arg1, arg2) {
+  return window['go']['main']['App']['GetAvailableBalance'](arg1, arg2);
+}
+
 export function GetBalance(arg1) {
   return window['go']['main']['App']['GetBalance'](arg1);
 }
 
+export function GetCurrencyBalances(arg1) {
+  return window['go']['main']['App']['GetCurrencyBalances'](arg1);
+}
+
 export function GetDatabaseStatus() {
   return window['go']['main']['App']['GetDatabaseStatus']();
 }
 
+export function GetFailedWebhookEvents(arg1) {
+  return window['go']['main']['App']['GetFailedWebhookEvents'](arg1);
+}
+
+export function GetJobStatuses() {
+  return window['go']['main']['App']['GetJobStatuses']();
+}
+
+export function GetPendingCredits(arg1) {
+  return window['go']['main']['App']['GetPendingCredits'](arg1);
+}
+
+export function GetServerStatus() {
+  return window['go']['main']['App']['GetServerStatus']();
+}
+
 export function GetStripePublishableKey() {
   return window['go']['main']['App']['GetStripePublishableKey']();
 }
 
+export function GetSubsystemStatus() {
+  return window['go']['main']['App']['GetSubsystemStatus']();
+}
+
+export function GetSupportedCurrencies() {
+  return window['go']['main']['App']['GetSupportedCurrencies']();
+}
+
 export function GetUserByLogin(arg1) {
   return window['go']['main']['App']['GetUserByLogin'](arg1);
 }
 
+export function GetUserConversions(arg1, arg2) {
+  return window['go']['main']['App']['GetUserConversions'](arg1, arg2);
+}
+
+export function GetUserHolds(arg1) {
+  return window['go']['main']['App']['GetUserHolds'](arg1);
+}
+
 export function GetUserMeta(arg1) {
   return window['go']['main']['App']['GetUserMeta'](arg1);
 }
@@ -38,14 +102,50 @@ export function GetUserTransactions(arg1, arg2) {
   return window['go']['main']['App']['GetUserTransactions'](arg1, arg2);
 }
 
+export function ImportBankStatement(arg1) {
+  return window['go']['main']['App']['ImportBankStatement'](arg1);
+}
+
+export function QueryTransactions(arg1) {
+  return window['go']['main']['App']['QueryTransactions'](arg1);
+}
+
+export function QuoteExchange(arg1) {
+  return window['go']['main']['App']['QuoteExchange'](arg1);
+}
+
 export function Register(arg1) {
   return window['go']['main']['App']['Register'](arg1);
 }
 
+export function ReleaseHold(arg1) {
+  return window['go']['main']['App']['ReleaseHold'](arg1);
+}
+
+export function ReplayWebhookEvent(arg1) {
+  return window['go']['main']['App']['ReplayWebhookEvent'](arg1);
+}
+
+export function ReportLoginFailure() {
+  return window['go']['main']['App']['ReportLoginFailure']();
+}
+
+export function SearchTransactions(arg1) {
+  return window['go']['main']['App']['SearchTransactions'](arg1);
+}
+
+export function SetActiveUser(arg1) {
+  return window['go']['main']['App']['SetActiveUser'](arg1);
+}
+
 export function UpdateBalance(arg1) {
   return window['go']['main']['App']['UpdateBalance'](arg1);
 }
 
+export function UpdateTransactionNotes(arg1) {
+  return window['go']['main']['App']['UpdateTransactionNotes'](arg1);
+}
+
 export function ValidateUserSession(arg1) {
   return window['go']['main']['App']['ValidateUserSession'](arg1);
 }
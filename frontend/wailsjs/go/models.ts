@@ -1,30 +1,681 @@
 export namespace main {
 	
+	export class AvailableBalanceResponse {
+	    currency: string;
+	    total: number;
+	    held: number;
+	    available: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AvailableBalanceResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currency = source["currency"];
+	        this.total = source["total"];
+	        this.held = source["held"];
+	        this.available = source["available"];
+	    }
+	}
 	export class BalanceRequest {
 	    user_id: string;
 	    encrypted_balance: string;
+	    expected_balance?: string;
+	    credited_event_id?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BalanceRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.encrypted_balance = source["encrypted_balance"];
+	        this.expected_balance = source["expected_balance"];
+	        this.credited_event_id = source["credited_event_id"];
+	    }
+	}
+	export class BalanceResponse {
+	    encrypted_balance: string;
+	    credited_event_id: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BalanceResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.encrypted_balance = source["encrypted_balance"];
+	        this.credited_event_id = source["credited_event_id"];
+	    }
+	}
+	export class ImportLineError {
+	    line: number;
+	    text: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportLineError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.text = source["text"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class BankImportResult {
+	    path: string;
+	    format: string;
+	    account_id: string;
+	    imported: number;
+	    booked: number;
+	    duplicates: number;
+	    errors: ImportLineError[];
+	    cancelled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new BankImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.format = source["format"];
+	        this.account_id = source["account_id"];
+	        this.imported = source["imported"];
+	        this.booked = source["booked"];
+	        this.duplicates = source["duplicates"];
+	        this.errors = this.convertValues(source["errors"], ImportLineError);
+	        this.cancelled = source["cancelled"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CurrencyBalancesResponse {
+	    balances: AvailableBalanceResponse[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CurrencyBalancesResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.balances = this.convertValues(source["balances"], AvailableBalanceResponse);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CurrencyInfo {
+	    code: string;
+	    minor_units: number;
+	    minimum_amount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CurrencyInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.minor_units = source["minor_units"];
+	        this.minimum_amount = source["minimum_amount"];
+	    }
+	}
+	export class ExchangeConversion {
+	    conversion_id: string;
+	    quote_id: string;
+	    from_currency: string;
+	    to_currency: string;
+	    sell_amount: number;
+	    buy_amount: number;
+	    mid_rate: number;
+	    rate: number;
+	    spread_bps: number;
+	    rate_source: string;
+	    // Go type: time
+	    rate_date: any;
+	    debit_transaction_id: string;
+	    credit_transaction_id: string;
+	    // Go type: time
+	    executed_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExchangeConversion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.conversion_id = source["conversion_id"];
+	        this.quote_id = source["quote_id"];
+	        this.from_currency = source["from_currency"];
+	        this.to_currency = source["to_currency"];
+	        this.sell_amount = source["sell_amount"];
+	        this.buy_amount = source["buy_amount"];
+	        this.mid_rate = source["mid_rate"];
+	        this.rate = source["rate"];
+	        this.spread_bps = source["spread_bps"];
+	        this.rate_source = source["rate_source"];
+	        this.rate_date = this.convertValues(source["rate_date"], null);
+	        this.debit_transaction_id = source["debit_transaction_id"];
+	        this.credit_transaction_id = source["credit_transaction_id"];
+	        this.executed_at = this.convertValues(source["executed_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExchangeExecuteRequest {
+	    user_id: string;
+	    quote_id: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExchangeExecuteRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.quote_id = source["quote_id"];
+	    }
+	}
+	export class ExchangeQuote {
+	    quote_id: string;
+	    from_currency: string;
+	    to_currency: string;
+	    sell_amount: number;
+	    buy_amount: number;
+	    mid_rate: number;
+	    rate: number;
+	    spread_bps: number;
+	    rate_source: string;
+	    // Go type: time
+	    rate_date: any;
+	    // Go type: time
+	    expires_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExchangeQuote(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.quote_id = source["quote_id"];
+	        this.from_currency = source["from_currency"];
+	        this.to_currency = source["to_currency"];
+	        this.sell_amount = source["sell_amount"];
+	        this.buy_amount = source["buy_amount"];
+	        this.mid_rate = source["mid_rate"];
+	        this.rate = source["rate"];
+	        this.spread_bps = source["spread_bps"];
+	        this.rate_source = source["rate_source"];
+	        this.rate_date = this.convertValues(source["rate_date"], null);
+	        this.expires_at = this.convertValues(source["expires_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExchangeQuoteRequest {
+	    user_id: string;
+	    from_currency: string;
+	    to_currency: string;
+	    amount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExchangeQuoteRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.from_currency = source["from_currency"];
+	        this.to_currency = source["to_currency"];
+	        this.amount = source["amount"];
+	    }
+	}
+	export class ExportFilter {
+	    types?: string[];
+	    statuses?: string[];
+	    currency?: string;
+	    date_from?: string;
+	    date_to?: string;
+	    min_amount?: number;
+	    max_amount?: number;
+	    delimiter?: string;
+	    language?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.types = source["types"];
+	        this.statuses = source["statuses"];
+	        this.currency = source["currency"];
+	        this.date_from = source["date_from"];
+	        this.date_to = source["date_to"];
+	        this.min_amount = source["min_amount"];
+	        this.max_amount = source["max_amount"];
+	        this.delimiter = source["delimiter"];
+	        this.language = source["language"];
+	    }
+	}
+	export class ExportResult {
+	    path: string;
+	    format: string;
+	    count: number;
+	    cancelled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.format = source["format"];
+	        this.count = source["count"];
+	        this.cancelled = source["cancelled"];
+	    }
+	}
+	export class HealthComponent {
+	    name: string;
+	    status: string;
+	    critical: boolean;
+	    latency_ms: number;
+	    error?: string;
+	    details?: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthComponent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.status = source["status"];
+	        this.critical = source["critical"];
+	        this.latency_ms = source["latency_ms"];
+	        this.error = source["error"];
+	        this.details = source["details"];
+	    }
+	}
+	export class HealthReport {
+	    connected: boolean;
+	    error: string;
+	    status: string;
+	    components: HealthComponent[];
+	    // Go type: time
+	    checked_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.error = source["error"];
+	        this.status = source["status"];
+	        this.components = this.convertValues(source["components"], HealthComponent);
+	        this.checked_at = this.convertValues(source["checked_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Hold {
+	    hold_id: string;
+	    user_id: string;
+	    type: string;
+	    amount: number;
+	    currency: string;
+	    status: string;
+	    description: string;
+	    transaction_id?: string;
+	    // Go type: time
+	    expires_at: any;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Hold(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hold_id = source["hold_id"];
+	        this.user_id = source["user_id"];
+	        this.type = source["type"];
+	        this.amount = source["amount"];
+	        this.currency = source["currency"];
+	        this.status = source["status"];
+	        this.description = source["description"];
+	        this.transaction_id = source["transaction_id"];
+	        this.expires_at = this.convertValues(source["expires_at"], null);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HoldActionRequest {
+	    user_id: string;
+	    hold_id: string;
+	    amount?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HoldActionRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.hold_id = source["hold_id"];
+	        this.amount = source["amount"];
+	    }
+	}
+	export class HoldRequest {
+	    user_id: string;
+	    type: string;
+	    amount: number;
+	    currency: string;
+	    description: string;
+	    expires_in_seconds?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HoldRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.type = source["type"];
+	        this.amount = source["amount"];
+	        this.currency = source["currency"];
+	        this.description = source["description"];
+	        this.expires_in_seconds = source["expires_in_seconds"];
+	    }
+	}
+	
+	export class JobStatus {
+	    name: string;
+	    schedule: string;
+	    running: boolean;
+	    // Go type: time
+	    next_run: any;
+	    // Go type: time
+	    last_started_at: any;
+	    // Go type: time
+	    last_finished_at: any;
+	    last_duration_ms: number;
+	    last_error?: string;
+	    runs: number;
+	    failures: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new JobStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.schedule = source["schedule"];
+	        this.running = source["running"];
+	        this.next_run = this.convertValues(source["next_run"], null);
+	        this.last_started_at = this.convertValues(source["last_started_at"], null);
+	        this.last_finished_at = this.convertValues(source["last_finished_at"], null);
+	        this.last_duration_ms = source["last_duration_ms"];
+	        this.last_error = source["last_error"];
+	        this.runs = source["runs"];
+	        this.failures = source["failures"];
+	        this.skipped = source["skipped"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StatusChange {
+	    status: string;
+	    // Go type: time
+	    changed_at: any;
+	    reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StatusChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.changed_at = this.convertValues(source["changed_at"], null);
+	        this.reason = source["reason"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Transaction {
+	    transaction_id: string;
+	    user_id: string;
+	    type: string;
+	    amount: number;
+	    currency: string;
+	    status: string;
+	    description: string;
+	    counterparty?: string;
+	    notes?: string;
+	    payment_id?: string;
+	    exchange_id?: string;
+	    bank_reference?: string;
+	    status_history?: StatusChange[];
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new BalanceRequest(source);
+	        return new Transaction(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.transaction_id = source["transaction_id"];
 	        this.user_id = source["user_id"];
-	        this.encrypted_balance = source["encrypted_balance"];
+	        this.type = source["type"];
+	        this.amount = source["amount"];
+	        this.currency = source["currency"];
+	        this.status = source["status"];
+	        this.description = source["description"];
+	        this.counterparty = source["counterparty"];
+	        this.notes = source["notes"];
+	        this.payment_id = source["payment_id"];
+	        this.exchange_id = source["exchange_id"];
+	        this.bank_reference = source["bank_reference"];
+	        this.status_history = this.convertValues(source["status_history"], StatusChange);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class BalanceResponse {
-	    encrypted_balance: string;
+	export class PendingCredit {
+	    event_id: number;
+	    transaction?: Transaction;
 	
 	    static createFrom(source: any = {}) {
-	        return new BalanceResponse(source);
+	        return new PendingCredit(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.encrypted_balance = source["encrypted_balance"];
+	        this.event_id = source["event_id"];
+	        this.transaction = this.convertValues(source["transaction"], Transaction);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class RegisterRequest {
 	    login: string;
@@ -44,9 +695,69 @@ export namespace main {
 	        this.password_hash = source["password_hash"];
 	    }
 	}
+	export class ServerStatus {
+	    running: boolean;
+	    address: string;
+	    url?: string;
+	    tls: string;
+	    cert_fingerprint?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.address = source["address"];
+	        this.url = source["url"];
+	        this.tls = source["tls"];
+	        this.cert_fingerprint = source["cert_fingerprint"];
+	        this.error = source["error"];
+	    }
+	}
+	export class StatementRequest {
+	    year: number;
+	    month: number;
+	    currency?: string;
+	    opening_balance?: number;
+	    closing_balance?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new StatementRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.year = source["year"];
+	        this.month = source["month"];
+	        this.currency = source["currency"];
+	        this.opening_balance = source["opening_balance"];
+	        this.closing_balance = source["closing_balance"];
+	    }
+	}
+	export class StatementResult {
+	    path: string;
+	    count: number;
+	    cancelled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StatementResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.count = source["count"];
+	        this.cancelled = source["cancelled"];
+	    }
+	}
+	
 	export class StripePaymentIntentRequest {
 	    user_id: string;
 	    amount: number;
+	    currency?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new StripePaymentIntentRequest(source);
@@ -56,6 +767,7 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.user_id = source["user_id"];
 	        this.amount = source["amount"];
+	        this.currency = source["currency"];
 	    }
 	}
 	export class StripePaymentIntentResponse {
@@ -72,36 +784,71 @@ export namespace main {
 	        this.payment_id = source["payment_id"];
 	    }
 	}
-	export class Transaction {
-	    transaction_id: string;
-	    user_id: string;
-	    type: string;
-	    amount: number;
-	    currency: string;
-	    status: string;
-	    description: string;
-	    payment_id?: string;
+	export class SubsystemStatus {
+	    name: string;
+	    state: string;
+	    error?: string;
 	    // Go type: time
-	    created_at: any;
-	    // Go type: time
-	    updated_at: any;
+	    since: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new Transaction(source);
+	        return new SubsystemStatus(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.transaction_id = source["transaction_id"];
-	        this.user_id = source["user_id"];
-	        this.type = source["type"];
-	        this.amount = source["amount"];
-	        this.currency = source["currency"];
-	        this.status = source["status"];
-	        this.description = source["description"];
-	        this.payment_id = source["payment_id"];
-	        this.created_at = this.convertValues(source["created_at"], null);
-	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.name = source["name"];
+	        this.state = source["state"];
+	        this.error = source["error"];
+	        this.since = this.convertValues(source["since"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TextRange {
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TextRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	export class TextHighlight {
+	    field: string;
+	    text: string;
+	    matches: TextRange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TextHighlight(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.text = source["text"];
+	        this.matches = this.convertValues(source["matches"], TextRange);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -122,9 +869,12 @@ export namespace main {
 		    return a;
 		}
 	}
+	
+	
 	export class TransactionListResponse {
 	    transactions: Transaction[];
 	    total: number;
+	    next_cursor?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new TransactionListResponse(source);
@@ -134,6 +884,133 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.transactions = this.convertValues(source["transactions"], Transaction);
 	        this.total = source["total"];
+	        this.next_cursor = source["next_cursor"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TransactionNotesRequest {
+	    user_id: string;
+	    transaction_id: string;
+	    notes: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TransactionNotesRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.transaction_id = source["transaction_id"];
+	        this.notes = source["notes"];
+	    }
+	}
+	export class TransactionQueryRequest {
+	    user_id: string;
+	    types?: string[];
+	    statuses?: string[];
+	    currency?: string;
+	    date_from?: string;
+	    date_to?: string;
+	    min_amount?: number;
+	    max_amount?: number;
+	    cursor?: string;
+	    limit?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TransactionQueryRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.types = source["types"];
+	        this.statuses = source["statuses"];
+	        this.currency = source["currency"];
+	        this.date_from = source["date_from"];
+	        this.date_to = source["date_to"];
+	        this.min_amount = source["min_amount"];
+	        this.max_amount = source["max_amount"];
+	        this.cursor = source["cursor"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class TransactionSearchRequest {
+	    user_id: string;
+	    query: string;
+	    limit?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TransactionSearchRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.query = source["query"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class TransactionSearchResult {
+	    transaction: Transaction;
+	    score: number;
+	    highlights: TextHighlight[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TransactionSearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.transaction = this.convertValues(source["transaction"], Transaction);
+	        this.score = source["score"];
+	        this.highlights = this.convertValues(source["highlights"], TextHighlight);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TransactionSearchResponse {
+	    results: TransactionSearchResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TransactionSearchResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.results = this.convertValues(source["results"], TransactionSearchResult);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -154,6 +1031,7 @@ export namespace main {
 		    return a;
 		}
 	}
+	
 	export class User {
 	    user_id: string;
 	    login: string;
@@ -216,6 +1094,56 @@ export namespace main {
 	        this.password_hash = source["password_hash"];
 	    }
 	}
+	export class WebhookEvent {
+	    event_id: string;
+	    provider: string;
+	    type: string;
+	    payload: string;
+	    status: string;
+	    error?: string;
+	    deliveries: number;
+	    attempts: number;
+	    // Go type: time
+	    received_at: any;
+	    // Go type: time
+	    processed_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new WebhookEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.event_id = source["event_id"];
+	        this.provider = source["provider"];
+	        this.type = source["type"];
+	        this.payload = source["payload"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.deliveries = source["deliveries"];
+	        this.attempts = source["attempts"];
+	        this.received_at = this.convertValues(source["received_at"], null);
+	        this.processed_at = this.convertValues(source["processed_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/health"
+)
+
+// healthCheckTimeout bounds every component check
+const healthCheckTimeout = 2 * time.Second
+
+// webhookBacklogAge is how long an event may sit unprocessed before it
+// counts as backlog
+const webhookBacklogAge = 5 * time.Minute
+
+// newHealthChecker builds the component checks behind /readyz and
+// GetDatabaseStatus. Only MongoDB is critical: without it nothing works,
+// while the rest degrade single features.
+func (a *App) newHealthChecker() *health.Checker {
+	return health.NewChecker(healthCheckTimeout,
+		health.Check{Name: "mongodb", Critical: true, Run: a.checkMongoDB},
+		health.Check{Name: "stripe", Run: a.checkStripe},
+		health.Check{Name: "scheduler", Run: a.checkScheduler},
+		health.Check{Name: "webhooks", Run: a.checkWebhooks},
+	)
+}
+
+func (a *App) checkMongoDB(ctx context.Context) health.Result {
+	if a.db == nil {
+		return health.Result{Status: health.StatusDown, Error: "database not initialized"}
+	}
+	if err := a.db.Ping(ctx); err != nil {
+		return health.Result{Status: health.StatusDown, Error: err.Error()}
+	}
+	return health.Result{Status: health.StatusOK}
+}
+
+// checkStripe validates configuration only; it does not call Stripe
+func (a *App) checkStripe(ctx context.Context) health.Result {
+	key := a.config.StripeSecretKey
+	if key == "" {
+		return health.Result{Status: health.StatusDown, Error: "STRIPE_SECRET_KEY is not set"}
+	}
+
+	mode := "unknown"
+	switch {
+	case strings.HasPrefix(key, "sk_test_"), strings.HasPrefix(key, "rk_test_"):
+		mode = "test"
+	case strings.HasPrefix(key, "sk_live_"), strings.HasPrefix(key, "rk_live_"):
+		mode = "live"
+	}
+	result := health.Result{Status: health.StatusOK, Details: map[string]any{"mode": mode}}
+
+	var problems []string
+	if mode == "unknown" {
+		problems = append(problems, "STRIPE_SECRET_KEY does not look like a Stripe secret key")
+	}
+	if a.config.StripePublishableKey == "" {
+		problems = append(problems, "STRIPE_PUBLISHABLE_KEY is not set")
+	}
+	if a.config.StripeWebhookSecret == "" {
+		problems = append(problems, "STRIPE_WEBHOOK_SECRET is not set")
+	}
+	if len(problems) > 0 {
+		result.Status = health.StatusDegraded
+		result.Error = strings.Join(problems, "; ")
+	}
+	return result
+}
+
+func (a *App) checkScheduler(ctx context.Context) health.Result {
+	if a.scheduler == nil || !a.scheduler.Running() {
+		return health.Result{Status: health.StatusDown, Error: "scheduler not running"}
+	}
+
+	result := health.Result{Status: health.StatusOK}
+	jobs := map[string]any{}
+	var failing []string
+	for _, s := range a.scheduler.Status() {
+		jobs[s.Name] = map[string]any{
+			"running":  s.Running,
+			"next_run": s.NextRun,
+			"last_ok":  s.LastError == "",
+		}
+		if s.LastError != "" {
+			failing = append(failing, fmt.Sprintf("%s: %s", s.Name, s.LastError))
+		}
+	}
+	result.Details = map[string]any{"jobs": jobs}
+	if len(failing) > 0 {
+		result.Status = health.StatusDegraded
+		result.Error = "last run failed for " + strings.Join(failing, "; ")
+	}
+	return result
+}
+
+func (a *App) checkWebhooks(ctx context.Context) health.Result {
+	if a.db == nil {
+		return health.Result{Status: health.StatusDown, Error: "database not initialized"}
+	}
+
+	failed, err := a.db.CountWebhookEvents(ctx, database.WebhookStatusFailed, time.Now())
+	if err != nil {
+		return health.Result{Status: health.StatusDown, Error: err.Error()}
+	}
+	stuck, err := a.db.CountWebhookEvents(ctx, database.WebhookStatusReceived, time.Now().Add(-webhookBacklogAge))
+	if err != nil {
+		return health.Result{Status: health.StatusDown, Error: err.Error()}
+	}
+
+	result := health.Result{
+		Status:  health.StatusOK,
+		Details: map[string]any{"failed": failed, "unprocessed": stuck},
+	}
+	if failed > 0 || stuck > 0 {
+		result.Status = health.StatusDegraded
+		result.Error = fmt.Sprintf("%d failed and %d unprocessed webhook events", failed, stuck)
+	}
+	return result
+}
+
+// handleLivez reports that the process is up and serving; it deliberately
+// checks nothing else so a database outage does not get the app restarted
+func (a *App) handleLivez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": string(health.StatusOK)})
+}
+
+// handleReadyz runs every component check. It answers 503 only when a
+// critical component is down; a degraded app still serves traffic.
+func (a *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := a.newHealthChecker().Run(r.Context())
+
+	code := http.StatusOK
+	if report.Status == health.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, report)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
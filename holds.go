@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"pocket-wallet/internal/database"
+)
+
+// CreateHold reserves part of the user's available balance for a pending outflow
+func (a *App) CreateHold(req HoldRequest) (_ *Hold, err error) {
+	ctx, done := observe("CreateHold")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbHold, err := a.wallet.CreateHold(ctx, database.HoldRequest{
+		UserID:      req.UserID,
+		Type:        req.Type,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		TTL:         time.Duration(req.ExpiresInSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return holdFromDB(dbHold), nil
+}
+
+// CaptureHold debits a held amount, creating a completed transaction
+func (a *App) CaptureHold(req HoldActionRequest) (_ *Transaction, err error) {
+	ctx, done := observe("CaptureHold")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbTx, err := a.wallet.CaptureHold(ctx, req.UserID, req.HoldID, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactionFromDB(dbTx), nil
+}
+
+// ReleaseHold cancels a hold and makes its funds available again
+func (a *App) ReleaseHold(req HoldActionRequest) (err error) {
+	ctx, done := observe("ReleaseHold")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return errDatabaseUnavailable
+	}
+
+	return a.wallet.ReleaseHold(ctx, req.UserID, req.HoldID)
+}
+
+// GetUserHolds lists the user's active holds
+func (a *App) GetUserHolds(userID string) (_ []Hold, err error) {
+	ctx, done := observe("GetUserHolds")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbHolds, err := a.wallet.Holds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	holds := make([]Hold, len(dbHolds))
+	for i, dbHold := range dbHolds {
+		holds[i] = *holdFromDB(dbHold)
+	}
+
+	return holds, nil
+}
+
+// GetAvailableBalance returns the total, held and available ledger balance
+func (a *App) GetAvailableBalance(userID string, currencyCode string) (_ *AvailableBalanceResponse, err error) {
+	ctx, done := observe("GetAvailableBalance")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	summary, err := a.wallet.AvailableBalance(ctx, userID, currencyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return balanceFromDB(summary), nil
+}
+
+// GetCurrencyBalances returns the ledger balance of every currency the user holds
+func (a *App) GetCurrencyBalances(userID string) (_ *CurrencyBalancesResponse, err error) {
+	ctx, done := observe("GetCurrencyBalances")
+	defer done(&err)
+
+	return a.getCurrencyBalances(ctx, userID)
+}
+
+// getCurrencyBalances implements GetCurrencyBalances
+func (a *App) getCurrencyBalances(ctx context.Context, userID string) (*CurrencyBalancesResponse, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	summaries, err := a.wallet.CurrencyBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]AvailableBalanceResponse, len(summaries))
+	for i, summary := range summaries {
+		balances[i] = *balanceFromDB(summary)
+	}
+
+	return &CurrencyBalancesResponse{Balances: balances}, nil
+}
+
+// balanceFromDB converts a database balance summary to the main type
+func balanceFromDB(summary *database.BalanceSummary) *AvailableBalanceResponse {
+	return &AvailableBalanceResponse{
+		Currency:  summary.Currency,
+		Total:     summary.Total,
+		Held:      summary.Held,
+		Available: summary.Available,
+	}
+}
+
+// holdFromDB converts a database hold to the main type
+func holdFromDB(dbHold *database.Hold) *Hold {
+	return &Hold{
+		HoldID:        dbHold.HoldID,
+		UserID:        dbHold.UserID,
+		Type:          dbHold.Type,
+		Amount:        dbHold.Amount,
+		Currency:      dbHold.Currency,
+		Status:        dbHold.Status,
+		Description:   dbHold.Description,
+		TransactionID: dbHold.TransactionID,
+		ExpiresAt:     dbHold.ExpiresAt,
+		CreatedAt:     dbHold.CreatedAt,
+		UpdatedAt:     dbHold.UpdatedAt,
+	}
+}
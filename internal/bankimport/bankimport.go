@@ -0,0 +1,127 @@
+package bankimport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pocket-wallet/internal/errcode"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Format identifies a bank statement format
+type Format string
+
+const (
+	FormatMT940   Format = "mt940"
+	FormatCAMT053 Format = "camt053"
+)
+
+// Entry is one booked or pending movement on a bank account
+type Entry struct {
+	BankReference string // Unique per account, used to detect re-imports
+	BookingDate   time.Time
+	ValueDate     time.Time
+	Amount        int64 // Hundredths as written in the file, negative for debits
+	Currency      string
+	Description   string
+	Counterparty  string
+	Booked        bool // False for pending entries
+	Line          int  // Line of the file the entry starts on
+}
+
+// LineError reports input that could not be turned into an entry
+type LineError struct {
+	Line   int    `json:"line"` // Line of the file the problem starts on
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// Statement is the parsed content of one statement file
+type Statement struct {
+	Format    Format
+	AccountID string
+	Currency  string
+	Entries   []Entry
+	Errors    []LineError
+}
+
+// Parse detects the statement format and parses it. Problems with single
+// entries are collected in Statement.Errors; an error is only returned
+// when the file as a whole is unreadable.
+func Parse(data []byte) (*Statement, error) {
+	data = toUTF8(data)
+	trimmed := bytes.TrimSpace(data)
+
+	// The parsers get the leading blank lines too, so line numbers match
+	// the file
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCAMT053(data)
+	case bytes.Contains(trimmed, []byte(":20:")) && bytes.Contains(trimmed, []byte(":61:")):
+		return parseMT940(data)
+	}
+	return nil, errcode.New(errcode.InvalidInput, "unrecognized statement format, expected MT940 or CAMT.053")
+}
+
+// toUTF8 converts Windows-1250 exports, still common in Polish banking,
+// to UTF-8 and leaves valid UTF-8 untouched
+func toUTF8(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return data
+	}
+	decoded, err := charmap.Windows1250.NewDecoder().Bytes(data)
+	if err != nil {
+		return data
+	}
+	return decoded
+}
+
+// parseAmount reads "1234,56" or "1234.56" into hundredths
+func parseAmount(s string) (int64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("too many decimals in amount %q", s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	value, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return value, nil
+}
+
+// fallbackReference builds a stable reference for entries the bank did not
+// give one; occurrence separates identical movements in the same file
+func fallbackReference(account string, e *Entry, occurrence int) string {
+	key := fmt.Sprintf("%s|%s|%d|%s|%s|%d", account, e.BookingDate.Format("2006-01-02"), e.Amount, e.Currency, e.Description, occurrence)
+	sum := sha256.Sum256([]byte(key))
+	return "H" + hex.EncodeToString(sum[:12])
+}
+
+// assignFallbackReferences fills in missing bank references
+func assignFallbackReferences(s *Statement) {
+	seen := make(map[string]int)
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		if e.BankReference != "" {
+			continue
+		}
+		key := fmt.Sprintf("%s|%d|%s", e.BookingDate.Format("2006-01-02"), e.Amount, e.Description)
+		seen[key]++
+		e.BankReference = fallbackReference(s.AccountID, e, seen[key])
+	}
+}
@@ -0,0 +1,416 @@
+package bankimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+const mt940Statement = `{1:F01BREXPLPWAXXX0000000000}{2:I940BREXPLPWXXXXN}{4:
+:20:ST240105
+:25:/PL61109010140000071219812874
+:28C:1/1
+:60F:C240102PLN1000,00
+:61:2401030103C150,00NTRFREF1//BANKREF001
+:86:020~00TRF~20Wynagrodzenie~21 grudzień~32ACME SP. Z O.O.
+:61:2401040104D49,99NCHGNONREF
+:86:Opłata za kartę
+:61:2401040104RC10,00NTRFREF3//BANKREF003
+:86:~20Zwrot~32Sklep
+:61:24010501X5D1,00NTRF
+:61:2412311231D25,50NTRFREF5//BANKREF005
+:62F:C240105PLN1090,51
+-}
+`
+
+func TestParseMT940(t *testing.T) {
+	stmt, err := Parse([]byte(mt940Statement))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if stmt.Format != FormatMT940 {
+		t.Errorf("Format = %q, want %q", stmt.Format, FormatMT940)
+	}
+	if stmt.AccountID != "PL61109010140000071219812874" {
+		t.Errorf("AccountID = %q", stmt.AccountID)
+	}
+	if stmt.Currency != "PLN" {
+		t.Errorf("Currency = %q, want PLN", stmt.Currency)
+	}
+
+	want := []Entry{
+		{
+			BankReference: "BANKREF001",
+			BookingDate:   date(2024, 1, 3),
+			ValueDate:     date(2024, 1, 3),
+			Amount:        15000,
+			Currency:      "PLN",
+			Description:   "Wynagrodzenie grudzień",
+			Counterparty:  "ACME SP. Z O.O.",
+			Booked:        true,
+			Line:          6,
+		},
+		{
+			BookingDate: date(2024, 1, 4),
+			ValueDate:   date(2024, 1, 4),
+			Amount:      -4999,
+			Currency:    "PLN",
+			Description: "Opłata za kartę",
+			Booked:      true,
+			Line:        8,
+		},
+		{
+			BankReference: "BANKREF003",
+			BookingDate:   date(2024, 1, 4),
+			ValueDate:     date(2024, 1, 4),
+			Amount:        -1000,
+			Currency:      "PLN",
+			Description:   "Zwrot",
+			Counterparty:  "Sklep",
+			Booked:        true,
+			Line:          10,
+		},
+		{
+			// The entry date rolls over into the previous year
+			BankReference: "BANKREF005",
+			BookingDate:   date(2024, 12, 31),
+			ValueDate:     date(2024, 12, 31),
+			Amount:        -2550,
+			Currency:      "PLN",
+			Booked:        true,
+			Line:          13,
+		},
+	}
+
+	if len(stmt.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(stmt.Entries), len(want), stmt.Entries)
+	}
+	for i := range want {
+		got := stmt.Entries[i]
+		if want[i].BankReference == "" {
+			// Filled in from the entry's content
+			if !strings.HasPrefix(got.BankReference, "H") {
+				t.Errorf("entry %d: BankReference = %q, want a fallback reference", i, got.BankReference)
+			}
+			got.BankReference = ""
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("entry %d:\n got %+v\nwant %+v", i, got, want[i])
+		}
+	}
+
+	if len(stmt.Errors) != 1 || stmt.Errors[0].Line != 12 {
+		t.Errorf("Errors = %+v, want the :61: line 12", stmt.Errors)
+	}
+}
+
+func TestParseStatementLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		wantOK      bool
+		wantAmount  int64
+		wantRef     string
+		wantBooking time.Time
+	}{
+		{"credit with bank reference", "240103C150,00NTRFREF1//BANK1", true, 15000, "BANK1", date(2024, 1, 3)},
+		{"debit with customer reference", "240103D0,5NTRFCUST1", true, -50, "CUST1", date(2024, 1, 3)},
+		{"reversed debit adds", "240103RD12,34NTRFNONREF", true, 1234, "", date(2024, 1, 3)},
+		{"funds code", "240103CX7,00NTRFREF", true, 700, "REF", date(2024, 1, 3)},
+		{"entry date next year", "2312310102C1,00NTRFREF", true, 100, "REF", date(2024, 1, 2)},
+		{"supplementary details", "240103C1,00NTRFREF\nPrzelew", true, 100, "REF", date(2024, 1, 3)},
+		{"missing mark", "240103150,00NTRFREF", false, 0, "", time.Time{}},
+		{"bad date", "241303C1,00NTRFREF", false, 0, "", time.Time{}},
+		{"too many decimals", "240103C1,001NTRFREF", false, 0, "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := parseStatementLine(tt.value, "PLN")
+			if ok != tt.wantOK {
+				t.Fatalf("parseStatementLine(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if entry.Amount != tt.wantAmount {
+				t.Errorf("Amount = %d, want %d", entry.Amount, tt.wantAmount)
+			}
+			if entry.BankReference != tt.wantRef {
+				t.Errorf("BankReference = %q, want %q", entry.BankReference, tt.wantRef)
+			}
+			if !entry.BookingDate.Equal(tt.wantBooking) {
+				t.Errorf("BookingDate = %v, want %v", entry.BookingDate, tt.wantBooking)
+			}
+		})
+	}
+}
+
+func TestParseInformation(t *testing.T) {
+	tests := []struct {
+		name             string
+		value            string
+		wantDescription  string
+		wantCounterparty string
+	}{
+		{"tilde subfields", "020~00TRF~20Czynsz~21 styczeń~32Jan~33 Kowalski", "Czynsz styczeń", "Jan Kowalski"},
+		{"angle subfields", "020<00TRF<20Faktura 1/2024<32Firma", "Faktura 1/2024", "Firma"},
+		{"continued across lines", "~20Abonament~21 \ninternet~32Dostawca", "Abonament internet", "Dostawca"},
+		{"unstructured", "Wypłata z  bankomatu\nWarszawa", "Wypłata z bankomatu Warszawa", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			description, counterparty := parseInformation(tt.value)
+			if description != tt.wantDescription || counterparty != tt.wantCounterparty {
+				t.Errorf("parseInformation(%q) = %q, %q, want %q, %q",
+					tt.value, description, counterparty, tt.wantDescription, tt.wantCounterparty)
+			}
+		})
+	}
+}
+
+const camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Acct><Id><IBAN>PL61109010140000071219812874</IBAN></Id><Ccy>PLN</Ccy></Acct>
+      <Ntry>
+        <Amt Ccy="PLN">150.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2024-01-03</Dt></BookgDt>
+        <ValDt><Dt>2024-01-02</Dt></ValDt>
+        <AcctSvcrRef>CAMTREF1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <RmtInf><Ustrd>Wynagrodzenie</Ustrd><Ustrd>grudzień</Ustrd></RmtInf>
+          <RltdPties><Dbtr><Nm>ACME</Nm></Dbtr><Cdtr><Nm>Jan Kowalski</Nm></Cdtr></RltdPties>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">12.5</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><DtTm>2024-01-04T10:15:00+01:00</DtTm></BookgDt>
+        <AddtlNtryInf>Kawiarnia</AddtlNtryInf>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E-7</EndToEndId></Refs>
+          <RltdPties><Dbtr><Nm>Jan Kowalski</Nm></Dbtr><Cdtr><Nm>Kawiarnia</Nm></Cdtr></RltdPties>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt>20.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <RvslInd>true</RvslInd>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+        <AcctSvcrRef>CAMTREF3</AcctSvcrRef>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="PLN">1.00</Amt>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="PLN">1.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+func TestParseCAMT053(t *testing.T) {
+	stmt, err := Parse([]byte(camtStatement))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if stmt.Format != FormatCAMT053 {
+		t.Errorf("Format = %q, want %q", stmt.Format, FormatCAMT053)
+	}
+	if stmt.AccountID != "PL61109010140000071219812874" || stmt.Currency != "PLN" {
+		t.Errorf("account = %q %q", stmt.AccountID, stmt.Currency)
+	}
+
+	want := []Entry{
+		{
+			BankReference: "CAMTREF1",
+			BookingDate:   date(2024, 1, 3),
+			ValueDate:     date(2024, 1, 2),
+			Amount:        15000,
+			Currency:      "PLN",
+			Description:   "Wynagrodzenie grudzień",
+			Counterparty:  "ACME",
+			Booked:        true,
+			Line:          6,
+		},
+		{
+			BankReference: "E2E-7",
+			BookingDate:   time.Date(2024, 1, 4, 10, 15, 0, 0, time.FixedZone("", 3600)),
+			ValueDate:     time.Date(2024, 1, 4, 10, 15, 0, 0, time.FixedZone("", 3600)),
+			Amount:        -1250,
+			Currency:      "EUR",
+			Description:   "Kawiarnia",
+			Counterparty:  "Kawiarnia",
+			Booked:        false,
+			Line:          18,
+		},
+		{
+			// A reversal of a debit is a credit, in the account currency
+			BankReference: "CAMTREF3",
+			BookingDate:   date(2024, 1, 5),
+			ValueDate:     date(2024, 1, 5),
+			Amount:        2000,
+			Currency:      "PLN",
+			Booked:        true,
+			Line:          29,
+		},
+	}
+
+	if len(stmt.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(stmt.Entries), len(want), stmt.Entries)
+	}
+	for i := range want {
+		got, w := stmt.Entries[i], want[i]
+		if !got.BookingDate.Equal(w.BookingDate) || !got.ValueDate.Equal(w.ValueDate) {
+			t.Errorf("entry %d: dates = %v %v, want %v %v", i, got.BookingDate, got.ValueDate, w.BookingDate, w.ValueDate)
+		}
+		got.BookingDate, got.ValueDate = w.BookingDate, w.ValueDate
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("entry %d:\n got %+v\nwant %+v", i, got, w)
+		}
+	}
+
+	wantErrors := []LineError{
+		{Line: 36, Text: "1.00 PLN", Reason: "missing credit/debit indicator"},
+		{Line: 40, Text: "1.00 PLN", Reason: "missing booking date"},
+	}
+	if !reflect.DeepEqual(stmt.Errors, wantErrors) {
+		t.Errorf("Errors = %+v, want %+v", stmt.Errors, wantErrors)
+	}
+}
+
+func TestCAMTReversal(t *testing.T) {
+	tests := []struct {
+		direction string
+		want      int64
+	}{
+		// The reversal of a debit
+		{"CRDT", 2000},
+		// The reversal of a credit
+		{"DBIT", -2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.direction, func(t *testing.T) {
+			data := `<Document><BkToCstmrStmt><Stmt><Acct><Ccy>PLN</Ccy></Acct><Ntry>
+<Amt>20.00</Amt><CdtDbtInd>` + tt.direction + `</CdtDbtInd><RvslInd>true</RvslInd>
+<BookgDt><Dt>2024-01-05</Dt></BookgDt><AcctSvcrRef>R1</AcctSvcrRef>
+</Ntry></Stmt></BkToCstmrStmt></Document>`
+			stmt, err := Parse([]byte(data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(stmt.Entries) != 1 {
+				t.Fatalf("got %d entries, errors %+v", len(stmt.Entries), stmt.Errors)
+			}
+			if got := stmt.Entries[0].Amount; got != tt.want {
+				t.Errorf("Amount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"csv", "date,amount\n2024-01-03,150.00\n"},
+		{"broken xml", "<Document><BkToCstmrStmt>"},
+		{"no statements", "<Document></Document>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); err == nil {
+				t.Error("Parse() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseWindows1250(t *testing.T) {
+	encoded, err := charmap.Windows1250.NewEncoder().String(mt940Statement)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	stmt, err := Parse([]byte(encoded))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := stmt.Entries[1].Description; got != "Opłata za kartę" {
+		t.Errorf("Description = %q, want it decoded from Windows-1250", got)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1234,56", 123456, false},
+		{"1234.56", 123456, false},
+		{"1234,5", 123450, false},
+		{"1234,", 123400, false},
+		{",99", 99, false},
+		{" 7 ", 700, false},
+		{"1,234", 0, true},
+		{"12a,00", 0, true},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAmount(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAmount(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAmount(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackReferences(t *testing.T) {
+	entry := Entry{BookingDate: date(2024, 1, 3), Amount: -500, Currency: "PLN", Description: "Kawa"}
+	stmt := &Statement{AccountID: "PL01", Entries: []Entry{entry, entry, {BankReference: "REF", Amount: -500}}}
+
+	assignFallbackReferences(stmt)
+
+	first, second := stmt.Entries[0].BankReference, stmt.Entries[1].BankReference
+	if first == "" || first == second {
+		t.Errorf("identical entries got references %q and %q, want distinct ones", first, second)
+	}
+	if stmt.Entries[2].BankReference != "REF" {
+		t.Errorf("bank reference was replaced: %q", stmt.Entries[2].BankReference)
+	}
+
+	// Re-importing the same file yields the same references
+	again := &Statement{AccountID: "PL01", Entries: []Entry{entry, entry}}
+	assignFallbackReferences(again)
+	if again.Entries[0].BankReference != first || again.Entries[1].BankReference != second {
+		t.Error("fallback references are not stable across imports")
+	}
+}
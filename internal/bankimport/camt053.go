@@ -0,0 +1,183 @@
+package bankimport
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+)
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) time() (time.Time, bool) {
+	if d.Date != "" {
+		t, err := time.Parse("2006-01-02", d.Date)
+		return t, err == nil
+	}
+	if d.DateTime != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+			if t, err := time.Parse(layout, d.DateTime); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+type camtEntry struct {
+	line   int // Where <Ntry> starts, set by UnmarshalXML
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"` // Direction of this entry, reversals included
+	Status      struct {
+		Text string `xml:",chardata"`
+		Code string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate    camtDate `xml:"BookgDt"`
+	ValueDate      camtDate `xml:"ValDt"`
+	ServicerRef    string   `xml:"AcctSvcrRef"`
+	AdditionalInfo string   `xml:"AddtlNtryInf"`
+	Details        []struct {
+		Refs struct {
+			ServicerRef string `xml:"AcctSvcrRef"`
+			EndToEndID  string `xml:"EndToEndId"`
+		} `xml:"Refs"`
+		Unstructured []string  `xml:"RmtInf>Ustrd"`
+		Debtor       camtParty `xml:"RltdPties>Dbtr"`
+		Creditor     camtParty `xml:"RltdPties>Cdtr"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// UnmarshalXML decodes an entry and notes the line it starts on
+func (n *camtEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.line, _ = d.InputPos()
+	type plain camtEntry // Without this method
+	return d.DecodeElement((*plain)(n), &start)
+}
+
+type camtDocument struct {
+	Statements []struct {
+		Account struct {
+			IBAN  string `xml:"Id>IBAN"`
+			Other string `xml:"Id>Othr>Id"`
+			Ccy   string `xml:"Ccy"`
+		} `xml:"Acct"`
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+// parseCAMT053 reads an ISO 20022 BankToCustomerStatement (camt.053)
+func parseCAMT053(data []byte) (*Statement, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, errcode.Wrap(errcode.InvalidInput, "invalid CAMT.053 XML", err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, errcode.New(errcode.InvalidInput, "CAMT.053 file contains no statements")
+	}
+
+	stmt := &Statement{Format: FormatCAMT053}
+	for _, s := range doc.Statements {
+		if stmt.AccountID == "" {
+			stmt.AccountID = s.Account.IBAN
+			if stmt.AccountID == "" {
+				stmt.AccountID = s.Account.Other
+			}
+			stmt.Currency = s.Account.Ccy
+		}
+
+		for _, n := range s.Entries {
+			entry, reason := camtToEntry(&n, stmt.Currency)
+			if reason != "" {
+				stmt.Errors = append(stmt.Errors, LineError{
+					Line:   n.line,
+					Text:   strings.TrimSpace(n.Amount.Value + " " + n.Amount.Currency + " " + n.ServicerRef),
+					Reason: reason,
+				})
+				continue
+			}
+			stmt.Entries = append(stmt.Entries, entry)
+		}
+	}
+
+	assignFallbackReferences(stmt)
+	return stmt, nil
+}
+
+func camtToEntry(n *camtEntry, accountCurrency string) (Entry, string) {
+	amount, err := parseAmount(n.Amount.Value)
+	if err != nil {
+		return Entry{}, err.Error()
+	}
+
+	credit := n.CreditDebit == "CRDT"
+	if !credit && n.CreditDebit != "DBIT" {
+		return Entry{}, "missing credit/debit indicator"
+	}
+	// Unlike MT940's RC and RD, a reversal (RvslInd) already carries its
+	// own direction: a CRDT reversal undoes a debit and credits the account
+	if !credit {
+		amount = -amount
+	}
+
+	bookingDate, ok := n.BookingDate.time()
+	if !ok {
+		return Entry{}, "missing booking date"
+	}
+	valueDate, ok := n.ValueDate.time()
+	if !ok {
+		valueDate = bookingDate
+	}
+
+	status := n.Status.Code
+	if status == "" {
+		status = strings.TrimSpace(n.Status.Text)
+	}
+
+	currency := n.Amount.Currency
+	if currency == "" {
+		currency = accountCurrency
+	}
+
+	entry := Entry{
+		BankReference: n.ServicerRef,
+		BookingDate:   bookingDate,
+		ValueDate:     valueDate,
+		Amount:        amount,
+		Currency:      currency,
+		Description:   strings.TrimSpace(n.AdditionalInfo),
+		Booked:        status == "" || status == "BOOK",
+		Line:          n.line,
+	}
+
+	if len(n.Details) > 0 {
+		d := n.Details[0]
+		if entry.BankReference == "" {
+			entry.BankReference = d.Refs.ServicerRef
+		}
+		if entry.BankReference == "" && d.Refs.EndToEndID != "" && d.Refs.EndToEndID != "NOTPROVIDED" {
+			entry.BankReference = d.Refs.EndToEndID
+		}
+		if len(d.Unstructured) > 0 {
+			entry.Description = strings.TrimSpace(strings.Join(d.Unstructured, " "))
+		}
+		// The counterparty is whoever is on the other side of the movement
+		if credit {
+			entry.Counterparty = d.Debtor.Name
+		} else {
+			entry.Counterparty = d.Creditor.Name
+		}
+	}
+
+	return entry, ""
+}
@@ -0,0 +1,168 @@
+package bankimport
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// statementLine matches the :61: field:
+// value date, optional entry date, debit/credit mark, optional funds code,
+// amount, transaction type, customer reference and optional bank reference
+var statementLine = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d{0,2})([NFS][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+
+// balanceLine matches :60F:/:62F: style balances, e.g. C240102PLN1234,56
+var balanceLine = regexp.MustCompile(`^[CD]\d{6}([A-Z]{3})`)
+
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+// parseMT940 reads a SWIFT MT940 statement as exported by Polish banks.
+// Several statements may be concatenated in one file.
+func parseMT940(data []byte) (*Statement, error) {
+	stmt := &Statement{Format: FormatMT940}
+
+	var fields []mt940Field
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		line := strings.TrimRight(raw, " \r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", trimmed == "-", trimmed == "-}", strings.HasPrefix(trimmed, "{1:"), strings.HasPrefix(trimmed, "{4:"):
+			continue
+		case strings.HasPrefix(line, ":"):
+			tagEnd := strings.Index(line[1:], ":")
+			if tagEnd < 0 {
+				stmt.Errors = append(stmt.Errors, LineError{Line: i + 1, Text: line, Reason: "malformed field tag"})
+				continue
+			}
+			fields = append(fields, mt940Field{tag: line[1 : tagEnd+1], value: line[tagEnd+2:], line: i + 1})
+		case len(fields) > 0:
+			// Continuation of the previous field
+			fields[len(fields)-1].value += "\n" + line
+		default:
+			stmt.Errors = append(stmt.Errors, LineError{Line: i + 1, Text: line, Reason: "text outside of any field"})
+		}
+	}
+
+	var current *Entry
+	for _, f := range fields {
+		switch f.tag {
+		case "25":
+			if stmt.AccountID == "" {
+				stmt.AccountID = strings.TrimPrefix(strings.TrimSpace(f.value), "/")
+			}
+		case "60F", "60M":
+			if m := balanceLine.FindStringSubmatch(strings.TrimSpace(f.value)); m != nil {
+				stmt.Currency = m[1]
+			}
+		case "61":
+			entry, ok := parseStatementLine(f.value, stmt.Currency)
+			if !ok {
+				stmt.Errors = append(stmt.Errors, LineError{Line: f.line, Text: ":61:" + f.value, Reason: "unparseable statement line"})
+				current = nil
+				continue
+			}
+			entry.Line = f.line
+			stmt.Entries = append(stmt.Entries, entry)
+			current = &stmt.Entries[len(stmt.Entries)-1]
+		case "86":
+			if current == nil {
+				continue
+			}
+			current.Description, current.Counterparty = parseInformation(f.value)
+			current = nil
+		}
+	}
+
+	assignFallbackReferences(stmt)
+	return stmt, nil
+}
+
+func parseStatementLine(value, currency string) (Entry, bool) {
+	first, supplementary, _ := strings.Cut(value, "\n")
+	m := statementLine.FindStringSubmatch(strings.TrimSpace(first))
+	if m == nil {
+		return Entry{}, false
+	}
+
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		return Entry{}, false
+	}
+	bookingDate := valueDate
+	if m[2] != "" {
+		// The entry date has no year; take the value date's, rolling over at new year
+		if entryDate, err := time.Parse("0102", m[2]); err == nil {
+			bookingDate = time.Date(valueDate.Year(), entryDate.Month(), entryDate.Day(), 0, 0, 0, 0, time.UTC)
+			if bookingDate.Sub(valueDate) > 180*24*time.Hour {
+				bookingDate = bookingDate.AddDate(-1, 0, 0)
+			} else if valueDate.Sub(bookingDate) > 180*24*time.Hour {
+				bookingDate = bookingDate.AddDate(1, 0, 0)
+			}
+		}
+	}
+
+	amount, err := parseAmount(m[5])
+	if err != nil {
+		return Entry{}, false
+	}
+	// Debits and reversed credits reduce the balance
+	if m[3] == "D" || m[3] == "RC" {
+		amount = -amount
+	}
+
+	entry := Entry{
+		BookingDate: bookingDate,
+		ValueDate:   valueDate,
+		Amount:      amount,
+		Currency:    currency,
+		Description: strings.TrimSpace(supplementary),
+		Booked:      true,
+	}
+
+	bankRef := strings.TrimSpace(m[8])
+	customerRef := strings.TrimSpace(m[7])
+	switch {
+	case bankRef != "":
+		entry.BankReference = bankRef
+	case customerRef != "" && customerRef != "NONREF":
+		entry.BankReference = customerRef
+	}
+
+	return entry, true
+}
+
+// parseInformation splits the :86: field. Polish banks use a structured
+// layout with ~NN (or <NN) subfields: ~20-~27 hold the title and ~32-~33
+// the counterparty name. Unstructured text becomes the description.
+func parseInformation(value string) (description, counterparty string) {
+	text := strings.ReplaceAll(value, "\n", "")
+	separator := ""
+	switch {
+	case strings.Contains(text, "~20"):
+		separator = "~"
+	case strings.Contains(text, "<20"):
+		separator = "<"
+	}
+	if separator == "" {
+		return strings.Join(strings.Fields(value), " "), ""
+	}
+
+	var title, name strings.Builder
+	for _, part := range strings.Split(text, separator)[1:] {
+		if len(part) < 2 {
+			continue
+		}
+		code, content := part[:2], part[2:]
+		switch {
+		case code >= "20" && code <= "27":
+			title.WriteString(content)
+		case code == "32" || code == "33":
+			name.WriteString(content)
+		}
+	}
+	return strings.TrimSpace(title.String()), strings.TrimSpace(name.String())
+}
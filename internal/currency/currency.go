@@ -0,0 +1,128 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pocket-wallet/internal/errcode"
+)
+
+// Default is the wallet currency used when a request does not name one
+const Default = "PLN"
+
+// Currency describes an ISO-4217 currency the wallet accepts
+type Currency struct {
+	Code       string `json:"code"`
+	MinorUnits int    `json:"minor_units"`    // Digits after the decimal point
+	StripeMin  int64  `json:"stripe_minimum"` // Smallest Stripe charge, in minor units
+}
+
+// supported lists currencies together with Stripe's minimum charge amounts
+var supported = map[string]Currency{
+	"AUD": {Code: "AUD", MinorUnits: 2, StripeMin: 50},
+	"BGN": {Code: "BGN", MinorUnits: 2, StripeMin: 100},
+	"CAD": {Code: "CAD", MinorUnits: 2, StripeMin: 50},
+	"CHF": {Code: "CHF", MinorUnits: 2, StripeMin: 50},
+	"CZK": {Code: "CZK", MinorUnits: 2, StripeMin: 1500},
+	"DKK": {Code: "DKK", MinorUnits: 2, StripeMin: 250},
+	"EUR": {Code: "EUR", MinorUnits: 2, StripeMin: 50},
+	"GBP": {Code: "GBP", MinorUnits: 2, StripeMin: 30},
+	"HUF": {Code: "HUF", MinorUnits: 2, StripeMin: 17500},
+	"JPY": {Code: "JPY", MinorUnits: 0, StripeMin: 50},
+	"NOK": {Code: "NOK", MinorUnits: 2, StripeMin: 300},
+	"PLN": {Code: "PLN", MinorUnits: 2, StripeMin: 200},
+	"RON": {Code: "RON", MinorUnits: 2, StripeMin: 200},
+	"SEK": {Code: "SEK", MinorUnits: 2, StripeMin: 300},
+	"USD": {Code: "USD", MinorUnits: 2, StripeMin: 50},
+}
+
+// Normalize validates a currency code and returns it in upper case.
+// An empty code resolves to Default.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return Default, nil
+	}
+	if _, ok := supported[code]; !ok {
+		return "", errcode.New(errcode.InvalidInput, "unsupported currency: "+code)
+	}
+	return code, nil
+}
+
+// Lookup returns the currency definition for a code
+func Lookup(code string) (Currency, error) {
+	code, err := Normalize(code)
+	if err != nil {
+		return Currency{}, err
+	}
+	return supported[code], nil
+}
+
+// Supported returns every accepted currency sorted by code
+func Supported() []Currency {
+	list := make([]Currency, 0, len(supported))
+	for _, c := range supported {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// ValidateStripeAmount checks an amount in minor units against Stripe's minimum charge
+func ValidateStripeAmount(code string, amount int64) error {
+	c, err := Lookup(code)
+	if err != nil {
+		return err
+	}
+	if amount < c.StripeMin {
+		return errcode.New(errcode.InvalidInput, fmt.Sprintf("minimum amount for %s is %s", c.Code, FormatMinor(c.StripeMin, c.Code)))
+	}
+	return nil
+}
+
+// ToMinor converts a major-unit amount (e.g. 12.34 PLN) to minor units (1234)
+func ToMinor(amount float64, code string) int64 {
+	return int64(math.Round(amount * scale(code)))
+}
+
+// FromMinor converts minor units back to a major-unit amount
+func FromMinor(amount int64, code string) float64 {
+	return float64(amount) / scale(code)
+}
+
+// FormatMinor renders minor units as an exact decimal string, e.g. "12.34 PLN"
+func FormatMinor(amount int64, code string) string {
+	return FormatDecimal(amount, code) + " " + strings.ToUpper(code)
+}
+
+// FormatDecimal renders minor units as an exact decimal string without the code
+func FormatDecimal(amount int64, code string) string {
+	units := minorUnits(code)
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if units == 0 {
+		return sign + digits
+	}
+	for len(digits) <= units {
+		digits = "0" + digits
+	}
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+}
+
+func minorUnits(code string) int {
+	if c, ok := supported[strings.ToUpper(code)]; ok {
+		return c.MinorUnits
+	}
+	return 2
+}
+
+func scale(code string) float64 {
+	return math.Pow10(minorUnits(code))
+}
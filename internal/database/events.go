@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserEventRetention is how long the event log keeps events for clients
+// resuming a stream; MongoDB deletes older ones. Keyed events are kept
+// until the user's credit marker passes them, as pending credits are read
+// from them however long the user stays away; the retention runs from
+// then on.
+const UserEventRetention = 7 * 24 * time.Hour
+
+// userEventCounter names the counter document user event IDs are taken from
+const userEventCounter = "user_events"
+
+// ErrEventRecorded is returned when an event with the same key is already
+// in the log
+var ErrEventRecorded = errors.New("event already recorded")
+
+// UserEvent is one entry in a user's event log. IDs increase across all
+// users, so a client resumes by asking for everything after the last ID it
+// saw.
+type UserEvent struct {
+	ID        int64      `json:"id" bson:"event_id"`
+	UserID    string     `json:"user_id" bson:"user_id"`
+	Type      string     `json:"type" bson:"type"`
+	Data      string     `json:"data" bson:"data"`       // JSON payload
+	Key       string     `json:"-" bson:"key,omitempty"` // Unique when set, so a retried append is not logged twice
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt *time.Time `json:"-" bson:"expires_at,omitempty"` // Nil for keyed events until they are credited
+}
+
+// userEventExpiry returns when MongoDB deletes an event created at
+// createdAt: keyed events wait for the credit marker, see
+// creditedEventsExpiry; others go UserEventRetention later
+func userEventExpiry(key string, createdAt time.Time) *time.Time {
+	if key != "" {
+		return nil
+	}
+	expiresAt := createdAt.Add(UserEventRetention)
+	return &expiresAt
+}
+
+// AppendUserEvent adds an event to a user's log. With a key, appending
+// the same event again fails with ErrEventRecorded. Appends for one user
+// take a lock, as the ID is allocated before the insert: a later ID stored
+// first would make readers skip the earlier one.
+func (db *MongoDB) AppendUserEvent(ctx context.Context, userID, eventType, key string, data []byte) (*UserEvent, error) {
+	ctx, span := startSpan(ctx, "AppendUserEvent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	unlock, err := db.lockUserEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err = db.counterCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": userEventCounter},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate event ID: %w", err)
+	}
+
+	now := time.Now()
+	event := &UserEvent{
+		ID:        counter.Seq,
+		UserID:    userID,
+		Type:      eventType,
+		Data:      string(data),
+		Key:       key,
+		CreatedAt: now,
+		ExpiresAt: userEventExpiry(key, now),
+	}
+	if _, err := db.userEventCollection.InsertOne(ctx, event); err != nil {
+		if key != "" && mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEventRecorded
+		}
+		return nil, fmt.Errorf("failed to append event: %w", err)
+	}
+
+	return event, nil
+}
+
+// GetUserEventsAfter lists a user's events with IDs above afterID, oldest
+// first
+func (db *MongoDB) GetUserEventsAfter(ctx context.Context, userID string, afterID int64, limit int) ([]*UserEvent, error) {
+	ctx, span := startSpan(ctx, "GetUserEventsAfter")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	filter := bson.M{
+		"user_id":  userID,
+		"event_id": bson.M{"$gt": afterID},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "event_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := db.userEventCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*UserEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode events: %w", err)
+	}
+
+	return events, nil
+}
+
+// LastUserEventID returns the ID of a user's newest event, or 0 when the
+// log holds none
+func (db *MongoDB) LastUserEventID(ctx context.Context, userID string) (int64, error) {
+	ctx, span := startSpan(ctx, "LastUserEventID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "event_id", Value: -1}}).SetLimit(1)
+	cursor, err := db.userEventCollection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last event: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*UserEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return 0, fmt.Errorf("failed to decode events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	return events[0].ID, nil
+}
+
+// creditedEventsExpiry selects a user's keyed events at or before the
+// credit marker upTo that are still kept, and starts their retention at
+// now. Events past the marker, such as an uncredited deposit, stay.
+func creditedEventsExpiry(userID string, upTo int64, now time.Time) (filter, update bson.M) {
+	filter = bson.M{
+		"user_id":    userID,
+		"event_id":   bson.M{"$lte": upTo},
+		"expires_at": bson.M{"$exists": false},
+	}
+	update = bson.M{"$set": bson.M{"expires_at": now.Add(UserEventRetention)}}
+	return filter, update
+}
+
+// expireCreditedEvents lets MongoDB delete the keyed events the credit
+// marker has passed. A failure is only logged: the balance is already
+// updated, and the next credit retries.
+func (db *MongoDB) expireCreditedEvents(ctx context.Context, userID string, upTo int64) {
+	filter, update := creditedEventsExpiry(userID, upTo, time.Now())
+	if _, err := db.userEventCollection.UpdateMany(ctx, filter, update); err != nil {
+		slog.Warn("Could not expire credited events", "user_id", userID, "error", err)
+	}
+}
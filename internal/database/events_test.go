@@ -0,0 +1,57 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUserEventExpiry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		key       string
+		createdAt time.Time
+		kept      bool // Still in the log now
+	}{
+		{"unkeyed within retention", "", now.Add(-time.Hour), true},
+		{"unkeyed past retention", "", now.Add(-UserEventRetention - time.Hour), false},
+		{"keyed within retention", "payment:succeeded:t1", now.Add(-time.Hour), true},
+		{"keyed past retention", "payment:succeeded:t1", now.Add(-UserEventRetention - time.Hour), true},
+		{"keyed a year old", "payment:succeeded:t1", now.AddDate(-1, 0, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt := userEventExpiry(tt.key, tt.createdAt)
+			if tt.key != "" && expiresAt != nil {
+				t.Fatalf("keyed event expires at %v, want it kept until credited", *expiresAt)
+			}
+			if kept := expiresAt == nil || expiresAt.After(now); kept != tt.kept {
+				t.Errorf("kept = %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
+
+func TestCreditedEventsExpiry(t *testing.T) {
+	now := time.Now()
+	filter, update := creditedEventsExpiry("u1", 42, now)
+
+	if filter["user_id"] != "u1" {
+		t.Errorf("filter user_id = %v, want u1", filter["user_id"])
+	}
+	if got := filter["event_id"].(bson.M)["$lte"]; got != int64(42) {
+		t.Errorf("filter event_id = %v, want events up to and including the marker", filter["event_id"])
+	}
+	if got := filter["expires_at"].(bson.M)["$exists"]; got != false {
+		t.Errorf("filter expires_at = %v, want only events without an expiry", filter["expires_at"])
+	}
+
+	expiresAt := update["$set"].(bson.M)["expires_at"].(time.Time)
+	if !expiresAt.Equal(now.Add(UserEventRetention)) {
+		t.Errorf("expires_at = %v, want the retention counted from the credit", expiresAt)
+	}
+}
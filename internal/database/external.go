@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExternalTransactionRequest describes a movement imported from a bank statement
+type ExternalTransactionRequest struct {
+	UserID        string
+	Credit        bool
+	Amount        float64
+	Currency      string
+	Status        string
+	Description   string
+	Counterparty  string
+	BankReference string
+	BookedAt      time.Time
+}
+
+// Outcomes of importing one bank statement entry
+const (
+	ExternalCreated   = "created"
+	ExternalBooked    = "booked"    // A pending entry imported earlier is now booked
+	ExternalDuplicate = "duplicate" // The bank reference was already imported as is
+)
+
+// CreateExternalTransaction stores an imported bank movement and reports
+// what happened to it. A bank reference seen before is not stored twice,
+// but a pending entry that a later statement shows booked is completed.
+func (db *MongoDB) CreateExternalTransaction(ctx context.Context, req *ExternalTransactionRequest) (transaction *Transaction, outcome string, err error) {
+	ctx, span := startSpan(ctx, "CreateExternalTransaction")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id":        req.UserID,
+		"bank_reference": req.BankReference,
+	}
+
+	if req.Status == "completed" {
+		now := time.Now()
+		update := bson.M{
+			"$set": bson.M{
+				"status":       "completed",
+				"amount":       req.Amount,
+				"description":  req.Description,
+				"counterparty": req.Counterparty,
+				"created_at":   req.BookedAt,
+				"updated_at":   now,
+			},
+			"$push": bson.M{
+				"status_history": StatusChange{Status: "completed", ChangedAt: now, Reason: "booked on bank statement"},
+			},
+		}
+		pending := bson.M{
+			"user_id":        req.UserID,
+			"bank_reference": req.BankReference,
+			"status":         "pending",
+		}
+
+		var booked Transaction
+		err := db.transactionCollection.FindOneAndUpdate(ctx, pending, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&booked)
+		if err == nil {
+			return &booked, ExternalBooked, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, "", fmt.Errorf("failed to book pending external transaction: %w", err)
+		}
+	}
+
+	count, err := db.transactionCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to check bank reference: %w", err)
+	}
+	if count > 0 {
+		return nil, ExternalDuplicate, nil
+	}
+
+	transactionType := "external_out"
+	if req.Credit {
+		transactionType = "external_in"
+	}
+
+	transaction = &Transaction{
+		TransactionID: uuid.New().String(),
+		UserID:        req.UserID,
+		Type:          transactionType,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Status:        req.Status,
+		Description:   req.Description,
+		Counterparty:  req.Counterparty,
+		BankReference: req.BankReference,
+		CreatedAt:     req.BookedAt,
+		UpdatedAt:     time.Now(),
+	}
+
+	_, err = db.transactionCollection.InsertOne(ctx, transaction)
+	if err != nil {
+		// A concurrent import of the same file lost the race on the unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ExternalDuplicate, nil
+		}
+		return nil, "", fmt.Errorf("failed to create external transaction: %w", err)
+	}
+
+	return transaction, ExternalCreated, nil
+}
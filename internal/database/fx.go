@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/errcode"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FX quote statuses
+const (
+	QuoteStatusOpen     = "open"
+	QuoteStatusExecuted = "executed"
+)
+
+var (
+	ErrQuoteNotFound = errcode.New(errcode.QuoteNotFound, "exchange quote not found")
+	ErrQuoteExpired  = errcode.New(errcode.QuoteExpired, "exchange quote expired")
+	ErrQuoteUsed     = errcode.New(errcode.QuoteUsed, "exchange quote already executed")
+)
+
+// FXQuote is a stored conversion offer; executing it books both legs
+type FXQuote struct {
+	QuoteID      string    `json:"quote_id" bson:"quote_id"`
+	UserID       string    `json:"user_id" bson:"user_id"`
+	FromCurrency string    `json:"from_currency" bson:"from_currency"`
+	ToCurrency   string    `json:"to_currency" bson:"to_currency"`
+	SellAmount   int64     `json:"sell_amount" bson:"sell_amount"` // Amount in minor units of FromCurrency
+	BuyAmount    int64     `json:"buy_amount" bson:"buy_amount"`   // Amount in minor units of ToCurrency
+	MidRate      float64   `json:"mid_rate" bson:"mid_rate"`
+	Rate         float64   `json:"rate" bson:"rate"`
+	SpreadBps    int64     `json:"spread_bps" bson:"spread_bps"`
+	RateSource   string    `json:"rate_source" bson:"rate_source"`
+	RateDate     time.Time `json:"rate_date" bson:"rate_date"`
+	Status       string    `json:"status" bson:"status"` // "open", "executed"
+	ExpiresAt    time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
+
+// FXConversion records an executed quote together with the rates used, for audit
+type FXConversion struct {
+	ConversionID        string    `json:"conversion_id" bson:"conversion_id"`
+	QuoteID             string    `json:"quote_id" bson:"quote_id"`
+	UserID              string    `json:"user_id" bson:"user_id"`
+	FromCurrency        string    `json:"from_currency" bson:"from_currency"`
+	ToCurrency          string    `json:"to_currency" bson:"to_currency"`
+	SellAmount          int64     `json:"sell_amount" bson:"sell_amount"`
+	BuyAmount           int64     `json:"buy_amount" bson:"buy_amount"`
+	MidRate             float64   `json:"mid_rate" bson:"mid_rate"`
+	Rate                float64   `json:"rate" bson:"rate"`
+	SpreadBps           int64     `json:"spread_bps" bson:"spread_bps"`
+	RateSource          string    `json:"rate_source" bson:"rate_source"`
+	RateDate            time.Time `json:"rate_date" bson:"rate_date"`
+	DebitTransactionID  string    `json:"debit_transaction_id" bson:"debit_transaction_id"`
+	CreditTransactionID string    `json:"credit_transaction_id" bson:"credit_transaction_id"`
+	ExecutedAt          time.Time `json:"executed_at" bson:"executed_at"`
+}
+
+// CreateFXQuote stores a new open quote for the user
+func (db *MongoDB) CreateFXQuote(ctx context.Context, quote *FXQuote) (*FXQuote, error) {
+	ctx, span := startSpan(ctx, "CreateFXQuote")
+	defer span.End()
+
+	quote.QuoteID = uuid.New().String()
+	quote.Status = QuoteStatusOpen
+	quote.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := db.fxQuoteCollection.InsertOne(ctx, quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exchange quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// ExecuteFXQuote books an open quote: it checks available funds in the
+// sold currency, writes an audit record and the debit and credit legs,
+// and marks the quote executed
+func (db *MongoDB) ExecuteFXQuote(ctx context.Context, userID, quoteID string) (*FXConversion, error) {
+	ctx, span := startSpan(ctx, "ExecuteFXQuote")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	unlock, err := db.lockFunds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var quote FXQuote
+	err = db.fxQuoteCollection.FindOne(ctx, bson.M{"quote_id": quoteID, "user_id": userID}).Decode(&quote)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get exchange quote: %w", err)
+	}
+
+	now := time.Now()
+	if quote.Status != QuoteStatusOpen {
+		return nil, ErrQuoteUsed
+	}
+	if !now.Before(quote.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+
+	summary, err := db.GetBalanceSummary(ctx, userID, quote.FromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Available < quote.SellAmount {
+		return nil, ErrInsufficientFunds
+	}
+
+	conversionID := uuid.New().String()
+	description := fmt.Sprintf("Wymiana %s → %s po kursie %.4f",
+		currency.FormatMinor(quote.SellAmount, quote.FromCurrency),
+		currency.FormatMinor(quote.BuyAmount, quote.ToCurrency),
+		quote.Rate)
+
+	debit := &Transaction{
+		TransactionID: uuid.New().String(),
+		UserID:        userID,
+		Type:          "exchange_out",
+		Amount:        currency.FromMinor(quote.SellAmount, quote.FromCurrency),
+		Currency:      quote.FromCurrency,
+		Status:        "completed",
+		Description:   description,
+		ExchangeID:    conversionID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	credit := &Transaction{
+		TransactionID: uuid.New().String(),
+		UserID:        userID,
+		Type:          "exchange_in",
+		Amount:        currency.FromMinor(quote.BuyAmount, quote.ToCurrency),
+		Currency:      quote.ToCurrency,
+		Status:        "completed",
+		Description:   description,
+		ExchangeID:    conversionID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	conversion := &FXConversion{
+		ConversionID:        conversionID,
+		QuoteID:             quote.QuoteID,
+		UserID:              userID,
+		FromCurrency:        quote.FromCurrency,
+		ToCurrency:          quote.ToCurrency,
+		SellAmount:          quote.SellAmount,
+		BuyAmount:           quote.BuyAmount,
+		MidRate:             quote.MidRate,
+		Rate:                quote.Rate,
+		SpreadBps:           quote.SpreadBps,
+		RateSource:          quote.RateSource,
+		RateDate:            quote.RateDate,
+		DebitTransactionID:  debit.TransactionID,
+		CreditTransactionID: credit.TransactionID,
+		ExecutedAt:          now,
+	}
+
+	// The audit record goes first: its unique quote_id claims the quote,
+	// so a second execution stops here even if this one dies halfway.
+	// Marking the quote executed comes last, and a failure before it undoes
+	// what was written.
+	_, err = db.fxConversionCollection.InsertOne(ctx, conversion)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrQuoteUsed
+		}
+		return nil, fmt.Errorf("failed to record conversion: %w", err)
+	}
+
+	_, err = db.transactionCollection.InsertMany(ctx, []interface{}{debit, credit})
+	if err != nil {
+		return nil, rollBackConversion(ctx, db.transactionCollection, db.fxConversionCollection, conversionID, fmt.Errorf("failed to create exchange transactions: %w", err))
+	}
+
+	result, err := db.fxQuoteCollection.UpdateOne(ctx,
+		bson.M{"quote_id": quoteID, "status": QuoteStatusOpen},
+		bson.M{"$set": bson.M{"status": QuoteStatusExecuted}})
+	if err != nil {
+		return nil, rollBackConversion(ctx, db.transactionCollection, db.fxConversionCollection, conversionID, fmt.Errorf("failed to update exchange quote: %w", err))
+	}
+	if result.ModifiedCount == 0 {
+		return nil, rollBackConversion(ctx, db.transactionCollection, db.fxConversionCollection, conversionID, ErrQuoteUsed)
+	}
+
+	return conversion, nil
+}
+
+// deleter is the part of a collection a rollback needs
+type deleter interface {
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+}
+
+// rollBackConversion deletes the legs and audit record of a conversion
+// that could not be completed and returns cause, joined with any error
+// from the clean-up. It runs even when ctx has ended, as a timeout is a
+// likely cause, and leaving the legs would book a conversion whose quote
+// is still open.
+func rollBackConversion(ctx context.Context, transactions, conversions deleter, conversionID string, cause error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if _, err := transactions.DeleteMany(ctx, bson.M{"exchange_id": conversionID}); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to roll back exchange transactions: %w", err))
+	}
+	if _, err := conversions.DeleteOne(ctx, bson.M{"conversion_id": conversionID}); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to roll back conversion: %w", err))
+	}
+	return cause
+}
+
+// GetUserConversions returns the user's executed conversions, newest first
+func (db *MongoDB) GetUserConversions(ctx context.Context, userID string, limit int) ([]*FXConversion, error) {
+	ctx, span := startSpan(ctx, "GetUserConversions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "executed_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := db.fxConversionCollection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var conversions []*FXConversion
+	if err := cursor.All(ctx, &conversions); err != nil {
+		return nil, fmt.Errorf("failed to decode conversions: %w", err)
+	}
+
+	return conversions, nil
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeCollection holds documents in memory and deletes those whose field
+// matches the filter's single key
+type fakeCollection struct {
+	docs []bson.M
+	err  error // Returned by every delete when set
+}
+
+func (c *fakeCollection) delete(ctx context.Context, filter interface{}, many bool) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	var key string
+	var value interface{}
+	for k, v := range filter.(bson.M) {
+		key, value = k, v
+	}
+	kept := c.docs[:0]
+	deleted := int64(0)
+	for _, doc := range c.docs {
+		if doc[key] == value && (many || deleted == 0) {
+			deleted++
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	c.docs = kept
+	return &mongo.DeleteResult{DeletedCount: deleted}, nil
+}
+
+func (c *fakeCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return c.delete(ctx, filter, false)
+}
+
+func (c *fakeCollection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return c.delete(ctx, filter, true)
+}
+
+func TestRollBackConversion(t *testing.T) {
+	// The quote update ran out of time, leaving ctx done
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+	updateFailed := fmt.Errorf("failed to update exchange quote: %w", context.DeadlineExceeded)
+	deleteFailed := errors.New("connection reset")
+
+	tests := []struct {
+		name             string
+		transactionsErr  error
+		conversionsErr   error
+		wantLegs         int
+		wantConversions  int
+		wantCleanupError bool
+	}{
+		{"quote update timed out", nil, nil, 0, 0, false},
+		{"legs not deleted", deleteFailed, nil, 2, 1, true},
+		{"conversion not deleted", nil, deleteFailed, 0, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := &fakeCollection{err: tt.transactionsErr, docs: []bson.M{
+				{"transaction_id": "t-debit", "exchange_id": "c1"},
+				{"transaction_id": "t-credit", "exchange_id": "c1"},
+				{"transaction_id": "t-other", "exchange_id": "c0"},
+			}}
+			conversions := &fakeCollection{err: tt.conversionsErr, docs: []bson.M{
+				{"conversion_id": "c1"},
+				{"conversion_id": "c0"},
+			}}
+
+			err := rollBackConversion(expired, transactions, conversions, "c1", updateFailed)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("rollBackConversion() error = %v, want the cause", err)
+			}
+			if cleanupFailed := errors.Is(err, deleteFailed); cleanupFailed != tt.wantCleanupError {
+				t.Errorf("rollBackConversion() error = %v, want clean-up error reported: %v", err, tt.wantCleanupError)
+			}
+
+			legs := 0
+			for _, doc := range transactions.docs {
+				if doc["exchange_id"] == "c1" {
+					legs++
+				}
+			}
+			if legs != tt.wantLegs {
+				t.Errorf("%d legs remain, want %d", legs, tt.wantLegs)
+			}
+			if len(transactions.docs) != tt.wantLegs+1 {
+				t.Errorf("%d transactions remain, want the other conversion's kept", len(transactions.docs))
+			}
+			if len(conversions.docs) != tt.wantConversions+1 {
+				t.Errorf("%d conversions remain, want %d", len(conversions.docs), tt.wantConversions+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/errcode"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Hold statuses
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+// DefaultHoldTTL is used when a hold is created without an explicit expiry
+const DefaultHoldTTL = 24 * time.Hour
+
+var (
+	ErrInsufficientFunds = errcode.New(errcode.InsufficientFunds, "insufficient available funds")
+	ErrHoldNotFound      = errcode.New(errcode.HoldNotFound, "hold not found")
+	ErrHoldNotActive     = errcode.New(errcode.HoldNotActive, "hold is not active")
+)
+
+// Hold reserves part of a user's balance for a pending outflow
+type Hold struct {
+	HoldID        string    `json:"hold_id" bson:"hold_id"`
+	UserID        string    `json:"user_id" bson:"user_id"`
+	Type          string    `json:"type" bson:"type"`     // transaction type created on capture: "withdrawal", "payment"
+	Amount        int64     `json:"amount" bson:"amount"` // Amount in minor units
+	Currency      string    `json:"currency" bson:"currency"`
+	Status        string    `json:"status" bson:"status"` // "active", "captured", "released", "expired"
+	Description   string    `json:"description" bson:"description"`
+	TransactionID string    `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"` // Set once captured
+	ExpiresAt     time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+type HoldRequest struct {
+	UserID      string        `json:"user_id"`
+	Type        string        `json:"type"`
+	Amount      int64         `json:"amount"`
+	Currency    string        `json:"currency"`
+	Description string        `json:"description"`
+	TTL         time.Duration `json:"ttl"`
+}
+
+// BalanceSummary is the server-side ledger view of a user's funds in one currency
+type BalanceSummary struct {
+	Currency  string `json:"currency"`
+	Total     int64  `json:"total"`     // Completed credits minus completed debits, in minor units
+	Held      int64  `json:"held"`      // Sum of active holds, in minor units
+	Available int64  `json:"available"` // Total minus held, in minor units
+}
+
+// creditTypes lists transaction types that add to the ledger balance;
+// every other type is treated as a debit
+var creditTypes = map[string]bool{
+	"deposit":     true,
+	"exchange_in": true,
+	"external_in": true,
+}
+
+// externalTypes are movements on outside bank accounts, imported for
+// reconciliation; they never change the wallet balance
+var externalTypes = []string{"external_in", "external_out"}
+
+// IsCredit reports whether a transaction type adds to the balance
+func IsCredit(transactionType string) bool {
+	return creditTypes[transactionType]
+}
+
+// CreateHold reserves funds for the user if enough are available
+func (db *MongoDB) CreateHold(ctx context.Context, req *HoldRequest) (*Hold, error) {
+	ctx, span := startSpan(ctx, "CreateHold")
+	defer span.End()
+
+	if req.Amount <= 0 {
+		return nil, errcode.New(errcode.InvalidInput, "hold amount must be positive")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	unlock, err := db.lockFunds(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	summary, err := db.GetBalanceSummary(ctx, req.UserID, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Available < req.Amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+	now := time.Now()
+
+	hold := &Hold{
+		HoldID:      uuid.New().String(),
+		UserID:      req.UserID,
+		Type:        req.Type,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Status:      HoldStatusActive,
+		Description: req.Description,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	_, err = db.holdCollection.InsertOne(ctx, hold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	return hold, nil
+}
+
+// GetHold returns a hold owned by the given user
+func (db *MongoDB) GetHold(ctx context.Context, userID, holdID string) (*Hold, error) {
+	ctx, span := startSpan(ctx, "GetHold")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var hold Hold
+	err := db.holdCollection.FindOne(ctx, bson.M{"hold_id": holdID, "user_id": userID}).Decode(&hold)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+
+	return &hold, nil
+}
+
+// GetUserHolds returns the user's holds with the given status, or all holds when status is empty
+func (db *MongoDB) GetUserHolds(ctx context.Context, userID, status string) ([]*Hold, error) {
+	ctx, span := startSpan(ctx, "GetUserHolds")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+	if status == HoldStatusActive {
+		// Holds past their expiry no longer count, even before ExpireHolds
+		// has marked them
+		filter["expires_at"] = bson.M{"$gt": time.Now()}
+	}
+
+	cursor, err := db.holdCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holds: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var holds []*Hold
+	if err := cursor.All(ctx, &holds); err != nil {
+		return nil, fmt.Errorf("failed to decode holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+// CaptureHold turns an active hold into a completed debit transaction.
+// Only the captured amount is debited; any remainder goes back to the
+// available balance.
+func (db *MongoDB) CaptureHold(ctx context.Context, userID, holdID string, amount int64) (*Transaction, error) {
+	ctx, span := startSpan(ctx, "CaptureHold")
+	defer span.End()
+
+	hold, err := db.GetHold(ctx, userID, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return nil, errcode.New(errcode.InvalidInput, "capture amount exceeds held amount")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	transaction := &Transaction{
+		TransactionID: uuid.New().String(),
+		UserID:        hold.UserID,
+		Type:          hold.Type,
+		Amount:        currency.FromMinor(amount, hold.Currency),
+		Currency:      hold.Currency,
+		Status:        "completed",
+		Description:   hold.Description,
+		HoldID:        hold.HoldID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	// Book the debit before closing the hold. Until the hold is closed
+	// both count against the balance, which errs on the safe side, and the
+	// unique hold_id index lets only one capture through.
+	_, err = db.transactionCollection.InsertOne(ctx, transaction)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrHoldNotActive
+		}
+		return nil, fmt.Errorf("failed to create transaction for hold: %w", err)
+	}
+
+	if err := db.transitionHold(ctx, userID, holdID, HoldStatusCaptured, transaction.TransactionID); err != nil {
+		// The hold was released or expired meanwhile, or ctx ran out; take
+		// the debit back even then, so the funds are not counted twice
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if _, deleteErr := db.transactionCollection.DeleteOne(rollbackCtx, bson.M{"transaction_id": transaction.TransactionID}); deleteErr != nil {
+			return nil, fmt.Errorf("failed to roll back transaction %s for hold: %w", transaction.TransactionID, deleteErr)
+		}
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// ReleaseHold cancels an active hold and returns its funds to the available balance
+func (db *MongoDB) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	ctx, span := startSpan(ctx, "ReleaseHold")
+	defer span.End()
+
+	return db.transitionHold(ctx, userID, holdID, HoldStatusReleased, "")
+}
+
+// ExpireHolds marks every active hold past its expiry as expired and
+// returns how many were changed
+func (db *MongoDB) ExpireHolds(ctx context.Context, now time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "ExpireHolds")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":     HoldStatusActive,
+		"expires_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     HoldStatusExpired,
+			"updated_at": now,
+		},
+	}
+
+	result, err := db.holdCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// GetBalanceSummary computes total, held and available funds for a user
+// in one currency from completed transactions and active holds
+func (db *MongoDB) GetBalanceSummary(ctx context.Context, userID, code string) (*BalanceSummary, error) {
+	ctx, span := startSpan(ctx, "GetBalanceSummary")
+	defer span.End()
+
+	summaries, err := db.balanceSummaries(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary, ok := summaries[code]; ok {
+		return summary, nil
+	}
+	return &BalanceSummary{Currency: code}, nil
+}
+
+// GetBalanceSummaries computes a balance summary for every currency the
+// user has completed transactions or active holds in, sorted by currency
+func (db *MongoDB) GetBalanceSummaries(ctx context.Context, userID string) ([]*BalanceSummary, error) {
+	ctx, span := startSpan(ctx, "GetBalanceSummaries")
+	defer span.End()
+
+	summaries, err := db.balanceSummaries(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*BalanceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		list = append(list, summary)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+
+	return list, nil
+}
+
+// balanceSummaries groups ledger totals by currency, optionally limited to
+// one currency. It only reads: expired holds are left out here and marked
+// by the expire-holds job.
+func (db *MongoDB) balanceSummaries(ctx context.Context, userID, code string) (map[string]*BalanceSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	summaries := make(map[string]*BalanceSummary)
+	summaryFor := func(code string) *BalanceSummary {
+		summary, ok := summaries[code]
+		if !ok {
+			summary = &BalanceSummary{Currency: code}
+			summaries[code] = summary
+		}
+		return summary
+	}
+
+	transactionFilter := bson.M{
+		"user_id": userID,
+		"status":  "completed",
+		"type":    bson.M{"$nin": externalTypes},
+	}
+	holdFilter := bson.M{
+		"user_id":    userID,
+		"status":     HoldStatusActive,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	if code != "" {
+		transactionFilter["currency"] = code
+		holdFilter["currency"] = code
+	}
+
+	cursor, err := db.transactionCollection.Find(ctx, transactionFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var transaction Transaction
+		if err := cursor.Decode(&transaction); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		summary := summaryFor(transaction.Currency)
+		amount := currency.ToMinor(transaction.Amount, transaction.Currency)
+		if IsCredit(transaction.Type) {
+			summary.Total += amount
+		} else {
+			summary.Total -= amount
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	holdCursor, err := db.holdCollection.Find(ctx, holdFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holds: %w", err)
+	}
+	defer holdCursor.Close(ctx)
+
+	for holdCursor.Next(ctx) {
+		var hold Hold
+		if err := holdCursor.Decode(&hold); err != nil {
+			return nil, fmt.Errorf("failed to decode hold: %w", err)
+		}
+		summaryFor(hold.Currency).Held += hold.Amount
+	}
+	if err := holdCursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	for _, summary := range summaries {
+		summary.Available = summary.Total - summary.Held
+	}
+
+	return summaries, nil
+}
+
+// transitionHold moves an active, unexpired hold to a final status
+func (db *MongoDB) transitionHold(ctx context.Context, userID, holdID, status, transactionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{
+		"status":     status,
+		"updated_at": now,
+	}
+	if transactionID != "" {
+		set["transaction_id"] = transactionID
+	}
+
+	filter := bson.M{
+		"hold_id":    holdID,
+		"user_id":    userID,
+		"status":     HoldStatusActive,
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	result, err := db.holdCollection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := db.GetHold(ctx, userID, holdID); err != nil {
+			return err
+		}
+		return ErrHoldNotActive
+	}
+
+	return nil
+}
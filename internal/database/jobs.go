@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobState is the persisted outcome of a background job's runs, so the
+// scheduler can catch up after a restart and report history
+type JobState struct {
+	Name           string    `json:"name" bson:"name"`
+	LastStartedAt  time.Time `json:"last_started_at" bson:"last_started_at"`
+	LastFinishedAt time.Time `json:"last_finished_at" bson:"last_finished_at"`
+	LastDurationMs int64     `json:"last_duration_ms" bson:"last_duration_ms"`
+	LastError      string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	Runs           int64     `json:"runs" bson:"runs"`
+	Failures       int64     `json:"failures" bson:"failures"`
+	Skipped        int64     `json:"skipped" bson:"skipped"` // Activations dropped while a run was in progress
+	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// GetJobStates returns the persisted state of every job that has run
+func (db *MongoDB) GetJobStates(ctx context.Context) ([]*JobState, error) {
+	ctx, span := startSpan(ctx, "GetJobStates")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.jobCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job states: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var states []*JobState
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, fmt.Errorf("failed to decode job states: %w", err)
+	}
+
+	return states, nil
+}
+
+// SaveJobState stores a job's state, replacing the previous one
+func (db *MongoDB) SaveJobState(ctx context.Context, state *JobState) error {
+	ctx, span := startSpan(ctx, "SaveJobState")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	state.UpdatedAt = time.Now()
+	_, err := db.jobCollection.ReplaceOne(ctx, bson.M{"name": state.Name}, state, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save job state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lockLease bounds how long a process that dies holding a lock keeps
+// others out
+const lockLease = 30 * time.Second
+
+// lockRetry is how long acquireLock waits before trying a taken lock again
+const lockRetry = 50 * time.Millisecond
+
+// lockFunds serializes outflows checked against a user's available
+// balance. The lock lives in MongoDB, so it also holds against the command
+// line running alongside the app. It waits until the lock is free or ctx
+// ends, and returns a function that releases it.
+func (db *MongoDB) lockFunds(ctx context.Context, userID string) (func(), error) {
+	return db.acquireLock(ctx, "funds:"+userID)
+}
+
+// lockUserEvents serializes appends to a user's event log, so the user's
+// events are stored in the order of their IDs
+func (db *MongoDB) lockUserEvents(ctx context.Context, userID string) (func(), error) {
+	return db.acquireLock(ctx, "events:"+userID)
+}
+
+// acquireLock takes the named lease lock, waiting until it is free or ctx
+// ends, and returns a function that releases it
+func (db *MongoDB) acquireLock(ctx context.Context, id string) (func(), error) {
+	owner := uuid.New().String()
+
+	for {
+		// Take the lock if it is free or its holder's lease ran out; while
+		// it is held the filter misses and the upsert hits the unique _id
+		now := time.Now()
+		_, err := db.lockCollection.UpdateOne(ctx,
+			bson.M{"_id": id, "expires_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(lockLease)}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("failed to take lock %s: %w", id, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("lock %s is held by another operation: %w", id, ctx.Err())
+		case <-time.After(lockRetry):
+		}
+	}
+
+	return func() {
+		// Release even when the caller's ctx has ended
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if _, err := db.lockCollection.DeleteOne(ctx, bson.M{"_id": id, "owner": owner}); err != nil {
+			slog.Warn("Could not release lock; it expires with its lease", "lock", id, "error", err)
+		}
+	}, nil
+}
@@ -3,14 +3,17 @@ package database
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"pocket-wallet/internal/errcode"
 	"pocket-wallet/pkg/config"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // User types from main package
@@ -21,6 +24,7 @@ type User struct {
 	Salt             string    `json:"salt" bson:"salt"`
 	PasswordHash     string    `json:"password_hash" bson:"password_hash"`
 	EncryptedBalance string    `json:"encrypted_balance" bson:"encrypted_balance"`
+	CreditedEventID  *int64    `json:"credited_event_id,omitempty" bson:"credited_event_id,omitempty"` // Last payment:succeeded event in the balance; nil for older accounts
 	CreatedAt        time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" bson:"updated_at"`
 }
@@ -39,59 +43,145 @@ type UserMetaResponse struct {
 
 // Transaction types for database
 type Transaction struct {
-	TransactionID string    `json:"transaction_id" bson:"transaction_id"`
-	UserID        string    `json:"user_id" bson:"user_id"`
-	Type          string    `json:"type" bson:"type"` // "deposit", "withdrawal", "payment"
-	Amount        float64   `json:"amount" bson:"amount"`
-	Currency      string    `json:"currency" bson:"currency"`
-	Status        string    `json:"status" bson:"status"` // "pending", "completed", "failed"
-	Description   string    `json:"description" bson:"description"`
-	PaymentID     string    `json:"payment_id,omitempty" bson:"payment_id,omitempty"` // Stripe payment ID
-	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+	TransactionID string         `json:"transaction_id" bson:"transaction_id"`
+	UserID        string         `json:"user_id" bson:"user_id"`
+	Type          string         `json:"type" bson:"type"` // "deposit", "withdrawal", "payment", "exchange_in", "exchange_out", "external_in", "external_out"
+	Amount        float64        `json:"amount" bson:"amount"`
+	Currency      string         `json:"currency" bson:"currency"`
+	Status        string         `json:"status" bson:"status"` // "pending", "completed", "failed", "cancelled"
+	Description   string         `json:"description" bson:"description"`
+	Counterparty  string         `json:"counterparty,omitempty" bson:"counterparty,omitempty"`
+	Notes         string         `json:"notes,omitempty" bson:"notes,omitempty"`
+	PaymentID     string         `json:"payment_id,omitempty" bson:"payment_id,omitempty"`         // Stripe payment ID
+	ExchangeID    string         `json:"exchange_id,omitempty" bson:"exchange_id,omitempty"`       // Currency conversion both legs belong to
+	HoldID        string         `json:"hold_id,omitempty" bson:"hold_id,omitempty"`               // Hold this debit captured
+	BankReference string         `json:"bank_reference,omitempty" bson:"bank_reference,omitempty"` // Imported bank statement entry
+	StatusHistory []StatusChange `json:"status_history,omitempty" bson:"status_history,omitempty"`
+	TraceParent   string         `json:"-" bson:"trace_parent,omitempty"`   // Span that started the payment
+	EventsPending bool           `json:"-" bson:"events_pending,omitempty"` // Status changed since its events were last recorded
+	CreatedAt     time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+// StatusChange records one transition of a transaction's status
+type StatusChange struct {
+	Status    string    `json:"status" bson:"status"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
 }
 
 type TransactionRequest struct {
-	UserID      string  `json:"user_id"`
-	Type        string  `json:"type"`
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
-	Description string  `json:"description"`
-	PaymentID   string  `json:"payment_id,omitempty"`
+	UserID       string  `json:"user_id"`
+	Type         string  `json:"type"`
+	Amount       float64 `json:"amount"`
+	Currency     string  `json:"currency"`
+	Description  string  `json:"description"`
+	Counterparty string  `json:"counterparty,omitempty"`
+	Notes        string  `json:"notes,omitempty"`
+	PaymentID    string  `json:"payment_id,omitempty"`
+	TraceParent  string  `json:"-"`
 }
 
+// ErrTransactionNotFound is returned when no transaction matches a lookup
+var ErrTransactionNotFound = errcode.New(errcode.TransactionNotFound, "transaction not found")
+
+// ErrUserNotFound is returned when no user matches a lookup
+var ErrUserNotFound = errcode.New(errcode.UserNotFound, "user not found")
+
+var (
+	ErrLoginTaken      = errcode.New(errcode.LoginTaken, "login already taken")
+	ErrBalanceConflict = errcode.New(errcode.BalanceConflict, "balance was changed concurrently")
+)
+
 type MongoDB struct {
-	client                *mongo.Client
-	database              *mongo.Database
-	collection            *mongo.Collection
-	transactionCollection *mongo.Collection
+	client                   *mongo.Client
+	database                 *mongo.Database
+	collection               *mongo.Collection
+	transactionCollection    *mongo.Collection
+	holdCollection           *mongo.Collection
+	fxQuoteCollection        *mongo.Collection
+	fxConversionCollection   *mongo.Collection
+	reconciliationCollection *mongo.Collection
+	jobCollection            *mongo.Collection
+	webhookCollection        *mongo.Collection
+	sessionCollection        *mongo.Collection
+	userEventCollection      *mongo.Collection
+	counterCollection        *mongo.Collection
+	lockCollection           *mongo.Collection
 }
 
+// NewMongoDB connects to MongoDB and prepares its indexes, failing when
+// the server cannot be reached
 func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
-	// Debug: print MongoDB URI (without password for security)
-	fmt.Printf("Connecting to MongoDB with URI: %s\n", cfg.MongoDBURI)
-
-	// Set client options
-	clientOptions := options.Client().ApplyURI(cfg.MongoDBURI)
+	db, err := OpenMongoDB(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Connect to MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	if err := db.Setup(ctx); err != nil {
+		db.Close()
+		return nil, err
 	}
+	return db, nil
+}
 
-	// Check the connection
-	err = client.Ping(ctx, nil)
+// OpenMongoDB creates a client without waiting for the server. It only
+// fails on a bad configuration; the driver connects, and reconnects, in the
+// background. Call Setup before use.
+func OpenMongoDB(cfg *config.Config) (*MongoDB, error) {
+	// The logging handler masks credentials in the URI
+	slog.Info("Connecting to MongoDB", "uri", cfg.MongoDBURI)
+
+	// Set client options; the monitor feeds command latency to /metrics
+	clientOptions := options.Client().ApplyURI(cfg.MongoDBURI).SetMonitor(commandMonitor())
+
+	client, err := mongo.Connect(context.Background(), clientOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
 
 	database := client.Database("pocketwallet")
 	collection := database.Collection("users")
 	transactionCollection := database.Collection("transactions")
+	holdCollection := database.Collection("holds")
+	fxQuoteCollection := database.Collection("fx_quotes")
+	fxConversionCollection := database.Collection("fx_conversions")
+	reconciliationCollection := database.Collection("reconciliation_reports")
+	jobCollection := database.Collection("job_states")
+	webhookCollection := database.Collection("webhook_events")
+	sessionCollection := database.Collection("sessions")
+	userEventCollection := database.Collection("user_events")
+	counterCollection := database.Collection("counters")
+	lockCollection := database.Collection("locks")
+
+	return &MongoDB{
+		client:                   client,
+		database:                 database,
+		collection:               collection,
+		transactionCollection:    transactionCollection,
+		holdCollection:           holdCollection,
+		fxQuoteCollection:        fxQuoteCollection,
+		fxConversionCollection:   fxConversionCollection,
+		reconciliationCollection: reconciliationCollection,
+		jobCollection:            jobCollection,
+		webhookCollection:        webhookCollection,
+		sessionCollection:        sessionCollection,
+		userEventCollection:      userEventCollection,
+		counterCollection:        counterCollection,
+		lockCollection:           lockCollection,
+	}, nil
+}
+
+// Setup checks that the server is reachable and creates the indexes
+func (db *MongoDB) Setup(ctx context.Context) error {
+	// Check the connection
+	err := db.client.Ping(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
 
 	// Create unique index on login
 	indexModel := mongo.IndexModel{
@@ -99,10 +189,10 @@ func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
 		Options: options.Index().SetUnique(true),
 	}
 
-	_, err = collection.Indexes().CreateOne(ctx, indexModel)
+	_, err = db.collection.Indexes().CreateOne(ctx, indexModel)
 	if err != nil {
 		// Index might already exist, log but don't fail
-		fmt.Printf("Warning: Could not create index: %v\n", err)
+		slog.Warn("Could not create index", "index", "login", "error", err)
 	}
 
 	// Create index on user_id for transactions
@@ -110,18 +200,237 @@ func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
 		Keys: bson.D{{Key: "user_id", Value: 1}},
 	}
 
-	_, err = transactionCollection.Indexes().CreateOne(ctx, transactionIndexModel)
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, transactionIndexModel)
 	if err != nil {
 		// Index might already exist, log but don't fail
-		fmt.Printf("Warning: Could not create transaction index: %v\n", err)
+		slog.Warn("Could not create index", "index", "transaction", "error", err)
 	}
 
-	return &MongoDB{
-		client:                client,
-		database:              database,
-		collection:            collection,
-		transactionCollection: transactionCollection,
-	}, nil
+	// Create index matching the newest-first keyset pagination order
+	transactionPageIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "created_at", Value: -1},
+			{Key: "transaction_id", Value: -1},
+		},
+	}
+
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, transactionPageIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "transaction pagination", "error", err)
+	}
+
+	// Create text index for transaction search; "none" disables English
+	// stemming, which would mangle Polish descriptions
+	transactionTextIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "description", Value: "text"},
+			{Key: "counterparty", Value: "text"},
+			{Key: "notes", Value: "text"},
+			{Key: "payment_id", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("transaction_text").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{
+				{Key: "description", Value: 10},
+				{Key: "counterparty", Value: 5},
+				{Key: "notes", Value: 3},
+				{Key: "payment_id", Value: 1},
+			}),
+	}
+
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, transactionTextIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "transaction text", "error", err)
+	}
+
+	// Create unique index on bank references so statement re-imports dedupe
+	bankReferenceIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "bank_reference", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"bank_reference": bson.M{"$exists": true}}),
+	}
+
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, bankReferenceIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "bank reference", "error", err)
+	}
+
+	// Create index on payment IDs for webhook lookups and reconciliation
+	paymentIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "payment_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"payment_id": bson.M{"$exists": true}}),
+	}
+
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, paymentIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "payment", "error", err)
+	}
+
+	// Create unique index on captured holds so a hold is debited at most once
+	holdCaptureIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "hold_id", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"hold_id": bson.M{"$exists": true}}),
+	}
+
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, holdCaptureIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "hold capture", "error", err)
+	}
+
+	// Create index for finding stale pending transactions
+	transactionStatusIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "type", Value: 1}, {Key: "created_at", Value: 1}},
+	}
+
+	_, err = db.transactionCollection.Indexes().CreateOne(ctx, transactionStatusIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "transaction status", "error", err)
+	}
+
+	// Create index on user_id and status for holds
+	holdIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}},
+	}
+
+	_, err = db.holdCollection.Indexes().CreateOne(ctx, holdIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "hold", "error", err)
+	}
+
+	// Create index on quote_id for FX quotes
+	fxQuoteIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "quote_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = db.fxQuoteCollection.Indexes().CreateOne(ctx, fxQuoteIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "FX quote", "error", err)
+	}
+
+	// Create unique index on executed quotes so a quote is booked at most once
+	fxConversionIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "quote_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = db.fxConversionCollection.Indexes().CreateOne(ctx, fxConversionIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "FX conversion", "error", err)
+	}
+
+	// Create unique index on job names
+	jobIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = db.jobCollection.Indexes().CreateOne(ctx, jobIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "job state", "error", err)
+	}
+
+	// Create unique index on webhook event IDs so redeliveries are recognized
+	webhookIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "event_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = db.webhookCollection.Indexes().CreateOne(ctx, webhookIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "webhook event", "error", err)
+	}
+
+	webhookStatusIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "received_at", Value: -1}},
+	}
+
+	_, err = db.webhookCollection.Indexes().CreateOne(ctx, webhookStatusIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "webhook status", "error", err)
+	}
+
+	// Create unique index on session token hashes
+	sessionIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = db.sessionCollection.Indexes().CreateOne(ctx, sessionIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "session", "error", err)
+	}
+
+	// Let MongoDB delete sessions once they expire
+	sessionTTLIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	_, err = db.sessionCollection.Indexes().CreateOne(ctx, sessionTTLIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "session expiry", "error", err)
+	}
+
+	// Create unique index on event IDs, scoped to the user for resuming streams
+	userEventIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "event_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = db.userEventCollection.Indexes().CreateOne(ctx, userEventIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "user event", "error", err)
+	}
+
+	// Create unique index on event keys, so an event is logged once
+	userEventKeyIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"key": bson.M{"$exists": true}}),
+	}
+
+	_, err = db.userEventCollection.Indexes().CreateOne(ctx, userEventKeyIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "user event key", "error", err)
+	}
+
+	// Let MongoDB delete events past the resume window
+	userEventTTLIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	_, err = db.userEventCollection.Indexes().CreateOne(ctx, userEventTTLIndexModel)
+	if err != nil {
+		// Index might already exist, log but don't fail
+		slog.Warn("Could not create index", "index", "user event expiry", "error", err)
+	}
+
+	return nil
 }
 
 func (db *MongoDB) Close() error {
@@ -130,7 +439,21 @@ func (db *MongoDB) Close() error {
 	return db.client.Disconnect(ctx)
 }
 
-func (db *MongoDB) CreateUser(req *RegisterRequest) (*User, error) {
+// Ping checks that the primary is reachable within ctx's deadline
+func (db *MongoDB) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "Ping")
+	defer span.End()
+
+	if err := db.client.Ping(ctx, readpref.Primary()); err != nil {
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+	return nil
+}
+
+func (db *MongoDB) CreateUser(ctx context.Context, req *RegisterRequest) (*User, error) {
+	ctx, span := startSpan(ctx, "CreateUser")
+	defer span.End()
+
 	userID := uuid.New().String()
 	now := time.Now()
 
@@ -141,30 +464,38 @@ func (db *MongoDB) CreateUser(req *RegisterRequest) (*User, error) {
 		Salt:             req.Salt,
 		PasswordHash:     req.PasswordHash,
 		EncryptedBalance: "", // Initially empty
+		CreditedEventID:  new(int64),
 		CreatedAt:        now,
 		UpdatedAt:        now,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := db.collection.InsertOne(ctx, user)
 	if err != nil {
+		// Logins are unique by index, which also settles concurrent sign-ups
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrLoginTaken
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return user, nil
 }
 
-func (db *MongoDB) GetUserByLogin(login string) (*User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (db *MongoDB) GetUserByLogin(ctx context.Context, login string) (*User, error) {
+	ctx, span := startSpan(ctx, "GetUserByLogin")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var user User
 	err := db.collection.FindOne(ctx, bson.M{"login": login}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -172,15 +503,18 @@ func (db *MongoDB) GetUserByLogin(login string) (*User, error) {
 	return &user, nil
 }
 
-func (db *MongoDB) GetUserByID(userID string) (*User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (db *MongoDB) GetUserByID(ctx context.Context, userID string) (*User, error) {
+	ctx, span := startSpan(ctx, "GetUserByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var user User
 	err := db.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -188,8 +522,11 @@ func (db *MongoDB) GetUserByID(userID string) (*User, error) {
 	return &user, nil
 }
 
-func (db *MongoDB) UpdateUserBalance(userID, encryptedBalance string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (db *MongoDB) UpdateUserBalance(ctx context.Context, userID, encryptedBalance string) error {
+	ctx, span := startSpan(ctx, "UpdateUserBalance")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	update := bson.M{
@@ -205,14 +542,85 @@ func (db *MongoDB) UpdateUserBalance(userID, encryptedBalance string) error {
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
 	}
 
 	return nil
 }
 
-func (db *MongoDB) GetUserMeta(login string) (*UserMetaResponse, error) {
-	user, err := db.GetUserByLogin(login)
+// UpdateUserBalanceIf replaces the encrypted balance only if it still
+// equals expected, so two clients cannot silently overwrite each other.
+// A positive creditedEventID records that the new balance includes that
+// event's deposit; the update then also fails if it was already credited.
+func (db *MongoDB) UpdateUserBalanceIf(ctx context.Context, userID, expected, encryptedBalance string, creditedEventID int64) error {
+	ctx, span := startSpan(ctx, "UpdateUserBalanceIf")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"encrypted_balance": encryptedBalance,
+		"updated_at":        time.Now(),
+	}
+	filter := bson.M{"user_id": userID, "encrypted_balance": expected}
+	if creditedEventID > 0 {
+		set["credited_event_id"] = creditedEventID
+		filter["credited_event_id"] = bson.M{"$lt": creditedEventID}
+	}
+
+	update := bson.M{"$set": set}
+	result, err := db.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update user balance: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := db.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+		if err != nil {
+			return fmt.Errorf("failed to update user balance: %w", err)
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+		return ErrBalanceConflict
+	}
+
+	if creditedEventID > 0 {
+		db.expireCreditedEvents(ctx, userID, creditedEventID)
+	}
+
+	return nil
+}
+
+// InitUserCreditMarker starts tracking credited deposits for an account
+// that predates it. Events up to eventID count as credited; a marker set in
+// the meantime is kept.
+func (db *MongoDB) InitUserCreditMarker(ctx context.Context, userID string, eventID int64) error {
+	ctx, span := startSpan(ctx, "InitUserCreditMarker")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "credited_event_id": bson.M{"$exists": false}}
+	result, err := db.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"credited_event_id": eventID}})
+	if err != nil {
+		return fmt.Errorf("failed to initialize credit marker: %w", err)
+	}
+
+	if result.ModifiedCount == 1 && eventID > 0 {
+		db.expireCreditedEvents(ctx, userID, eventID)
+	}
+
+	return nil
+}
+
+func (db *MongoDB) GetUserMeta(ctx context.Context, login string) (*UserMetaResponse, error) {
+	ctx, span := startSpan(ctx, "GetUserMeta")
+	defer span.End()
+
+	user, err := db.GetUserByLogin(ctx, login)
 	if err != nil {
 		return nil, err
 	}
@@ -224,7 +632,10 @@ func (db *MongoDB) GetUserMeta(login string) (*UserMetaResponse, error) {
 }
 
 // Transaction methods
-func (db *MongoDB) CreateTransaction(req *TransactionRequest) (*Transaction, error) {
+func (db *MongoDB) CreateTransaction(ctx context.Context, req *TransactionRequest) (*Transaction, error) {
+	ctx, span := startSpan(ctx, "CreateTransaction")
+	defer span.End()
+
 	transactionID := uuid.New().String()
 	now := time.Now()
 
@@ -236,12 +647,15 @@ func (db *MongoDB) CreateTransaction(req *TransactionRequest) (*Transaction, err
 		Currency:      req.Currency,
 		Status:        "pending", // Default status
 		Description:   req.Description,
+		Counterparty:  req.Counterparty,
+		Notes:         req.Notes,
 		PaymentID:     req.PaymentID,
+		TraceParent:   req.TraceParent,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	_, err := db.transactionCollection.InsertOne(ctx, transaction)
@@ -252,8 +666,11 @@ func (db *MongoDB) CreateTransaction(req *TransactionRequest) (*Transaction, err
 	return transaction, nil
 }
 
-func (db *MongoDB) GetUserTransactions(userID string, limit int) ([]*Transaction, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (db *MongoDB) GetUserTransactions(ctx context.Context, userID string, limit int) ([]*Transaction, error) {
+	ctx, span := startSpan(ctx, "GetUserTransactions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Set default limit if not provided
@@ -285,38 +702,122 @@ func (db *MongoDB) GetUserTransactions(userID string, limit int) ([]*Transaction
 	return transactions, nil
 }
 
-func (db *MongoDB) UpdateTransactionStatus(transactionID, status string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// UpdateTransactionStatus sets a transaction's status and reports whether
+// it changed; setting the status it already has leaves the history alone.
+// A change marks the transaction's events pending.
+func (db *MongoDB) UpdateTransactionStatus(ctx context.Context, transactionID, status string) (bool, error) {
+	ctx, span := startSpan(ctx, "UpdateTransactionStatus")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
+			"status":         status,
+			"events_pending": true,
+			"updated_at":     now,
+		},
+		"$push": bson.M{
+			"status_history": StatusChange{Status: status, ChangedAt: now},
 		},
 	}
 
-	result, err := db.transactionCollection.UpdateOne(ctx, bson.M{"transaction_id": transactionID}, update)
+	filter := bson.M{"transaction_id": transactionID, "status": bson.M{"$ne": status}}
+	result, err := db.transactionCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("failed to update transaction status: %w", err)
+		return false, fmt.Errorf("failed to update transaction status: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("transaction not found")
+		count, err := db.transactionCollection.CountDocuments(ctx, bson.M{"transaction_id": transactionID})
+		if err != nil {
+			return false, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if count == 0 {
+			return false, ErrTransactionNotFound
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// GetTransactionsWithPendingEvents lists a user's transactions whose last
+// status change has not been recorded in their event log
+func (db *MongoDB) GetTransactionsWithPendingEvents(ctx context.Context, userID string) ([]*Transaction, error) {
+	ctx, span := startSpan(ctx, "GetTransactionsWithPendingEvents")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "events_pending": true}
+	cursor, err := db.transactionCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// ClearTransactionEventsPending records that the events of a transaction
+// in the given status are logged. A status change since leaves them
+// pending.
+func (db *MongoDB) ClearTransactionEventsPending(ctx context.Context, transactionID, status string) error {
+	ctx, span := startSpan(ctx, "ClearTransactionEventsPending")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"transaction_id": transactionID, "status": status}
+	if _, err := db.transactionCollection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"events_pending": ""}}); err != nil {
+		return fmt.Errorf("failed to clear pending events: %w", err)
 	}
 
 	return nil
 }
 
-func (db *MongoDB) GetTransactionByPaymentID(paymentID string) (*Transaction, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// GetTransactionByID looks up a transaction of any user
+func (db *MongoDB) GetTransactionByID(ctx context.Context, transactionID string) (*Transaction, error) {
+	ctx, span := startSpan(ctx, "GetTransactionByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var transaction Transaction
+	err := db.transactionCollection.FindOne(ctx, bson.M{"transaction_id": transactionID}).Decode(&transaction)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
+
+func (db *MongoDB) GetTransactionByPaymentID(ctx context.Context, paymentID string) (*Transaction, error) {
+	ctx, span := startSpan(ctx, "GetTransactionByPaymentID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var transaction Transaction
 	err := db.transactionCollection.FindOne(ctx, bson.M{"payment_id": paymentID}).Decode(&transaction)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("transaction not found")
+			return nil, ErrTransactionNotFound
 		}
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
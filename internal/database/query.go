@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxPageSize caps a single page of transaction results
+const MaxPageSize = 200
+
+var ErrInvalidCursor = errcode.New(errcode.InvalidCursor, "invalid cursor")
+
+// TransactionFilter narrows a user's transactions; zero values match everything
+type TransactionFilter struct {
+	UserID    string
+	Types     []string
+	Statuses  []string
+	Currency  string
+	From      time.Time // Inclusive
+	To        time.Time // Exclusive
+	MinAmount float64
+	MaxAmount float64
+
+	WalletOnly bool // Leave out imported external bank movements
+}
+
+// TransactionQuery is a filter plus keyset pagination state
+type TransactionQuery struct {
+	TransactionFilter
+	Cursor string
+	Limit  int
+}
+
+// TransactionPage is one page of results ordered newest first
+type TransactionPage struct {
+	Transactions []*Transaction
+	NextCursor   string // Empty on the last page
+	Total        int64  // Matches for the filter across all pages
+}
+
+// bson builds the Mongo filter; user_id is always part of it so a query
+// can never reach another user's transactions
+func (f *TransactionFilter) bson() bson.M {
+	filter := bson.M{"user_id": f.UserID}
+
+	types := bson.M{}
+	if len(f.Types) > 0 {
+		types["$in"] = f.Types
+	}
+	if f.WalletOnly {
+		types["$nin"] = externalTypes
+	}
+	if len(types) > 0 {
+		filter["type"] = types
+	}
+	if len(f.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": f.Statuses}
+	}
+	if f.Currency != "" {
+		filter["currency"] = f.Currency
+	}
+
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lt"] = f.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	amount := bson.M{}
+	if f.MinAmount > 0 {
+		amount["$gte"] = f.MinAmount
+	}
+	if f.MaxAmount > 0 {
+		amount["$lte"] = f.MaxAmount
+	}
+	if len(amount) > 0 {
+		filter["amount"] = amount
+	}
+
+	return filter
+}
+
+// encodeCursor packs the sort key of the last returned transaction
+func encodeCursor(t *Transaction) string {
+	raw := strconv.FormatInt(t.CreatedAt.UnixMilli(), 10) + ":" + t.TransactionID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	millis, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return time.UnixMilli(ms), id, nil
+}
+
+// QueryTransactions returns one page of a user's transactions, newest
+// first, continuing after the given cursor
+func (db *MongoDB) QueryTransactions(ctx context.Context, q *TransactionQuery) (*TransactionPage, error) {
+	ctx, span := startSpan(ctx, "QueryTransactions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	filter := q.bson()
+	total, err := db.transactionCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	if q.Cursor != "" {
+		createdAt, id, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": createdAt}},
+			bson.M{"created_at": createdAt, "transaction_id": bson.M{"$lt": id}},
+		}}}}
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "transaction_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+	cursor, err := db.transactionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+
+	page := &TransactionPage{Total: total}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.NextCursor = encodeCursor(transactions[limit-1])
+	}
+	page.Transactions = transactions
+
+	return page, nil
+}
+
+// CountUserTransactions returns how many transactions a user has
+func (db *MongoDB) CountUserTransactions(ctx context.Context, userID string) (int64, error) {
+	ctx, span := startSpan(ctx, "CountUserTransactions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	total, err := db.transactionCollection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	return total, nil
+}
+
+// StreamTransactions calls fn for every transaction matching the filter,
+// oldest first, without loading the whole history into memory
+func (db *MongoDB) StreamTransactions(ctx context.Context, filter *TransactionFilter, fn func(*Transaction) error) error {
+	ctx, span := startSpan(ctx, "StreamTransactions")
+	defer span.End()
+
+	// Exports of long histories take longer than regular queries
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "transaction_id", Value: 1}})
+	cursor, err := db.transactionCollection.Find(ctx, filter.bson(), opts)
+	if err != nil {
+		return fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var transaction Transaction
+		if err := cursor.Decode(&transaction); err != nil {
+			return fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		if err := fn(&transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		id        string
+	}{
+		{"uuid", time.UnixMilli(1718000000123), "5f0c6c1e-6a9a-4f0e-9d43-0f5d8c2a7b11"},
+		{"id with colon", time.UnixMilli(1718000000123), "ext:2024-06-10:0001"},
+		{"epoch", time.UnixMilli(0), "a"},
+		{"before epoch", time.UnixMilli(-1500), "b"},
+		{"sub-millisecond dropped", time.Unix(1718000000, 123456789), "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := encodeCursor(&Transaction{CreatedAt: tt.createdAt, TransactionID: tt.id})
+
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				t.Fatalf("decodeCursor(%q) error = %v", cursor, err)
+			}
+			if want := time.UnixMilli(tt.createdAt.UnixMilli()); !createdAt.Equal(want) {
+				t.Errorf("created_at = %v, want %v", createdAt, want)
+			}
+			if id != tt.id {
+				t.Errorf("id = %q, want %q", id, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("1718000000123:ab"))},
+		{"no separator", encode("1718000000123")},
+		{"empty id", encode("1718000000123:")},
+		{"empty time", encode(":a")},
+		{"time not a number", encode("yesterday:a")},
+		{"time overflows", encode("99999999999999999999:a")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeCursor(tt.cursor)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
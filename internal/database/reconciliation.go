@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Discrepancy kinds found when reconciling against a payment provider
+const (
+	DiscrepancyStatusMismatch = "status_mismatch" // Statuses differ
+	DiscrepancyAmountMismatch = "amount_mismatch" // Amount or currency differ
+	DiscrepancyMissingLocal   = "missing_local"   // Provider payment without a transaction
+	DiscrepancyMissingRemote  = "missing_remote"  // Transaction the provider does not know
+)
+
+// Discrepancy is one disagreement between the transactions collection and
+// the payment provider
+type Discrepancy struct {
+	Kind           string `json:"kind" bson:"kind"`
+	PaymentID      string `json:"payment_id" bson:"payment_id"`
+	TransactionID  string `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"`
+	UserID         string `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	LocalStatus    string `json:"local_status,omitempty" bson:"local_status,omitempty"`
+	ProviderStatus string `json:"provider_status,omitempty" bson:"provider_status,omitempty"`
+	LocalAmount    int64  `json:"local_amount,omitempty" bson:"local_amount,omitempty"`       // Minor units
+	ProviderAmount int64  `json:"provider_amount,omitempty" bson:"provider_amount,omitempty"` // Minor units
+	Currency       string `json:"currency,omitempty" bson:"currency,omitempty"`
+	Fixed          bool   `json:"fixed" bson:"fixed"`
+	Detail         string `json:"detail" bson:"detail"`
+}
+
+// ReconciliationReport summarizes one reconciliation run
+type ReconciliationReport struct {
+	ReportID      string         `json:"report_id" bson:"report_id"`
+	Provider      string         `json:"provider" bson:"provider"`
+	From          time.Time      `json:"from" bson:"from"`
+	To            time.Time      `json:"to" bson:"to"`
+	DryRun        bool           `json:"dry_run" bson:"dry_run"`
+	Checked       int            `json:"checked" bson:"checked"` // Payments and transactions compared
+	Matched       int            `json:"matched" bson:"matched"`
+	Fixed         int            `json:"fixed" bson:"fixed"`
+	Discrepancies []*Discrepancy `json:"discrepancies" bson:"discrepancies"`
+	StartedAt     time.Time      `json:"started_at" bson:"started_at"`
+	FinishedAt    time.Time      `json:"finished_at" bson:"finished_at"`
+}
+
+// StreamPaymentTransactions calls fn for every transaction with a payment
+// ID created in [from, to), across all users
+func (db *MongoDB) StreamPaymentTransactions(ctx context.Context, from, to time.Time, fn func(*Transaction) error) error {
+	ctx, span := startSpan(ctx, "StreamPaymentTransactions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	filter := bson.M{
+		"payment_id": bson.M{"$exists": true, "$ne": ""},
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}
+	cursor, err := db.transactionCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("failed to get payment transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var transaction Transaction
+		if err := cursor.Decode(&transaction); err != nil {
+			return fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		if err := fn(&transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	return nil
+}
+
+// TransitionTransactionStatus changes a transaction's status only if it is
+// still in the expected one, so a fix never overwrites a concurrent
+// webhook. The change and its reason are appended to the status history,
+// and the transaction's events are marked pending.
+func (db *MongoDB) TransitionTransactionStatus(ctx context.Context, transactionID, from, to, reason string) (bool, error) {
+	ctx, span := startSpan(ctx, "TransitionTransactionStatus")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":         to,
+			"events_pending": true,
+			"updated_at":     now,
+		},
+		"$push": bson.M{
+			"status_history": StatusChange{Status: to, ChangedAt: now, Reason: reason},
+		},
+	}
+
+	result, err := db.transactionCollection.UpdateOne(ctx, bson.M{"transaction_id": transactionID, "status": from}, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// SaveReconciliationReport stores a finished reconciliation run
+func (db *MongoDB) SaveReconciliationReport(ctx context.Context, report *ReconciliationReport) error {
+	ctx, span := startSpan(ctx, "SaveReconciliationReport")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if report.ReportID == "" {
+		report.ReportID = uuid.New().String()
+	}
+
+	_, err := db.reconciliationCollection.InsertOne(ctx, report)
+	if err != nil {
+		return fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+
+	return nil
+}
+
+// GetStalePendingDeposits returns up to limit deposits still pending that
+// were created before cutoff, oldest first
+func (db *MongoDB) GetStalePendingDeposits(ctx context.Context, cutoff time.Time, limit int) ([]*Transaction, error) {
+	ctx, span := startSpan(ctx, "GetStalePendingDeposits")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"type":       "deposit",
+		"status":     "pending",
+		"created_at": bson.M{"$lt": cutoff},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := db.transactionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending deposits: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode pending deposits: %w", err)
+	}
+
+	return transactions, nil
+}
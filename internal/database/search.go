@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"pocket-wallet/internal/errcode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextRange marks a matched term inside a field, as UTF-16 code unit
+// offsets so JavaScript clients can slice the text with them directly
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Highlight lists where the query matched one field of a transaction
+type Highlight struct {
+	Field   string      `json:"field"`
+	Text    string      `json:"text"`
+	Matches []TextRange `json:"matches"`
+}
+
+// SearchResult is a ranked transaction match
+type SearchResult struct {
+	Transaction *Transaction
+	Score       float64
+	Highlights  []Highlight
+}
+
+// searchableFields are the text-indexed transaction fields, in display order
+var searchableFields = []string{"description", "counterparty", "notes", "payment_id"}
+
+// SearchTransactions runs a full-text query over one user's transactions
+// and returns results ranked by relevance. A query that looks like a
+// Stripe payment ID is matched exactly instead.
+func (db *MongoDB) SearchTransactions(ctx context.Context, userID, query string, limit int) ([]*SearchResult, error) {
+	ctx, span := startSpan(ctx, "SearchTransactions")
+	defer span.End()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errcode.New(errcode.InvalidInput, "search query is required")
+	}
+
+	if limit <= 0 || limit > MaxPageSize {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	type scoredTransaction struct {
+		Transaction `bson:",inline"`
+		Score       float64 `bson:"score"`
+	}
+
+	var found []scoredTransaction
+	if strings.HasPrefix(query, "pi_") && !strings.ContainsAny(query, " \t") {
+		cursor, err := db.transactionCollection.Find(ctx, bson.M{"user_id": userID, "payment_id": query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search transactions: %w", err)
+		}
+		defer cursor.Close(ctx)
+		if err := cursor.All(ctx, &found); err != nil {
+			return nil, fmt.Errorf("failed to decode transactions: %w", err)
+		}
+		for i := range found {
+			found[i].Score = 1
+		}
+	} else {
+		filter := bson.M{
+			"user_id": userID,
+			"$text":   bson.M{"$search": query},
+		}
+		opts := options.Find().
+			SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+			SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "created_at", Value: -1}}).
+			SetLimit(int64(limit))
+		cursor, err := db.transactionCollection.Find(ctx, filter, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search transactions: %w", err)
+		}
+		defer cursor.Close(ctx)
+		if err := cursor.All(ctx, &found); err != nil {
+			return nil, fmt.Errorf("failed to decode transactions: %w", err)
+		}
+	}
+
+	terms := searchTerms(query)
+	results := make([]*SearchResult, 0, len(found))
+	for i := range found {
+		transaction := found[i].Transaction
+		// Defensive: never return another user's data even if the query changes
+		if transaction.UserID != userID {
+			continue
+		}
+		results = append(results, &SearchResult{
+			Transaction: &transaction,
+			Score:       found[i].Score,
+			Highlights:  highlightTransaction(&transaction, terms),
+		})
+	}
+
+	return results, nil
+}
+
+// UpdateTransactionNotes replaces the free-text notes on a user's transaction
+func (db *MongoDB) UpdateTransactionNotes(ctx context.Context, userID, transactionID, notes string) error {
+	ctx, span := startSpan(ctx, "UpdateTransactionNotes")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"notes":      notes,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := db.transactionCollection.UpdateOne(ctx, bson.M{"transaction_id": transactionID, "user_id": userID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction notes: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// searchTerms splits a query the way the text index does, dropping
+// quotes and negated terms, which must not be highlighted
+func searchTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(query, isTermSeparator) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		if folded := foldTerm(word); folded != "" {
+			terms = append(terms, folded)
+		}
+	}
+	return terms
+}
+
+func highlightTransaction(t *Transaction, terms []string) []Highlight {
+	values := map[string]string{
+		"description":  t.Description,
+		"counterparty": t.Counterparty,
+		"notes":        t.Notes,
+		"payment_id":   t.PaymentID,
+	}
+
+	var highlights []Highlight
+	for _, field := range searchableFields {
+		text := values[field]
+		if text == "" {
+			continue
+		}
+		if matches := matchTerms(text, terms); len(matches) > 0 {
+			highlights = append(highlights, Highlight{Field: field, Text: text, Matches: matches})
+		}
+	}
+	return highlights
+}
+
+// matchTerms finds every word in text equal to a query term, ignoring
+// case and diacritics the way the text index does, so "zabka" highlights
+// "Żabka"
+func matchTerms(text string, terms []string) []TextRange {
+	var matches []TextRange
+	start, startUnit := -1, 0
+	flush := func(end, endUnit int) {
+		if start < 0 {
+			return
+		}
+		word := foldTerm(text[start:end])
+		for _, term := range terms {
+			if word == term {
+				matches = append(matches, TextRange{Start: startUnit, End: endUnit})
+				break
+			}
+		}
+		start = -1
+	}
+
+	unit := 0
+	for i, r := range text {
+		if isTermSeparator(r) {
+			flush(i, unit)
+		} else if start < 0 {
+			start, startUnit = i, unit
+		}
+		unit += utf16.RuneLen(r)
+	}
+	flush(len(text), unit)
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+func isTermSeparator(r rune) bool {
+	return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '_' && r != '-')
+}
+
+// foldTerm lower-cases a word and strips combining diacritics. Letters
+// without a decomposition, like "ł", are kept: the text index does not
+// fold them either, and a highlight must not claim a match it did not make.
+func foldTerm(word string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), strings.ToLower(word))
+	if err != nil {
+		return strings.ToLower(word)
+	}
+	return folded
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  []TextRange
+	}{
+		{"ascii", "Top up via card", "card", []TextRange{{11, 15}}},
+		{"diacritics folded", "Zakupy Żabka", "zabka", []TextRange{{7, 12}}},
+		{"offsets count UTF-16 units", "Płatność za żółty parasol", "parasol", []TextRange{{18, 25}}},
+		{"astral rune counts twice", "🎉 prezent", "prezent", []TextRange{{3, 10}}},
+		{"ł is not folded", "Doładowanie konta", "doladowanie konta", []TextRange{{12, 17}}},
+		{"ł matches itself", "Doładowanie konta", "DOŁADOWANIE", []TextRange{{0, 11}}},
+		{"negated term skipped", "rent and bills", "rent -bills", []TextRange{{0, 4}}},
+		{"every occurrence", "kawa, kawa", "kawa", []TextRange{{0, 4}, {6, 10}}},
+		{"no match", "Przelew", "czynsz", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchTerms(tt.text, searchTerms(tt.query))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchTerms(%q, %q) = %v, want %v", tt.text, tt.query, got, tt.want)
+			}
+		})
+	}
+}
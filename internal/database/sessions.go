@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrSessionNotFound = errcode.New(errcode.Unauthorized, "session not found or expired")
+
+// Session is an API session. Only a hash of the token is stored, so a
+// database dump cannot be replayed against the API.
+type Session struct {
+	TokenHash string    `json:"-" bson:"token_hash"`
+	UserID    string    `json:"user_id" bson:"user_id"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession issues a new random session token for a user
+func (db *MongoDB) CreateSession(ctx context.Context, userID string, ttl time.Duration) (string, *Session, error) {
+	ctx, span := startSpan(ctx, "CreateSession")
+	defer span.End()
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	session := &Session{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := db.sessionCollection.InsertOne(ctx, session); err != nil {
+		return "", nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return token, session, nil
+}
+
+// GetSession resolves a token to its unexpired session
+func (db *MongoDB) GetSession(ctx context.Context, token string) (*Session, error) {
+	ctx, span := startSpan(ctx, "GetSession")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"token_hash": hashToken(token),
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var session Session
+	if err := db.sessionCollection.FindOne(ctx, filter).Decode(&session); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// DeleteSession revokes a token
+func (db *MongoDB) DeleteSession(ctx context.Context, token string) error {
+	ctx, span := startSpan(ctx, "DeleteSession")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := db.sessionCollection.DeleteOne(ctx, bson.M{"token_hash": hashToken(token)}); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"pocket-wallet/internal/metrics"
+	"pocket-wallet/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/event"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan traces one MongoDB method
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "MongoDB."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemMongoDB))
+}
+
+// commandMonitor feeds command latency to the metrics and records failed
+// commands on the span of the method that sent them
+func commandMonitor() *event.CommandMonitor {
+	monitor := metrics.MongoMonitor()
+	failed := monitor.Failed
+	monitor.Failed = func(ctx context.Context, e *event.CommandFailedEvent) {
+		failed(ctx, e)
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(errors.New(e.Failure), trace.WithAttributes(semconv.DBOperationName(e.CommandName)))
+	}
+	return monitor
+}
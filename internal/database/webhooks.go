@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Webhook event processing outcomes
+const (
+	WebhookStatusReceived  = "received"  // Stored, not processed yet
+	WebhookStatusProcessed = "processed" // Handled successfully
+	WebhookStatusIgnored   = "ignored"   // Event type we do not act on
+	WebhookStatusFailed    = "failed"    // Handler returned an error
+)
+
+var ErrWebhookEventNotFound = errcode.New(errcode.WebhookEventNotFound, "webhook event not found")
+
+// WebhookEvent is a verified webhook delivery stored with its raw payload
+// so failed events can be inspected and replayed
+type WebhookEvent struct {
+	EventID     string    `json:"event_id" bson:"event_id"`
+	Provider    string    `json:"provider" bson:"provider"`
+	Type        string    `json:"type" bson:"type"`
+	Payload     string    `json:"payload" bson:"payload"` // Raw request body
+	Status      string    `json:"status" bson:"status"`
+	Error       string    `json:"error,omitempty" bson:"error,omitempty"`
+	Deliveries  int       `json:"deliveries" bson:"deliveries"` // Times the provider sent it
+	Attempts    int       `json:"attempts" bson:"attempts"`     // Times we processed it, including replays
+	ReceivedAt  time.Time `json:"received_at" bson:"received_at"`
+	ProcessedAt time.Time `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
+}
+
+// RecordWebhookEvent stores a delivery. A redelivery of a known event only
+// bumps its delivery count; the first payload is kept.
+func (db *MongoDB) RecordWebhookEvent(ctx context.Context, provider, eventID, eventType string, payload []byte) error {
+	ctx, span := startSpan(ctx, "RecordWebhookEvent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"event_id":    eventID,
+			"provider":    provider,
+			"type":        eventType,
+			"payload":     string(payload),
+			"status":      WebhookStatusReceived,
+			"attempts":    0,
+			"received_at": time.Now(),
+		},
+		"$inc": bson.M{"deliveries": 1},
+	}
+
+	_, err := db.webhookCollection.UpdateOne(ctx, bson.M{"event_id": eventID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to record webhook event: %w", err)
+	}
+
+	return nil
+}
+
+// SetWebhookEventOutcome records the result of processing an event
+func (db *MongoDB) SetWebhookEventOutcome(ctx context.Context, eventID, status string, processErr error) error {
+	ctx, span := startSpan(ctx, "SetWebhookEventOutcome")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"status":       status,
+		"processed_at": time.Now(),
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"attempts": 1},
+	}
+	if processErr != nil {
+		set["error"] = processErr.Error()
+	} else {
+		update["$unset"] = bson.M{"error": ""}
+	}
+
+	result, err := db.webhookCollection.UpdateOne(ctx, bson.M{"event_id": eventID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrWebhookEventNotFound
+	}
+
+	return nil
+}
+
+// GetWebhookEvent returns one stored event
+func (db *MongoDB) GetWebhookEvent(ctx context.Context, eventID string) (*WebhookEvent, error) {
+	ctx, span := startSpan(ctx, "GetWebhookEvent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var event WebhookEvent
+	err := db.webhookCollection.FindOne(ctx, bson.M{"event_id": eventID}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrWebhookEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+
+	return &event, nil
+}
+
+// GetWebhookEvents lists stored events newest first, optionally only those
+// with the given status
+func (db *MongoDB) GetWebhookEvents(ctx context.Context, status string, limit int) ([]*WebhookEvent, error) {
+	ctx, span := startSpan(ctx, "GetWebhookEvents")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "received_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := db.webhookCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*WebhookEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook events: %w", err)
+	}
+
+	return events, nil
+}
+
+// CountWebhookEvents counts events with a status received before a point
+// in time, to spot a processing backlog
+func (db *MongoDB) CountWebhookEvents(ctx context.Context, status string, receivedBefore time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "CountWebhookEvents")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":      status,
+		"received_at": bson.M{"$lt": receivedBefore},
+	}
+	count, err := db.webhookCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook events: %w", err)
+	}
+
+	return count, nil
+}
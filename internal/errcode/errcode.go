@@ -0,0 +1,104 @@
+package errcode
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code identifies a kind of failure. Codes are part of the frontend and
+// REST API contract and must not change once released.
+type Code string
+
+const (
+	InvalidInput         Code = "INVALID_INPUT"
+	Unauthorized         Code = "UNAUTHORIZED" // Missing, unknown or expired API session
+	InvalidCredentials   Code = "INVALID_CREDENTIALS"
+	NotFound             Code = "NOT_FOUND" // No such REST endpoint
+	UserNotFound         Code = "USER_NOT_FOUND"
+	LoginTaken           Code = "LOGIN_TAKEN"
+	BalanceConflict      Code = "BALANCE_CONFLICT" // Balance changed since the client read it
+	TransactionNotFound  Code = "TRANSACTION_NOT_FOUND"
+	InvalidCursor        Code = "INVALID_CURSOR"
+	InsufficientFunds    Code = "INSUFFICIENT_FUNDS"
+	HoldNotFound         Code = "HOLD_NOT_FOUND"
+	HoldNotActive        Code = "HOLD_NOT_ACTIVE"
+	QuoteNotFound        Code = "QUOTE_NOT_FOUND"
+	QuoteExpired         Code = "QUOTE_EXPIRED"
+	QuoteUsed            Code = "QUOTE_USED"
+	PaymentNotFound      Code = "PAYMENT_NOT_FOUND"
+	PaymentDeclined      Code = "PAYMENT_DECLINED"
+	PaymentFailed        Code = "PAYMENT_FAILED" // Payment provider refused or could not be reached
+	WebhookEventNotFound Code = "WEBHOOK_EVENT_NOT_FOUND"
+	Unavailable          Code = "UNAVAILABLE"
+	RequestFailed        Code = "REQUEST_FAILED" // Error without a more specific code
+)
+
+// Error is a failure with a stable code. Message is an English detail for
+// logs and developers; users see the localized text for the code.
+type Error struct {
+	Code    Code
+	Message string
+	Err     error // Underlying cause, if any
+}
+
+// New creates an error, typically a package-level sentinel
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap attaches a code to an underlying error
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any error with the same code, so errors.Is against a
+// sentinel also matches re-worded and wrapped errors of the same kind
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Of returns the code of the first coded error in err's chain
+func Of(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return RequestFailed
+}
+
+// HTTPStatus is the REST API status for a code
+func (c Code) HTTPStatus() int {
+	switch c {
+	case InvalidInput, InvalidCursor:
+		return http.StatusBadRequest
+	case Unauthorized, InvalidCredentials:
+		return http.StatusUnauthorized
+	case PaymentDeclined:
+		return http.StatusPaymentRequired
+	case NotFound, UserNotFound, TransactionNotFound, HoldNotFound, QuoteNotFound, PaymentNotFound, WebhookEventNotFound:
+		return http.StatusNotFound
+	case LoginTaken, BalanceConflict, HoldNotActive, QuoteUsed:
+		return http.StatusConflict
+	case QuoteExpired:
+		return http.StatusGone
+	case InsufficientFunds:
+		return http.StatusUnprocessableEntity
+	case PaymentFailed:
+		return http.StatusBadGateway
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
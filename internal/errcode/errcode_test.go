@@ -0,0 +1,53 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"coded", New(InvalidInput, "amount must be positive"), InvalidInput},
+		{"wrapped", fmt.Errorf("failed to capture hold: %w", New(HoldNotActive, "hold is not active")), HoldNotActive},
+		{"wrapping a cause", Wrap(InvalidInput, "invalid CAMT.053 XML", errors.New("EOF")), InvalidInput},
+		{"uncoded", errors.New("connection reset"), RequestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.want {
+				t.Errorf("Of() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code Code
+		want int
+	}{
+		{InvalidInput, http.StatusBadRequest},
+		{InvalidCursor, http.StatusBadRequest},
+		{InvalidCredentials, http.StatusUnauthorized},
+		{TransactionNotFound, http.StatusNotFound},
+		{BalanceConflict, http.StatusConflict},
+		{QuoteExpired, http.StatusGone},
+		{Unavailable, http.StatusServiceUnavailable},
+		{RequestFailed, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			if got := tt.code.HTTPStatus(); got != tt.want {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
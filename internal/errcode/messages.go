@@ -0,0 +1,70 @@
+package errcode
+
+import "strings"
+
+// DefaultLanguage is the language of the desktop interface unless
+// configured otherwise
+const DefaultLanguage = "pl"
+
+var messages = map[string]map[Code]string{
+	"pl": {
+		InvalidInput:         "Nieprawidłowe dane",
+		Unauthorized:         "Sesja wygasła, zaloguj się ponownie",
+		InvalidCredentials:   "Nieprawidłowy login lub hasło",
+		NotFound:             "Nie znaleziono",
+		UserNotFound:         "Nie znaleziono użytkownika",
+		LoginTaken:           "Ten login jest już zajęty",
+		BalanceConflict:      "Saldo zostało zmienione w międzyczasie, odśwież i spróbuj ponownie",
+		TransactionNotFound:  "Nie znaleziono transakcji",
+		InvalidCursor:        "Nieprawidłowa strona wyników",
+		InsufficientFunds:    "Niewystarczające środki",
+		HoldNotFound:         "Nie znaleziono blokady środków",
+		HoldNotActive:        "Blokada środków nie jest już aktywna",
+		QuoteNotFound:        "Nie znaleziono oferty wymiany",
+		QuoteExpired:         "Oferta wymiany wygasła",
+		QuoteUsed:            "Oferta wymiany została już wykorzystana",
+		PaymentNotFound:      "Nie znaleziono płatności",
+		PaymentDeclined:      "Płatność została odrzucona",
+		PaymentFailed:        "Operator płatności odrzucił żądanie",
+		WebhookEventNotFound: "Nie znaleziono zdarzenia",
+		Unavailable:          "Usługa jest chwilowo niedostępna",
+		RequestFailed:        "Nie udało się wykonać operacji",
+	},
+	"en": {
+		InvalidInput:         "Invalid input",
+		Unauthorized:         "Your session has expired, please log in again",
+		InvalidCredentials:   "Invalid login or password",
+		NotFound:             "Not found",
+		UserNotFound:         "User not found",
+		LoginTaken:           "This login is already taken",
+		BalanceConflict:      "The balance changed in the meantime, refresh and try again",
+		TransactionNotFound:  "Transaction not found",
+		InvalidCursor:        "Invalid results page",
+		InsufficientFunds:    "Insufficient funds",
+		HoldNotFound:         "Hold not found",
+		HoldNotActive:        "The hold is no longer active",
+		QuoteNotFound:        "Exchange quote not found",
+		QuoteExpired:         "The exchange quote has expired",
+		QuoteUsed:            "The exchange quote has already been used",
+		PaymentNotFound:      "Payment not found",
+		PaymentDeclined:      "The payment was declined",
+		PaymentFailed:        "The payment provider rejected the request",
+		WebhookEventNotFound: "Event not found",
+		Unavailable:          "The service is temporarily unavailable",
+		RequestFailed:        "The operation failed",
+	},
+}
+
+// Message is the user-facing text for a code. Unknown languages fall back
+// to English; a language tag such as "pl-PL" matches "pl".
+func (c Code) Message(language string) string {
+	language, _, _ = strings.Cut(strings.ToLower(language), "-")
+	catalog, ok := messages[language]
+	if !ok {
+		catalog = messages["en"]
+	}
+	if text, ok := catalog[c]; ok {
+		return text
+	}
+	return catalog[RequestFailed]
+}
@@ -0,0 +1,216 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/errcode"
+)
+
+// Format identifies an export file format
+type Format string
+
+const (
+	FormatCSV       Format = "csv"
+	FormatJSONLines Format = "jsonl"
+	FormatOFX       Format = "ofx"
+	FormatQIF       Format = "qif"
+)
+
+// Formats lists every supported format
+var Formats = []Format{FormatCSV, FormatJSONLines, FormatOFX, FormatQIF}
+
+// Options tune how transactions are rendered
+type Options struct {
+	Delimiter rune   // CSV field separator, defaults to ','
+	Language  string // "pl" or "en" column headers, defaults to "en"
+
+	// Statement formats (OFX) describe a single-currency account
+	AccountID     string
+	Currency      string
+	From          time.Time
+	To            time.Time
+	LedgerBalance *int64 // Closing balance in minor units; summed from the lines when nil
+}
+
+// Writer renders transactions one at a time; Close flushes any trailer
+type Writer interface {
+	Write(t *database.Transaction) error
+	Close() error
+}
+
+// ParseFormat validates a user-supplied format name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatCSV, FormatJSONLines, FormatOFX, FormatQIF:
+		return f, nil
+	case "json", "ndjson":
+		return FormatJSONLines, nil
+	}
+	return "", errcode.New(errcode.InvalidInput, "unsupported export format: "+name)
+}
+
+// Extension returns the file extension for a format, without the dot
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ContentType returns the MIME type of files in a format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONLines:
+		return "application/x-ndjson"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatQIF:
+		return "application/qif"
+	}
+	return "application/octet-stream"
+}
+
+// IsStatement reports whether the format is a bank statement for personal
+// finance tools. Statements only carry completed entries by default and
+// cover a single currency.
+func (f Format) IsStatement() bool {
+	return f == FormatOFX || f == FormatQIF
+}
+
+// NewWriter creates a writer for the given format
+func NewWriter(w io.Writer, format Format, opts Options) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, opts)
+	case FormatJSONLines:
+		return &jsonLinesWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatOFX:
+		return newOFXWriter(w, opts)
+	case FormatQIF:
+		return newQIFWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// Amount renders a transaction amount as an exact decimal string in its
+// currency's minor units, e.g. "12.30"
+func Amount(t *database.Transaction) string {
+	return currency.FormatDecimal(currency.ToMinor(t.Amount, t.Currency), t.Currency)
+}
+
+var csvHeaders = map[string][]string{
+	"en": {"Transaction ID", "Date", "Type", "Amount", "Currency", "Status", "Description", "Counterparty", "Notes", "Payment ID"},
+	"pl": {"ID transakcji", "Data", "Typ", "Kwota", "Waluta", "Status", "Opis", "Kontrahent", "Notatki", "ID płatności"},
+}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	header        []string
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer, opts Options) (*csvWriter, error) {
+	language := opts.Language
+	if language == "" {
+		language = "en"
+	}
+	header, ok := csvHeaders[language]
+	if !ok {
+		return nil, errcode.New(errcode.InvalidInput, "unsupported header language: "+opts.Language)
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		if opts.Delimiter == '"' || opts.Delimiter == '\r' || opts.Delimiter == '\n' {
+			return nil, fmt.Errorf("invalid CSV delimiter: %q", opts.Delimiter)
+		}
+		writer.Comma = opts.Delimiter
+	}
+
+	return &csvWriter{writer: writer, header: header}, nil
+}
+
+func (c *csvWriter) Write(t *database.Transaction) error {
+	if !c.headerWritten {
+		if err := c.writer.Write(c.header); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	return c.writer.Write([]string{
+		t.TransactionID,
+		t.CreatedAt.UTC().Format(time.RFC3339),
+		t.Type,
+		Amount(t),
+		t.Currency,
+		t.Status,
+		textCell(t.Description),
+		textCell(t.Counterparty),
+		textCell(t.Notes),
+		t.PaymentID,
+	})
+}
+
+// textCell keeps spreadsheets from evaluating free text as a formula by
+// prefixing cells that start like one with an apostrophe. Excel and
+// LibreOffice also read a formula after a leading tab or carriage return.
+func textCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Close() error {
+	// An empty export still gets a header so spreadsheets open it cleanly
+	if !c.headerWritten {
+		if err := c.writer.Write(c.header); err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonLine is one exported transaction; amount is a string to keep it exact
+type jsonLine struct {
+	TransactionID string    `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Type          string    `json:"type"`
+	Amount        string    `json:"amount"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+	Description   string    `json:"description"`
+	Counterparty  string    `json:"counterparty,omitempty"`
+	Notes         string    `json:"notes,omitempty"`
+	PaymentID     string    `json:"payment_id,omitempty"`
+}
+
+type jsonLinesWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonLinesWriter) Write(t *database.Transaction) error {
+	return j.encoder.Encode(jsonLine{
+		TransactionID: t.TransactionID,
+		CreatedAt:     t.CreatedAt.UTC(),
+		Type:          t.Type,
+		Amount:        Amount(t),
+		Currency:      t.Currency,
+		Status:        t.Status,
+		Description:   t.Description,
+		Counterparty:  t.Counterparty,
+		Notes:         t.Notes,
+		PaymentID:     t.PaymentID,
+	})
+}
+
+func (j *jsonLinesWriter) Close() error {
+	return nil
+}
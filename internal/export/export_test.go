@@ -0,0 +1,319 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"pocket-wallet/internal/database"
+)
+
+var postedAt = time.Date(2024, 6, 10, 12, 30, 0, 0, time.UTC)
+
+func sampleTransactions() []*database.Transaction {
+	return []*database.Transaction{
+		{
+			TransactionID: "5f0c6c1e-6a9a-4f0e-9d43-0f5d8c2a7b11",
+			Type:          "deposit",
+			Amount:        120.5,
+			Currency:      "PLN",
+			Status:        "completed",
+			Description:   "Doładowanie kartą",
+			PaymentID:     "pi_123",
+			CreatedAt:     postedAt,
+		},
+		{
+			TransactionID: "0b7e2f4a-1c3d-4e5f-8a9b-0c1d2e3f4a5b",
+			Type:          "payment",
+			Amount:        19.99,
+			Currency:      "PLN",
+			Status:        "completed",
+			Description:   "Bilet \"normalny\", strefa 1",
+			Counterparty:  "ZTM <Warszawa> & Co",
+			CreatedAt:     postedAt.Add(time.Hour),
+		},
+	}
+}
+
+func render(t *testing.T, format Format, opts Options, transactions []*database.Transaction) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	writer, err := NewWriter(&b, format, opts)
+	if err != nil {
+		t.Fatalf("NewWriter(%s) error = %v", format, err)
+	}
+	for _, tx := range transactions {
+		if err := writer.Write(tx); err != nil {
+			t.Fatalf("Write(%s) error = %v", tx.TransactionID, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"csv", FormatCSV, false},
+		{" OFX ", FormatOFX, false},
+		{"qif", FormatQIF, false},
+		{"jsonl", FormatJSONLines, false},
+		{"json", FormatJSONLines, false},
+		{"ndjson", FormatJSONLines, false},
+		{"xlsx", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(string) string
+		in   string
+		want string
+	}{
+		{"FITID strips dashes", FITID, "5f0c6c1e-6a9a-4f0e-9d43-0f5d8c2a7b11", "5F0C6C1E6A9A4F0E9D430F5D8C2A7B11"},
+		{"FITID is stable", FITID, "5F0C6C1E6A9A4F0E9D430F5D8C2A7B11", "5F0C6C1E6A9A4F0E9D430F5D8C2A7B11"},
+		{"AccountID fits OFX", AccountID, "5f0c6c1e-6a9a-4f0e-9d43-0f5d8c2a7b11", "5F0C6C1E6A9A4F0E9D430F"},
+		{"AccountID keeps short IDs", AccountID, "user-1", "USER1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         Options
+		transactions []*database.Transaction
+		want         string
+	}{
+		{
+			name: "empty export keeps the header",
+			want: "Transaction ID,Date,Type,Amount,Currency,Status,Description,Counterparty,Notes,Payment ID\n",
+		},
+		{
+			name:         "polish headers with semicolons",
+			opts:         Options{Language: "pl", Delimiter: ';'},
+			transactions: sampleTransactions()[:1],
+			want: "ID transakcji;Data;Typ;Kwota;Waluta;Status;Opis;Kontrahent;Notatki;ID płatności\n" +
+				"5f0c6c1e-6a9a-4f0e-9d43-0f5d8c2a7b11;2024-06-10T12:30:00Z;deposit;120.50;PLN;completed;Doładowanie kartą;;;pi_123\n",
+		},
+		{
+			name:         "quotes fields with the delimiter",
+			transactions: sampleTransactions()[1:],
+			want: "Transaction ID,Date,Type,Amount,Currency,Status,Description,Counterparty,Notes,Payment ID\n" +
+				"0b7e2f4a-1c3d-4e5f-8a9b-0c1d2e3f4a5b,2024-06-10T13:30:00Z,payment,19.99,PLN,completed,\"Bilet \"\"normalny\"\", strefa 1\",ZTM <Warszawa> & Co,,\n",
+		},
+		{
+			name: "neutralizes formulas in text cells",
+			transactions: []*database.Transaction{{
+				TransactionID: "tx-1",
+				Type:          "payment",
+				Amount:        5,
+				Currency:      "PLN",
+				Status:        "completed",
+				Description:   "=HYPERLINK(\"http://example.com\")",
+				Counterparty:  "@SUM(A1:A9)",
+				Notes:         "+48 123 456 789",
+				CreatedAt:     postedAt,
+			}, {
+				TransactionID: "tx-2",
+				Type:          "payment",
+				Amount:        5,
+				Currency:      "PLN",
+				Status:        "completed",
+				Description:   "-2+3",
+				Counterparty:  "Sklep = tanio",
+				CreatedAt:     postedAt,
+			}, {
+				TransactionID: "tx-3",
+				Type:          "payment",
+				Amount:        5,
+				Currency:      "PLN",
+				Status:        "completed",
+				Description:   "\t=1+1",
+				Counterparty:  "\r=cmd|' /C calc'!A0",
+				Notes:         "Za\tzakupy",
+				CreatedAt:     postedAt,
+			}},
+			want: "Transaction ID,Date,Type,Amount,Currency,Status,Description,Counterparty,Notes,Payment ID\n" +
+				"tx-1,2024-06-10T12:30:00Z,payment,5.00,PLN,completed,\"'=HYPERLINK(\"\"http://example.com\"\")\",'@SUM(A1:A9),'+48 123 456 789,\n" +
+				"tx-2,2024-06-10T12:30:00Z,payment,5.00,PLN,completed,'-2+3,Sklep = tanio,,\n" +
+				"tx-3,2024-06-10T12:30:00Z,payment,5.00,PLN,completed,'\t=1+1,\"'\r=cmd|' /C calc'!A0\",Za\tzakupy,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(t, FormatCSV, tt.opts, tt.transactions); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVWriterOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown language", Options{Language: "de"}},
+		{"quote delimiter", Options{Delimiter: '"'}},
+		{"newline delimiter", Options{Delimiter: '\n'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWriter(&bytes.Buffer{}, FormatCSV, tt.opts); err == nil {
+				t.Error("NewWriter() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestOFXWriter(t *testing.T) {
+	balance := int64(10051)
+	opts := Options{
+		AccountID:     AccountID("5f0c6c1e-6a9a-4f0e-9d43-0f5d8c2a7b11"),
+		Currency:      "PLN",
+		From:          time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		To:            time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		LedgerBalance: &balance,
+	}
+	out := render(t, FormatOFX, opts, sampleTransactions())
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"account", "<BANKACCTFROM><BANKID>POCKETWALLET</BANKID><ACCTID>5F0C6C1E6A9A4F0E9D430F</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>"},
+		{"currency", "<CURDEF>PLN</CURDEF>"},
+		{"period start", "<DTSTART>20240601000000.000[0:GMT]</DTSTART>"},
+		{"period end", "<DTEND>20240701000000.000[0:GMT]</DTEND>"},
+		{"deposit type", "<TRNTYPE>DEP</TRNTYPE>"},
+		{"credit amount", "<TRNAMT>120.50</TRNAMT>"},
+		{"debit amount", "<TRNAMT>-19.99</TRNAMT>"},
+		{"posted time", "<DTPOSTED>20240610123000.000[0:GMT]</DTPOSTED>"},
+		{"fitid", "<FITID>5F0C6C1E6A9A4F0E9D430F5D8C2A7B11</FITID>"},
+		{"payee escaped", "<NAME>ZTM &lt;Warszawa&gt; &amp; Co</NAME>"},
+		{"memo escaped", "<MEMO>Bilet &#34;normalny&#34;, strefa 1</MEMO>"},
+		{"ledger balance", "<LEDGERBAL><BALAMT>100.51</BALAMT><DTASOF>20240701000000.000[0:GMT]</DTASOF></LEDGERBAL>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestOFXWriterBalanceFromLines(t *testing.T) {
+	out := render(t, FormatOFX, Options{AccountID: "A", Currency: "PLN"}, sampleTransactions())
+	if want := "<BALAMT>100.51</BALAMT>"; !strings.Contains(out, want) {
+		t.Errorf("output is missing %q:\n%s", want, out)
+	}
+}
+
+func TestOFXWriterErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no currency", Options{AccountID: "A"}},
+		{"no account", Options{Currency: "PLN"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWriter(&bytes.Buffer{}, FormatOFX, tt.opts); err == nil {
+				t.Error("NewWriter() error = nil, want an error")
+			}
+		})
+	}
+
+	t.Run("other currency", func(t *testing.T) {
+		writer, err := NewWriter(&bytes.Buffer{}, FormatOFX, Options{AccountID: "A", Currency: "EUR"})
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		if err := writer.Write(sampleTransactions()[0]); err == nil {
+			t.Error("Write() error = nil, want a currency mismatch")
+		}
+	})
+}
+
+func TestQIFWriter(t *testing.T) {
+	pending := *sampleTransactions()[1]
+	pending.Status = "pending"
+	pending.Description = "Bilet\nmiesięczny"
+
+	tests := []struct {
+		name         string
+		transactions []*database.Transaction
+		want         string
+	}{
+		{
+			name: "empty register",
+			want: "!Type:Bank\n",
+		},
+		{
+			name:         "completed deposit",
+			transactions: sampleTransactions()[:1],
+			want: "!Type:Bank\n" +
+				"D" + postedAt.Local().Format("01/02/2006") + "\n" +
+				"T120.50\n" +
+				"N5F0C6C1E6A9A4F0E9D430F5D8C2A7B11\n" +
+				"PDoładowanie kartą\n" +
+				"MDoładowanie kartą\n" +
+				"CX\n" +
+				"^\n",
+		},
+		{
+			name:         "pending payment on one line",
+			transactions: []*database.Transaction{&pending},
+			want: "!Type:Bank\n" +
+				"D" + pending.CreatedAt.Local().Format("01/02/2006") + "\n" +
+				"T-19.99\n" +
+				"N0B7E2F4A1C3D4E5F8A9B0C1D2E3F4A5B\n" +
+				"PZTM <Warszawa> & Co\n" +
+				"MBilet miesięczny\n" +
+				"^\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(t, FormatQIF, Options{}, tt.transactions); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
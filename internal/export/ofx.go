@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+)
+
+// ofxBankID identifies the wallet as the "bank" in OFX statements
+const ofxBankID = "POCKETWALLET"
+
+// ofxAccountIDLength is the longest ACCTID OFX allows
+const ofxAccountIDLength = 22
+
+// FITID derives the OFX financial institution transaction ID from a
+// transaction ID. It never changes for a transaction, so finance tools
+// recognise entries they already imported.
+func FITID(transactionID string) string {
+	return strings.ToUpper(strings.ReplaceAll(transactionID, "-", ""))
+}
+
+// AccountID derives the account number statements show for a user. It
+// fits OFX's 22 character ACCTID, so every statement format shows the same
+// number and finance tools keep matching imports to one account.
+func AccountID(userID string) string {
+	id := strings.ToUpper(strings.ReplaceAll(userID, "-", ""))
+	if len(id) > ofxAccountIDLength {
+		id = id[:ofxAccountIDLength]
+	}
+	return id
+}
+
+// SignedAmount returns the transaction amount in minor units, negative for debits
+func SignedAmount(t *database.Transaction) int64 {
+	amount := currency.ToMinor(t.Amount, t.Currency)
+	if !database.IsCredit(t.Type) {
+		amount = -amount
+	}
+	return amount
+}
+
+// ofxWriter buffers statement lines because the statement header needs
+// the covered date range before the transaction list
+type ofxWriter struct {
+	w     io.Writer
+	opts  Options
+	lines []*database.Transaction
+}
+
+func newOFXWriter(w io.Writer, opts Options) (*ofxWriter, error) {
+	if opts.Currency == "" {
+		return nil, fmt.Errorf("OFX export requires a currency")
+	}
+	if opts.AccountID == "" {
+		return nil, fmt.Errorf("OFX export requires an account ID")
+	}
+	return &ofxWriter{w: w, opts: opts}, nil
+}
+
+func (o *ofxWriter) Write(t *database.Transaction) error {
+	if t.Currency != o.opts.Currency {
+		return fmt.Errorf("OFX statement is in %s but transaction %s is in %s", o.opts.Currency, t.TransactionID, t.Currency)
+	}
+	o.lines = append(o.lines, t)
+	return nil
+}
+
+func (o *ofxWriter) Close() error {
+	now := time.Now()
+	start, end := o.opts.From, o.opts.To
+	if end.IsZero() {
+		end = now
+	}
+	if start.IsZero() {
+		start = end
+		if len(o.lines) > 0 {
+			start = o.lines[0].CreatedAt
+		}
+	}
+
+	var balance int64
+	if o.opts.LedgerBalance != nil {
+		balance = *o.opts.LedgerBalance
+	} else {
+		for _, t := range o.lines {
+			balance += SignedAmount(t)
+		}
+	}
+
+	b := bufio.NewWriter(o.w)
+	fmt.Fprint(b, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`+"\n")
+	fmt.Fprint(b, `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n")
+	fmt.Fprint(b, "<OFX>\n")
+	fmt.Fprint(b, "<SIGNONMSGSRSV1><SONRS>\n")
+	fmt.Fprint(b, "<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprintf(b, "<DTSERVER>%s</DTSERVER>\n", ofxTime(now))
+	fmt.Fprint(b, "<LANGUAGE>POL</LANGUAGE>\n")
+	fmt.Fprint(b, "</SONRS></SIGNONMSGSRSV1>\n")
+	fmt.Fprint(b, "<BANKMSGSRSV1><STMTTRNRS>\n")
+	fmt.Fprint(b, "<TRNUID>0</TRNUID>\n")
+	fmt.Fprint(b, "<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprint(b, "<STMTRS>\n")
+	fmt.Fprintf(b, "<CURDEF>%s</CURDEF>\n", o.opts.Currency)
+	fmt.Fprintf(b, "<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n",
+		ofxBankID, ofxText(o.opts.AccountID, ofxAccountIDLength))
+	fmt.Fprint(b, "<BANKTRANLIST>\n")
+	fmt.Fprintf(b, "<DTSTART>%s</DTSTART>\n", ofxTime(start))
+	fmt.Fprintf(b, "<DTEND>%s</DTEND>\n", ofxTime(end))
+	for _, t := range o.lines {
+		fmt.Fprint(b, "<STMTTRN>\n")
+		fmt.Fprintf(b, "<TRNTYPE>%s</TRNTYPE>\n", ofxTransactionType(t))
+		fmt.Fprintf(b, "<DTPOSTED>%s</DTPOSTED>\n", ofxTime(t.CreatedAt))
+		fmt.Fprintf(b, "<TRNAMT>%s</TRNAMT>\n", currency.FormatDecimal(SignedAmount(t), t.Currency))
+		fmt.Fprintf(b, "<FITID>%s</FITID>\n", FITID(t.TransactionID))
+		fmt.Fprintf(b, "<NAME>%s</NAME>\n", ofxText(payee(t), 32))
+		if t.Description != "" {
+			fmt.Fprintf(b, "<MEMO>%s</MEMO>\n", ofxText(t.Description, 255))
+		}
+		fmt.Fprint(b, "</STMTTRN>\n")
+	}
+	fmt.Fprint(b, "</BANKTRANLIST>\n")
+	fmt.Fprintf(b, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n",
+		currency.FormatDecimal(balance, o.opts.Currency), ofxTime(end))
+	fmt.Fprint(b, "</STMTRS>\n")
+	fmt.Fprint(b, "</STMTTRNRS></BANKMSGSRSV1>\n")
+	fmt.Fprint(b, "</OFX>\n")
+
+	return b.Flush()
+}
+
+func ofxTransactionType(t *database.Transaction) string {
+	switch t.Type {
+	case "deposit":
+		return "DEP"
+	case "payment":
+		return "PAYMENT"
+	}
+	if database.IsCredit(t.Type) {
+		return "CREDIT"
+	}
+	return "DEBIT"
+}
+
+// ofxTime formats a timestamp as YYYYMMDDHHMMSS.XXX[0:GMT]
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxText escapes text for XML and trims it to the element's maximum length
+func ofxText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) > max {
+		runes = runes[:max]
+	}
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(string(runes)))
+	return b.String()
+}
+
+// payee picks the best short name for a transaction
+func payee(t *database.Transaction) string {
+	if t.Counterparty != "" {
+		return t.Counterparty
+	}
+	if t.Description != "" {
+		return t.Description
+	}
+	return t.Type
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+)
+
+// qifWriter renders a "!Type:Bank" QIF register. QIF has no transaction
+// ID field, so the FITID goes into the check number (N) line where
+// GnuCash and HomeBank use it to spot duplicates.
+type qifWriter struct {
+	w             *bufio.Writer
+	headerWritten bool
+}
+
+func newQIFWriter(w io.Writer) *qifWriter {
+	return &qifWriter{w: bufio.NewWriter(w)}
+}
+
+func (q *qifWriter) Write(t *database.Transaction) error {
+	if !q.headerWritten {
+		fmt.Fprint(q.w, "!Type:Bank\n")
+		q.headerWritten = true
+	}
+
+	fmt.Fprintf(q.w, "D%s\n", t.CreatedAt.Local().Format("01/02/2006"))
+	fmt.Fprintf(q.w, "T%s\n", currency.FormatDecimal(SignedAmount(t), t.Currency))
+	fmt.Fprintf(q.w, "N%s\n", FITID(t.TransactionID))
+	fmt.Fprintf(q.w, "P%s\n", qifText(payee(t)))
+	if t.Description != "" {
+		fmt.Fprintf(q.w, "M%s\n", qifText(t.Description))
+	}
+	if t.Status == "completed" {
+		fmt.Fprint(q.w, "CX\n")
+	}
+	fmt.Fprint(q.w, "^\n")
+
+	return nil
+}
+
+func (q *qifWriter) Close() error {
+	if !q.headerWritten {
+		fmt.Fprint(q.w, "!Type:Bank\n")
+	}
+	return q.w.Flush()
+}
+
+// qifText keeps a value on a single line, as QIF is line oriented
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
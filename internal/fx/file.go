@@ -0,0 +1,276 @@
+package fx
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// FileSource imports a daily rate file published by a central bank.
+// Supported formats are NBP table A XML (both the API and the archive
+// layout), ECB eurofxref XML and CSV with "currency,rate[,date]" columns.
+// The file is re-read whenever it changes on disk, so a daily download
+// into the same path is picked up without a restart.
+type FileSource struct {
+	path string
+	base string // Base currency for CSV files, XML formats carry their own
+
+	mu      sync.Mutex
+	table   *RateTable
+	modTime time.Time
+}
+
+// NewFileSource creates a source reading from path; base applies to CSV files
+func NewFileSource(path, base string) *FileSource {
+	return &FileSource{
+		path: path,
+		base: base,
+	}
+}
+
+func (s *FileSource) Name() string {
+	return "file:" + filepath.Base(s.path)
+}
+
+func (s *FileSource) Rates(ctx context.Context) (*RateTable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat rate file: %w", err)
+	}
+
+	if s.table != nil && info.ModTime().Equal(s.modTime) {
+		return s.table, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate file: %w", err)
+	}
+
+	table, err := ParseRates(data, s.base)
+	if err != nil {
+		return nil, err
+	}
+
+	s.table = table
+	s.modTime = info.ModTime()
+	return table, nil
+}
+
+// ParseRates detects the format of a rate file and parses it
+func ParseRates(data []byte, csvBase string) (*RateTable, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return parseCSV(trimmed, csvBase)
+	}
+
+	switch {
+	case bytes.Contains(trimmed, []byte("<ArrayOfExchangeRatesTable")), bytes.Contains(trimmed, []byte("<ExchangeRatesTable")):
+		return parseNBPAPI(trimmed)
+	case bytes.Contains(trimmed, []byte("<tabela_kursow")):
+		return parseNBPArchive(trimmed)
+	case bytes.Contains(trimmed, []byte("eurofxref")), bytes.Contains(trimmed, []byte("<gesmes:Envelope")):
+		return parseECB(trimmed)
+	}
+	return nil, fmt.Errorf("unrecognized rate file format")
+}
+
+type nbpAPITable struct {
+	EffectiveDate string `xml:"EffectiveDate"`
+	Rates         []struct {
+		Code string `xml:"Code"`
+		Mid  string `xml:"Mid"`
+	} `xml:"Rates>Rate"`
+}
+
+// parseNBPAPI reads the api.nbp.pl table A XML; rates are PLN per unit
+func parseNBPAPI(data []byte) (*RateTable, error) {
+	var doc struct {
+		Tables []nbpAPITable `xml:"ExchangeRatesTable"`
+	}
+	if bytes.Contains(data, []byte("<ArrayOfExchangeRatesTable")) {
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid NBP XML: %w", err)
+		}
+	} else {
+		var table nbpAPITable
+		if err := xml.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("invalid NBP XML: %w", err)
+		}
+		doc.Tables = append(doc.Tables, table)
+	}
+	if len(doc.Tables) == 0 {
+		return nil, fmt.Errorf("NBP XML contains no rate table")
+	}
+
+	// The API returns tables oldest first; use the latest one
+	latest := doc.Tables[len(doc.Tables)-1]
+	table := &RateTable{Base: "PLN", Source: "NBP", Rates: make(map[string]float64)}
+	if date, err := time.Parse("2006-01-02", latest.EffectiveDate); err == nil {
+		table.Date = date
+	}
+	for _, rate := range latest.Rates {
+		value, err := parseDecimal(rate.Mid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NBP rate for %s: %w", rate.Code, err)
+		}
+		table.Rates[strings.ToUpper(rate.Code)] = value
+	}
+	return table, nil
+}
+
+// parseNBPArchive reads the nbp.pl/kursy/xml archive layout, which uses
+// decimal commas and quotes some currencies per 100 units
+func parseNBPArchive(data []byte) (*RateTable, error) {
+	var doc struct {
+		Date      string `xml:"data_publikacji"`
+		Positions []struct {
+			Code       string `xml:"kod_waluty"`
+			Multiplier string `xml:"przelicznik"`
+			Mid        string `xml:"kurs_sredni"`
+		} `xml:"pozycja"`
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid NBP XML: %w", err)
+	}
+
+	table := &RateTable{Base: "PLN", Source: "NBP", Rates: make(map[string]float64)}
+	if date, err := time.Parse("2006-01-02", doc.Date); err == nil {
+		table.Date = date
+	}
+	for _, pos := range doc.Positions {
+		value, err := parseDecimal(pos.Mid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NBP rate for %s: %w", pos.Code, err)
+		}
+		multiplier := 1.0
+		if pos.Multiplier != "" {
+			multiplier, err = parseDecimal(pos.Multiplier)
+			if err != nil || multiplier <= 0 {
+				return nil, fmt.Errorf("invalid NBP multiplier for %s", pos.Code)
+			}
+		}
+		table.Rates[strings.ToUpper(pos.Code)] = value / multiplier
+	}
+	return table, nil
+}
+
+// parseECB reads eurofxref-daily.xml; rates are units per EUR and are
+// inverted so the table stays "base per unit"
+func parseECB(data []byte) (*RateTable, error) {
+	var doc struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube>Cube"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid ECB XML: %w", err)
+	}
+	if len(doc.Days) == 0 {
+		return nil, fmt.Errorf("ECB XML contains no rates")
+	}
+
+	// Historical files list the newest day first
+	day := doc.Days[0]
+	table := &RateTable{Base: "EUR", Source: "ECB", Rates: make(map[string]float64)}
+	if date, err := time.Parse("2006-01-02", day.Time); err == nil {
+		table.Date = date
+	}
+	for _, rate := range day.Rates {
+		value, err := parseDecimal(rate.Rate)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid ECB rate for %s", rate.Currency)
+		}
+		table.Rates[strings.ToUpper(rate.Currency)] = 1 / value
+	}
+	return table, nil
+}
+
+// parseCSV reads "currency,rate[,date]" rows with a header line; the
+// delimiter may be a comma or a semicolon and rates are base per unit
+func parseCSV(data []byte, base string) (*RateTable, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate CSV: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	codeCol, hasCode := columns["currency"]
+	rateCol, hasRate := columns["rate"]
+	if !hasCode || !hasRate {
+		return nil, fmt.Errorf("rate CSV must have currency and rate columns")
+	}
+	dateCol, hasDate := columns["date"]
+
+	table := &RateTable{Base: base, Source: "CSV", Rates: make(map[string]float64)}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate CSV line %d: %w", line, err)
+		}
+		value, err := parseDecimal(record[rateCol])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate on line %d: %w", line, err)
+		}
+		table.Rates[strings.ToUpper(strings.TrimSpace(record[codeCol]))] = value
+		if hasDate && dateCol < len(record) {
+			if date, err := time.Parse("2006-01-02", strings.TrimSpace(record[dateCol])); err == nil && date.After(table.Date) {
+				table.Date = date
+			}
+		}
+	}
+	if len(table.Rates) == 0 {
+		return nil, fmt.Errorf("rate CSV contains no rates")
+	}
+	return table, nil
+}
+
+// charsetReader decodes the Central European encodings NBP archive files are published in
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "iso-8859-2", "iso8859-2", "latin2":
+		return charmap.ISO8859_2.NewDecoder().Reader(input), nil
+	case "windows-1250", "cp1250":
+		return charmap.Windows1250.NewDecoder().Reader(input), nil
+	case "utf-8", "utf8":
+		return input, nil
+	}
+	return nil, fmt.Errorf("unsupported charset: %s", label)
+}
+
+// parseDecimal accepts both "4.3012" and the Polish "4,3012"
+func parseDecimal(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
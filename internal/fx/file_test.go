@@ -0,0 +1,300 @@
+package fx
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// encode converts UTF-8 test data to the charset an NBP archive file
+// declares
+func encode(t *testing.T, cm *charmap.Charmap, s string) string {
+	t.Helper()
+	out, err := cm.NewEncoder().String(s)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return out
+}
+
+const nbpAPITables = `<?xml version="1.0" encoding="utf-8"?>
+<ArrayOfExchangeRatesTable xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <ExchangeRatesTable>
+    <Table>A</Table>
+    <No>110/A/NBP/2024</No>
+    <EffectiveDate>2024-06-07</EffectiveDate>
+    <Rates>
+      <Rate><Currency>euro</Currency><Code>EUR</Code><Mid>4.2900</Mid></Rate>
+    </Rates>
+  </ExchangeRatesTable>
+  <ExchangeRatesTable>
+    <Table>A</Table>
+    <No>111/A/NBP/2024</No>
+    <EffectiveDate>2024-06-10</EffectiveDate>
+    <Rates>
+      <Rate><Currency>euro</Currency><Code>EUR</Code><Mid>4.3012</Mid></Rate>
+      <Rate><Currency>dolar amerykański</Currency><Code>usd</Code><Mid>3.9950</Mid></Rate>
+      <Rate><Currency>jen (Japonia)</Currency><Code>JPY</Code><Mid>0.025441</Mid></Rate>
+    </Rates>
+  </ExchangeRatesTable>
+</ArrayOfExchangeRatesTable>`
+
+const nbpAPISingle = `<ExchangeRatesTable>
+  <Table>A</Table>
+  <EffectiveDate>2024-06-10</EffectiveDate>
+  <Rates>
+    <Rate><Code>CHF</Code><Mid>4.4513</Mid></Rate>
+  </Rates>
+</ExchangeRatesTable>`
+
+// nbpArchive returns an archive table in the given charset, with decimal
+// commas and HUF and JPY quoted per 100 units
+func nbpArchive(charset string) string {
+	return `<?xml version="1.0" encoding="` + charset + `"?>
+<tabela_kursow typ="A" uid="24a111">
+  <numer_tabeli>111/A/NBP/2024</numer_tabeli>
+  <data_publikacji>2024-06-10</data_publikacji>
+  <pozycja>
+    <nazwa_waluty>euro</nazwa_waluty>
+    <przelicznik>1</przelicznik>
+    <kod_waluty>EUR</kod_waluty>
+    <kurs_sredni>4,3012</kurs_sredni>
+  </pozycja>
+  <pozycja>
+    <nazwa_waluty>forint (Węgry)</nazwa_waluty>
+    <przelicznik>100</przelicznik>
+    <kod_waluty>HUF</kod_waluty>
+    <kurs_sredni>1,0860</kurs_sredni>
+  </pozycja>
+  <pozycja>
+    <nazwa_waluty>jen (Japonia)</nazwa_waluty>
+    <przelicznik>100</przelicznik>
+    <kod_waluty>JPY</kod_waluty>
+    <kurs_sredni>2,5441</kurs_sredni>
+  </pozycja>
+  <pozycja>
+    <nazwa_waluty>frank szwajcarski</nazwa_waluty>
+    <kod_waluty>CHF</kod_waluty>
+    <kurs_sredni>4,4513</kurs_sredni>
+  </pozycja>
+</tabela_kursow>`
+}
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+  <gesmes:subject>Reference rates</gesmes:subject>
+  <gesmes:Sender><gesmes:name>European Central Bank</gesmes:name></gesmes:Sender>
+  <Cube>
+    <Cube time="2024-06-10">
+      <Cube currency="USD" rate="1.0765"/>
+      <Cube currency="PLN" rate="4.3515"/>
+    </Cube>
+    <Cube time="2024-06-07">
+      <Cube currency="USD" rate="1.0900"/>
+      <Cube currency="PLN" rate="4.3000"/>
+    </Cube>
+  </Cube>
+</gesmes:Envelope>`
+
+func TestParseRates(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		csvBase    string
+		wantBase   string
+		wantSource string
+		wantDate   time.Time
+		wantRates  map[string]float64
+	}{
+		{
+			name:       "NBP API uses the latest table",
+			data:       nbpAPITables,
+			wantBase:   "PLN",
+			wantSource: "NBP",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"EUR": 4.3012, "USD": 3.995, "JPY": 0.025441},
+		},
+		{
+			name:       "NBP API single table",
+			data:       nbpAPISingle,
+			wantBase:   "PLN",
+			wantSource: "NBP",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"CHF": 4.4513},
+		},
+		{
+			name:       "NBP archive in ISO-8859-2 divides by przelicznik",
+			data:       encode(t, charmap.ISO8859_2, nbpArchive("ISO-8859-2")),
+			wantBase:   "PLN",
+			wantSource: "NBP",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"EUR": 4.3012, "HUF": 0.01086, "JPY": 0.025441, "CHF": 4.4513},
+		},
+		{
+			name:       "NBP archive in windows-1250",
+			data:       encode(t, charmap.Windows1250, nbpArchive("windows-1250")),
+			wantBase:   "PLN",
+			wantSource: "NBP",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"EUR": 4.3012, "HUF": 0.01086, "JPY": 0.025441, "CHF": 4.4513},
+		},
+		{
+			name:       "NBP archive in UTF-8",
+			data:       nbpArchive("UTF-8"),
+			wantBase:   "PLN",
+			wantSource: "NBP",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"EUR": 4.3012, "HUF": 0.01086, "JPY": 0.025441, "CHF": 4.4513},
+		},
+		{
+			name:       "ECB inverts to EUR per unit and uses the newest day",
+			data:       ecbDaily,
+			wantBase:   "EUR",
+			wantSource: "ECB",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"USD": 1 / 1.0765, "PLN": 1 / 4.3515},
+		},
+		{
+			name:       "CSV with comma and date column",
+			data:       "currency,rate,date\nEUR,4.3012,2024-06-07\nusd, 3.995,2024-06-10\n",
+			csvBase:    "PLN",
+			wantBase:   "PLN",
+			wantSource: "CSV",
+			wantDate:   date(2024, 6, 10),
+			wantRates:  map[string]float64{"EUR": 4.3012, "USD": 3.995},
+		},
+		{
+			name:       "CSV with semicolons and decimal commas",
+			data:       "\n  Rate;Currency\n4,3012;EUR\n3,995;USD\n",
+			csvBase:    "PLN",
+			wantBase:   "PLN",
+			wantSource: "CSV",
+			wantRates:  map[string]float64{"EUR": 4.3012, "USD": 3.995},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := ParseRates([]byte(tt.data), tt.csvBase)
+			if err != nil {
+				t.Fatalf("ParseRates() error = %v", err)
+			}
+			if table.Base != tt.wantBase {
+				t.Errorf("Base = %q, want %q", table.Base, tt.wantBase)
+			}
+			if table.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", table.Source, tt.wantSource)
+			}
+			if !table.Date.Equal(tt.wantDate) {
+				t.Errorf("Date = %v, want %v", table.Date, tt.wantDate)
+			}
+			if len(table.Rates) != len(tt.wantRates) {
+				t.Errorf("Rates = %v, want %v", table.Rates, tt.wantRates)
+			}
+			for code, want := range tt.wantRates {
+				if got, ok := table.Rates[code]; !ok || math.Abs(got-want) > 1e-12 {
+					t.Errorf("Rates[%s] = %v, want %v", code, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRatesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"unknown XML", `<rates><rate/></rates>`, "unrecognized rate file format"},
+		{"NBP API broken XML", `<ExchangeRatesTable><Rates>`, "invalid NBP XML"},
+		{"NBP API without tables", `<ArrayOfExchangeRatesTable></ArrayOfExchangeRatesTable>`, "no rate table"},
+		{"NBP API bad rate", `<ExchangeRatesTable><Rates><Rate><Code>EUR</Code><Mid>n/a</Mid></Rate></Rates></ExchangeRatesTable>`, "invalid NBP rate for EUR"},
+		{
+			"NBP archive zero multiplier",
+			`<tabela_kursow><pozycja><przelicznik>0</przelicznik><kod_waluty>HUF</kod_waluty><kurs_sredni>1,08</kurs_sredni></pozycja></tabela_kursow>`,
+			"invalid NBP multiplier for HUF",
+		},
+		{
+			"NBP archive bad rate",
+			`<tabela_kursow><pozycja><kod_waluty>EUR</kod_waluty><kurs_sredni>4.30.12</kurs_sredni></pozycja></tabela_kursow>`,
+			"invalid NBP rate for EUR",
+		},
+		{
+			"NBP archive unsupported charset",
+			`<?xml version="1.0" encoding="KOI8-R"?><tabela_kursow></tabela_kursow>`,
+			"unsupported charset",
+		},
+		{"ECB without rates", `<gesmes:Envelope xmlns:gesmes="x"><Cube></Cube></gesmes:Envelope>`, "ECB XML contains no rates"},
+		{
+			"ECB zero rate",
+			`<gesmes:Envelope xmlns:gesmes="x"><Cube><Cube time="2024-06-10"><Cube currency="USD" rate="0"/></Cube></Cube></gesmes:Envelope>`,
+			"invalid ECB rate for USD",
+		},
+		{"empty file", "", "invalid rate CSV"},
+		{"CSV without rate column", "currency,value\nEUR,4.30\n", "must have currency and rate columns"},
+		{"CSV bad rate", "currency,rate\nEUR,4.30\nUSD,four\n", "invalid rate on line 3"},
+		{"CSV short row", "currency,rate\nEUR\n", "invalid rate CSV line 2"},
+		{"CSV header only", "currency,rate\n", "contains no rates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRates([]byte(tt.data), "PLN")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRates() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSourceRereadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	write := func(data string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := NewFileSource(path, "PLN")
+	if source.Name() != "file:rates.csv" {
+		t.Errorf("Name() = %q", source.Name())
+	}
+
+	write("currency,rate\nEUR,4.30\n", time.Now().Add(-time.Hour))
+	table, err := source.Rates(context.Background())
+	if err != nil {
+		t.Fatalf("Rates() error = %v", err)
+	}
+	if table.Rates["EUR"] != 4.30 {
+		t.Errorf("EUR = %v, want 4.30", table.Rates["EUR"])
+	}
+
+	write("currency,rate\nEUR,4.40\n", time.Now())
+	table, err = source.Rates(context.Background())
+	if err != nil {
+		t.Fatalf("Rates() error = %v", err)
+	}
+	if table.Rates["EUR"] != 4.40 {
+		t.Errorf("EUR after the file changed = %v, want 4.40", table.Rates["EUR"])
+	}
+
+	os.Remove(path)
+	if _, err := source.Rates(context.Background()); err == nil {
+		t.Error("Rates() of a removed file succeeded")
+	}
+}
@@ -0,0 +1,129 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/errcode"
+)
+
+// RateTable holds the value of one unit of each currency expressed in Base,
+// e.g. with Base "PLN" a rate of 4.30 for "EUR" means 1 EUR = 4.30 PLN
+type RateTable struct {
+	Base   string
+	Date   time.Time
+	Source string
+	Rates  map[string]float64
+}
+
+// RateSource provides the current table of exchange rates
+type RateSource interface {
+	Name() string
+	Rates(ctx context.Context) (*RateTable, error)
+}
+
+// Rate returns how many units of `to` one unit of `from` buys at mid-market
+func (t *RateTable) Rate(from, to string) (float64, error) {
+	fromRate, err := t.value(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := t.value(to)
+	if err != nil {
+		return 0, err
+	}
+	return fromRate / toRate, nil
+}
+
+func (t *RateTable) value(code string) (float64, error) {
+	if code == t.Base {
+		return 1, nil
+	}
+	rate, ok := t.Rates[code]
+	if !ok || rate <= 0 {
+		return 0, errcode.New(errcode.InvalidInput, fmt.Sprintf("no %s rate for %s in %s table", t.Base, code, t.Source))
+	}
+	return rate, nil
+}
+
+// Quote is a priced, time-limited offer to convert between two currencies
+type Quote struct {
+	FromCurrency string
+	ToCurrency   string
+	SellAmount   int64   // Debited from FromCurrency, in minor units
+	BuyAmount    int64   // Credited to ToCurrency, in minor units
+	MidRate      float64 // Mid-market rate from the source
+	Rate         float64 // Rate applied after the spread
+	SpreadBps    int64
+	Source       string
+	RateDate     time.Time
+	ExpiresAt    time.Time
+}
+
+// Exchanger prices conversions from a rate source with a configured spread
+type Exchanger struct {
+	source    RateSource
+	spreadBps int64
+	quoteTTL  time.Duration
+}
+
+// NewExchanger creates an exchanger; spreadBps is charged on every conversion
+func NewExchanger(source RateSource, spreadBps int64, quoteTTL time.Duration) *Exchanger {
+	return &Exchanger{
+		source:    source,
+		spreadBps: spreadBps,
+		quoteTTL:  quoteTTL,
+	}
+}
+
+// SourceName returns the name of the underlying rate source
+func (e *Exchanger) SourceName() string {
+	return e.source.Name()
+}
+
+// Quote prices selling `amount` minor units of `from` for `to`
+func (e *Exchanger) Quote(ctx context.Context, from, to string, amount int64) (*Quote, error) {
+	if amount <= 0 {
+		return nil, errcode.New(errcode.InvalidInput, "amount must be positive")
+	}
+	if from == to {
+		return nil, errcode.New(errcode.InvalidInput, fmt.Sprintf("cannot exchange %s to itself", from))
+	}
+
+	table, err := e.source.Rates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+
+	mid, err := table.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := mid * (1 - float64(e.spreadBps)/10000)
+	buy := currency.ToMinor(currency.FromMinor(amount, from)*rate, to)
+	if buy <= 0 {
+		return nil, errcode.New(errcode.InvalidInput, "amount too small to exchange")
+	}
+
+	return &Quote{
+		FromCurrency: from,
+		ToCurrency:   to,
+		SellAmount:   amount,
+		BuyAmount:    buy,
+		MidRate:      roundRate(mid),
+		Rate:         roundRate(rate),
+		SpreadBps:    e.spreadBps,
+		Source:       table.Source,
+		RateDate:     table.Date,
+		ExpiresAt:    time.Now().Add(e.quoteTTL),
+	}, nil
+}
+
+// roundRate keeps rates at a precision banks publish, which makes them stable to audit
+func roundRate(rate float64) float64 {
+	return math.Round(rate*1e8) / 1e8
+}
@@ -0,0 +1,146 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+)
+
+func TestRateTableRate(t *testing.T) {
+	table := &RateTable{
+		Base:   "PLN",
+		Source: "NBP",
+		Rates:  map[string]float64{"EUR": 4.30, "USD": 4.00, "JPY": 0.0271, "CHF": 0},
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     float64
+	}{
+		{"into base", "EUR", "PLN", 4.30},
+		{"from base", "PLN", "EUR", 1 / 4.30},
+		{"cross rate", "EUR", "USD", 4.30 / 4.00},
+		{"zero minor units", "USD", "JPY", 4.00 / 0.0271},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.Rate(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Rate(%s, %s) error = %v", tt.from, tt.to, err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Rate(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+
+	for _, pair := range [][2]string{{"GBP", "PLN"}, {"PLN", "GBP"}, {"CHF", "EUR"}} {
+		if _, err := table.Rate(pair[0], pair[1]); errcode.Of(err) != errcode.InvalidInput {
+			t.Errorf("Rate(%s, %s) error = %v, want INVALID_INPUT", pair[0], pair[1], err)
+		}
+	}
+}
+
+// failingSource stands in for a rate file that cannot be read
+type failingSource struct{}
+
+func (failingSource) Name() string { return "failing" }
+
+func (failingSource) Rates(ctx context.Context) (*RateTable, error) {
+	return nil, errors.New("rate file missing")
+}
+
+func TestExchangerQuote(t *testing.T) {
+	source := NewStaticSource("PLN", map[string]float64{"EUR": 4.30, "JPY": 0.0271, "HUF": 0.0108})
+	exchanger := NewExchanger(source, 50, 30*time.Second)
+
+	tests := []struct {
+		name     string
+		from, to string
+		amount   int64
+		wantBuy  int64
+		wantMid  float64
+		wantRate float64
+	}{
+		// 100.00 EUR at 4.30 less 0.5%
+		{"into base", "EUR", "PLN", 10000, 42785, 4.3, 4.2785},
+		// 10.00 PLN into yen, which have no minor units
+		{"into zero minor units", "PLN", "JPY", 1000, 367, 36.900369, 36.71586716},
+		// 500 JPY
+		{"from zero minor units", "JPY", "PLN", 500, 1348, 0.0271, 0.02696450},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			quote, err := exchanger.Quote(context.Background(), tt.from, tt.to, tt.amount)
+			if err != nil {
+				t.Fatalf("Quote() error = %v", err)
+			}
+			if quote.SellAmount != tt.amount || quote.BuyAmount != tt.wantBuy {
+				t.Errorf("sell %d buy %d, want sell %d buy %d", quote.SellAmount, quote.BuyAmount, tt.amount, tt.wantBuy)
+			}
+			if quote.MidRate != tt.wantMid || quote.Rate != tt.wantRate {
+				t.Errorf("mid %v rate %v, want mid %v rate %v", quote.MidRate, quote.Rate, tt.wantMid, tt.wantRate)
+			}
+			if quote.SpreadBps != 50 || quote.Source != "static" {
+				t.Errorf("spread %d source %q, want 50 and static", quote.SpreadBps, quote.Source)
+			}
+			if quote.ExpiresAt.Before(before.Add(30*time.Second)) || quote.ExpiresAt.After(time.Now().Add(30*time.Second)) {
+				t.Errorf("ExpiresAt = %v, want 30s from now", quote.ExpiresAt)
+			}
+		})
+	}
+
+	rejected := []struct {
+		name     string
+		from, to string
+		amount   int64
+	}{
+		{"zero amount", "EUR", "PLN", 0},
+		{"negative amount", "EUR", "PLN", -100},
+		{"same currency", "EUR", "EUR", 100},
+		{"no rate", "GBP", "PLN", 100},
+		// 0.01 HUF is worth a hundredth of a grosz
+		{"too small", "HUF", "PLN", 1},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := exchanger.Quote(context.Background(), tt.from, tt.to, tt.amount)
+			if errcode.Of(err) != errcode.InvalidInput {
+				t.Errorf("Quote() error = %v, want INVALID_INPUT", err)
+			}
+		})
+	}
+
+	failing := NewExchanger(failingSource{}, 50, time.Minute)
+	if _, err := failing.Quote(context.Background(), "EUR", "PLN", 100); err == nil {
+		t.Error("Quote() with an unreadable source succeeded")
+	}
+}
+
+func TestParseStaticRates(t *testing.T) {
+	rates, err := ParseStaticRates(" EUR=4.30, usd = 4.00 ,,GBP=5.05")
+	if err != nil {
+		t.Fatalf("ParseStaticRates() error = %v", err)
+	}
+	want := map[string]float64{"EUR": 4.30, "USD": 4.00, "GBP": 5.05}
+	if len(rates) != len(want) {
+		t.Errorf("rates = %v, want %v", rates, want)
+	}
+	for code, rate := range want {
+		if rates[code] != rate {
+			t.Errorf("rates[%s] = %v, want %v", code, rates[code], rate)
+		}
+	}
+
+	for _, spec := range []string{"EUR", "EUR=four", "EUR=4.30,USD"} {
+		if _, err := ParseStaticRates(spec); err == nil {
+			t.Errorf("ParseStaticRates(%q) succeeded", spec)
+		}
+	}
+}
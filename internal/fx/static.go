@@ -0,0 +1,54 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StaticSource serves a fixed rate table, useful offline and in development
+type StaticSource struct {
+	table *RateTable
+}
+
+// NewStaticSource creates a source from rates expressed in base
+func NewStaticSource(base string, rates map[string]float64) *StaticSource {
+	return &StaticSource{
+		table: &RateTable{
+			Base:   base,
+			Date:   time.Now().UTC().Truncate(24 * time.Hour),
+			Source: "static",
+			Rates:  rates,
+		},
+	}
+}
+
+// ParseStaticRates parses a "EUR=4.30,USD=4.00" list into a rate map
+func ParseStaticRates(spec string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate %q, expected CODE=RATE", pair)
+		}
+		rate, err := parseDecimal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate for %s: %w", code, err)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	return rates, nil
+}
+
+func (s *StaticSource) Name() string {
+	return "static"
+}
+
+func (s *StaticSource) Rates(ctx context.Context) (*RateTable, error) {
+	return s.table, nil
+}
@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status of a component or of the whole application
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded" // Working, with a problem worth a look
+	StatusDown     Status = "down"
+)
+
+// Result is what a check reports about its component
+type Result struct {
+	Status  Status
+	Error   string
+	Details map[string]any
+}
+
+// Check probes one component. Only critical checks can take the whole
+// application down; others at worst degrade it.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) Result
+}
+
+// Component is the outcome of one check
+type Component struct {
+	Name      string         `json:"name"`
+	Status    Status         `json:"status"`
+	Critical  bool           `json:"critical"`
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Report is the combined outcome of all checks
+type Report struct {
+	Status     Status      `json:"status"`
+	Components []Component `json:"components"`
+	CheckedAt  time.Time   `json:"checked_at"`
+}
+
+// Checker runs a set of checks concurrently, each under a deadline
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker creates a checker that gives every check at most timeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run executes every check and combines the results
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		Status:     StatusOK,
+		Components: make([]Component, len(c.checks)),
+		CheckedAt:  time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Components[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		switch {
+		case component.Status == StatusDown && component.Critical:
+			report.Status = StatusDown
+		case component.Status != StatusOK && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// run executes one check; a check that overruns its deadline is down
+func (c *Checker) run(ctx context.Context, check Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- Result{Status: StatusDown, Error: "check panicked"}
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Status: StatusDown, Error: "check timed out after " + c.timeout.String()}
+	}
+
+	return Component{
+		Name:      check.Name,
+		Status:    result.Status,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Error:     result.Error,
+		Details:   result.Details,
+	}
+}
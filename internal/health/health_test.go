@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func returns(status Status) func(ctx context.Context) Result {
+	return func(ctx context.Context) Result { return Result{Status: status} }
+}
+
+func TestCheckerStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{"no checks", nil, StatusOK},
+		{"all ok", []Check{
+			{Name: "mongodb", Critical: true, Run: returns(StatusOK)},
+			{Name: "stripe", Run: returns(StatusOK)},
+		}, StatusOK},
+		{"non-critical down", []Check{
+			{Name: "mongodb", Critical: true, Run: returns(StatusOK)},
+			{Name: "stripe", Run: returns(StatusDown)},
+		}, StatusDegraded},
+		{"critical degraded", []Check{
+			{Name: "mongodb", Critical: true, Run: returns(StatusDegraded)},
+			{Name: "stripe", Run: returns(StatusOK)},
+		}, StatusDegraded},
+		{"critical down", []Check{
+			{Name: "mongodb", Critical: true, Run: returns(StatusDown)},
+			{Name: "stripe", Run: returns(StatusOK)},
+		}, StatusDown},
+		{"critical down after a degraded one", []Check{
+			{Name: "stripe", Run: returns(StatusDown)},
+			{Name: "mongodb", Critical: true, Run: returns(StatusDown)},
+		}, StatusDown},
+		{"critical down before a degraded one", []Check{
+			{Name: "mongodb", Critical: true, Run: returns(StatusDown)},
+			{Name: "stripe", Run: returns(StatusDegraded)},
+		}, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewChecker(time.Second, tt.checks...).Run(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("Status = %s, want %s", report.Status, tt.want)
+			}
+			if len(report.Components) != len(tt.checks) {
+				t.Fatalf("%d components, want %d", len(report.Components), len(tt.checks))
+			}
+			for i, check := range tt.checks {
+				if c := report.Components[i]; c.Name != check.Name || c.Critical != check.Critical {
+					t.Errorf("component %d = %s critical %v, want %s critical %v", i, c.Name, c.Critical, check.Name, check.Critical)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	checker := NewChecker(20*time.Millisecond,
+		Check{Name: "mongodb", Critical: true, Run: func(ctx context.Context) Result {
+			<-ctx.Done()
+			return Result{Status: StatusOK}
+		}},
+		// Ignores ctx, so only the checker's own deadline ends it
+		Check{Name: "stripe", Run: func(ctx context.Context) Result {
+			<-release
+			return Result{Status: StatusOK}
+		}},
+		Check{Name: "scheduler", Run: returns(StatusOK)},
+	)
+
+	begin := time.Now()
+	report := checker.Run(context.Background())
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Fatalf("Run() took %v, want it bounded by the timeout", elapsed)
+	}
+
+	if report.Status != StatusDown {
+		t.Errorf("Status = %s, want down with a critical check timed out", report.Status)
+	}
+	for _, c := range report.Components[:2] {
+		if c.Status != StatusDown || !strings.Contains(c.Error, "timed out after 20ms") {
+			t.Errorf("%s = %s %q, want down after timing out", c.Name, c.Status, c.Error)
+		}
+	}
+	if c := report.Components[2]; c.Status != StatusOK {
+		t.Errorf("%s = %s, want the fast check unaffected", c.Name, c.Status)
+	}
+}
+
+func TestCheckerPanic(t *testing.T) {
+	checker := NewChecker(time.Second,
+		Check{Name: "stripe", Run: func(ctx context.Context) Result { panic("nil client") }},
+		Check{Name: "mongodb", Critical: true, Run: returns(StatusOK)},
+	)
+
+	report := checker.Run(context.Background())
+	if report.Status != StatusDegraded {
+		t.Errorf("Status = %s, want degraded with a non-critical check panicking", report.Status)
+	}
+	if c := report.Components[0]; c.Status != StatusDown || c.Error != "check panicked" {
+		t.Errorf("%s = %s %q, want down after panicking", c.Name, c.Status, c.Error)
+	}
+	if c := report.Components[1]; c.Status != StatusOK {
+		t.Errorf("%s = %s, want the other check unaffected", c.Name, c.Status)
+	}
+}
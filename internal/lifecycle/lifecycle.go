@@ -0,0 +1,441 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// State of a component
+type State string
+
+const (
+	StatePending   State = "pending"   // Not started yet
+	StateWaiting   State = "waiting"   // A dependency is not running
+	StateRunning   State = "running"   // Started and healthy
+	StateUnhealthy State = "unhealthy" // Started, but its health check fails
+	StateFailed    State = "failed"    // Could not start; retried in the background
+	StateStopped   State = "stopped"
+)
+
+// Component is one subsystem the manager starts and stops. Start brings it
+// up and Health, when set, must pass before components depending on it
+// start. Stop, when set, releases it.
+type Component struct {
+	Name      string
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Health    func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+}
+
+// Status is a snapshot of one component for display
+type Status struct {
+	Name  string    `json:"name"`
+	State State     `json:"state"`
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// Options tune a manager; zero values pick the defaults
+type Options struct {
+	StartTimeout  time.Duration // Per start attempt, health check included; default 10s
+	HealthTimeout time.Duration // Per health check; default 2s
+	CheckInterval time.Duration // Between retries and health checks; default 5s
+
+	// OnChange is called whenever a component changes state
+	OnChange func(Status)
+}
+
+type entry struct {
+	Component
+	status  Status
+	started bool  // Start succeeded, so Stop is due
+	down    error // Reported by Fail during the current start attempt
+}
+
+// Manager starts components in dependency order and stops them in reverse.
+// A component that fails to start does not stop the others: the app runs
+// degraded, the components depending on it wait, and it is retried until
+// it comes up. One that goes down later takes its dependents down with it
+// until it is back.
+type Manager struct {
+	opts    Options
+	entries []*entry // In start order
+	byName  map[string]*entry
+
+	mu          sync.Mutex
+	started     bool
+	stopped     bool
+	stopMonitor context.CancelFunc
+	monitor     sync.WaitGroup
+}
+
+// New creates a manager for components. Dependencies must name other
+// components and must not form a cycle.
+func New(opts Options, components ...Component) (*Manager, error) {
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = 10 * time.Second
+	}
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = 2 * time.Second
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = 5 * time.Second
+	}
+
+	m := &Manager{opts: opts, byName: make(map[string]*entry)}
+	for _, c := range components {
+		if c.Name == "" || c.Start == nil {
+			return nil, errors.New("component needs a name and a start function")
+		}
+		if _, dup := m.byName[c.Name]; dup {
+			return nil, fmt.Errorf("duplicate component %q", c.Name)
+		}
+		m.byName[c.Name] = &entry{Component: c, status: Status{Name: c.Name, State: StatePending, Since: time.Now()}}
+	}
+
+	// Order depth first, keeping the given order among independent ones
+	visiting := make(map[string]bool)
+	visited := make(map[string]bool)
+	var visit func(name string) error
+	visit = func(name string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("dependency cycle through %q", name)
+		}
+		visiting[name] = true
+		e := m.byName[name]
+		for _, dep := range e.DependsOn {
+			if _, ok := m.byName[dep]; !ok {
+				return fmt.Errorf("component %q depends on unknown %q", name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		m.entries = append(m.entries, e)
+		return nil
+	}
+	for _, c := range components {
+		if err := visit(c.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Start brings every component up in dependency order in the background
+// and then keeps watching them: failed ones are retried and running ones
+// health checked. It returns right away, so a component that is slow to
+// fail does not hold up the caller; Status and OnChange report progress.
+func (m *Manager) Start() {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return
+	}
+	m.started = true
+	var ctx context.Context
+	ctx, m.stopMonitor = context.WithCancel(context.Background())
+	m.mu.Unlock()
+
+	m.monitor.Add(1)
+	go m.watch(ctx)
+}
+
+// Stop stops the monitor, then every started component in reverse
+// dependency order, whichever order they came up in. Each gets what
+// remains of ctx; one that fails or overruns does not keep the rest from
+// stopping. The errors are joined.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.started || m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	m.mu.Unlock()
+
+	// A start attempt in progress finishes first, unless it overruns ctx;
+	// what it brings up after that is left to the process exit
+	var errs []error
+	m.stopMonitor()
+	monitorDone := make(chan struct{})
+	go func() {
+		m.monitor.Wait()
+		close(monitorDone)
+	}()
+	select {
+	case <-monitorDone:
+	case <-ctx.Done():
+		slog.Warn("Component start still running at shutdown", "error", ctx.Err())
+		errs = append(errs, fmt.Errorf("start in progress: %w", ctx.Err()))
+	}
+
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		e := m.entries[i]
+		m.mu.Lock()
+		started := e.started
+		m.mu.Unlock()
+		if !started {
+			continue
+		}
+		if err := m.stopOne(ctx, e); err != nil {
+			slog.Warn("Component did not stop cleanly", "component", e.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Fail reports that a started component went down by itself, e.g. a
+// server whose listener broke, having released what it held. It is marked
+// failed and the monitor starts it again, like one that failed to start.
+func (m *Manager) Fail(name string, err error) {
+	m.mu.Lock()
+	e, ok := m.byName[name]
+	if !ok || m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	// A failure while Start is still running fails that attempt
+	e.down = err
+	wasStarted := e.started
+	e.started = false
+	m.mu.Unlock()
+
+	if wasStarted {
+		m.setState(e, StateFailed, err)
+	}
+}
+
+// Running reports whether a component is started and healthy
+func (m *Manager) Running(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.byName[name]
+	return ok && e.status.State == StateRunning
+}
+
+// Status reports every component in start order
+func (m *Manager) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]Status, len(m.entries))
+	for i, e := range m.entries {
+		statuses[i] = e.status
+	}
+	return statuses
+}
+
+func (m *Manager) watch(ctx context.Context) {
+	defer m.monitor.Done()
+
+	m.startPending(ctx)
+
+	ticker := time.NewTicker(m.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkHealth(ctx)
+			m.stopDependents(ctx)
+			m.startPending(ctx)
+		}
+	}
+}
+
+// missingDependency names the first dependency of e that is not running,
+// or returns "" when all are. The caller holds m.mu.
+func (m *Manager) missingDependency(e *entry) string {
+	for _, dep := range e.DependsOn {
+		if m.byName[dep].status.State != StateRunning {
+			return dep
+		}
+	}
+	return ""
+}
+
+// startPending tries every component that is not started and whose
+// dependencies are running
+func (m *Manager) startPending(ctx context.Context) {
+	for _, e := range m.entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.mu.Lock()
+		started := e.started
+		missing := m.missingDependency(e)
+		m.mu.Unlock()
+
+		switch {
+		case started:
+		case missing != "":
+			m.setState(e, StateWaiting, fmt.Errorf("waiting for %s", missing))
+		default:
+			m.startOne(ctx, e)
+		}
+	}
+}
+
+// startOne starts a component and waits for it to become healthy
+func (m *Manager) startOne(ctx context.Context, e *entry) {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.StartTimeout)
+	defer cancel()
+
+	m.mu.Lock()
+	e.down = nil
+	m.mu.Unlock()
+
+	start := time.Now()
+	if err := e.Start(ctx); err != nil {
+		m.setState(e, StateFailed, err)
+		return
+	}
+
+	m.mu.Lock()
+	down := e.down
+	e.started = down == nil
+	m.mu.Unlock()
+	if down != nil {
+		m.setState(e, StateFailed, down)
+		return
+	}
+
+	// Gate dependents on the component actually working
+	for e.Health != nil {
+		err := m.health(ctx, e)
+		if err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			m.setState(e, StateUnhealthy, err)
+			return
+		case <-time.After(m.opts.HealthTimeout / 4):
+		}
+	}
+
+	m.setState(e, StateRunning, nil)
+	slog.Info("Component started", "component", e.Name, "duration_ms", time.Since(start).Milliseconds())
+}
+
+// checkHealth moves started components between running and unhealthy
+func (m *Manager) checkHealth(ctx context.Context) {
+	for _, e := range m.entries {
+		m.mu.Lock()
+		started := e.started
+		m.mu.Unlock()
+		if !started || e.Health == nil || ctx.Err() != nil {
+			continue
+		}
+
+		if err := m.health(ctx, e); err != nil {
+			m.setState(e, StateUnhealthy, err)
+		} else {
+			m.setState(e, StateRunning, nil)
+		}
+	}
+}
+
+// stopDependents stops started components a dependency of which left
+// StateRunning, e.g. jobs whose database went away, so they do not run
+// against it. They wait and startPending brings them back once it
+// recovers. Going in start order also stops their own dependents.
+func (m *Manager) stopDependents(ctx context.Context) {
+	for _, e := range m.entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.mu.Lock()
+		started := e.started
+		missing := m.missingDependency(e)
+		m.mu.Unlock()
+		if !started || missing == "" {
+			continue
+		}
+
+		stopCtx, cancel := context.WithTimeout(ctx, m.opts.StartTimeout)
+		err := m.halt(stopCtx, e)
+		cancel()
+		m.mu.Lock()
+		e.started = false
+		m.mu.Unlock()
+		if err != nil {
+			slog.Warn("Component did not stop cleanly", "component", e.Name, "error", err)
+		}
+		m.setState(e, StateWaiting, fmt.Errorf("waiting for %s", missing))
+	}
+}
+
+func (m *Manager) health(ctx context.Context, e *entry) error {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.HealthTimeout)
+	defer cancel()
+	return e.Health(ctx)
+}
+
+func (m *Manager) stopOne(ctx context.Context, e *entry) error {
+	defer m.setState(e, StateStopped, nil)
+	return m.halt(ctx, e)
+}
+
+// halt calls a component's Stop, bounded by ctx
+func (m *Manager) halt(ctx context.Context, e *entry) error {
+	if e.Stop == nil {
+		return nil
+	}
+
+	// Run Stop aside, so one that ignores ctx cannot hold up the rest
+	done := make(chan error, 1)
+	go func() { done <- e.Stop(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// setState records a state change and reports it; repeats are ignored
+func (m *Manager) setState(e *entry, state State, err error) {
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	m.mu.Lock()
+	if e.status.State == state && e.status.Error == message {
+		m.mu.Unlock()
+		return
+	}
+	previous := e.status.State
+	e.status = Status{Name: e.Name, State: state, Error: message, Since: time.Now()}
+	status := e.status
+	m.mu.Unlock()
+
+	switch state {
+	case StateFailed, StateUnhealthy:
+		slog.Warn("Component not available", "component", e.Name, "state", state, "previous", previous, "error", message)
+	case StateRunning:
+		if previous == StateUnhealthy {
+			slog.Info("Component recovered", "component", e.Name)
+		}
+	}
+
+	if m.opts.OnChange != nil {
+		m.opts.OnChange(status)
+	}
+}
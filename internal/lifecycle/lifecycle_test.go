@@ -0,0 +1,226 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls until cond holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailRestartsComponent(t *testing.T) {
+	var starts atomic.Int32
+	m, err := New(Options{CheckInterval: 10 * time.Millisecond}, Component{
+		Name:  "server",
+		Start: func(ctx context.Context) error { starts.Add(1); return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	defer m.Stop(context.Background())
+
+	waitFor(t, "the first start", func() bool { return m.Running("server") })
+
+	m.Fail("server", errors.New("listener closed"))
+	if m.Running("server") {
+		t.Error("server is still running after Fail")
+	}
+	waitFor(t, "the restart", func() bool { return starts.Load() == 2 && m.Running("server") })
+}
+
+func TestFailDuringStart(t *testing.T) {
+	var m *Manager
+	var starts atomic.Int32
+	var mu sync.Mutex
+	var states []Status
+	m, err := New(Options{
+		CheckInterval: 10 * time.Millisecond,
+		OnChange: func(s Status) {
+			mu.Lock()
+			states = append(states, s)
+			mu.Unlock()
+		},
+	}, Component{
+		Name: "server",
+		Start: func(ctx context.Context) error {
+			// The first attempt goes down before Start returns
+			if starts.Add(1) == 1 {
+				m.Fail("server", errors.New("serve failed"))
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	defer m.Stop(context.Background())
+
+	waitFor(t, "the restart", func() bool { return m.Running("server") })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if first := states[0]; first.State != StateFailed || first.Error != "serve failed" {
+		t.Errorf("status after the first attempt = %s %q, want failed", first.State, first.Error)
+	}
+}
+
+func TestStopIsBoundedByContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var attempts atomic.Int32
+	m, err := New(Options{CheckInterval: 10 * time.Millisecond}, Component{
+		Name: "stuck",
+		Start: func(ctx context.Context) error {
+			if attempts.Add(1) == 1 {
+				return errors.New("not yet")
+			}
+			<-release // Ignores ctx
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	waitFor(t, "the retry", func() bool { return attempts.Load() == 2 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	if err := m.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("Stop() took %v, want it bounded by ctx", elapsed)
+	}
+}
+
+func TestStopOrder(t *testing.T) {
+	var stopped []string
+	component := func(name string, deps ...string) Component {
+		return Component{
+			Name:      name,
+			DependsOn: deps,
+			Start:     func(ctx context.Context) error { return nil },
+			Stop:      func(ctx context.Context) error { stopped = append(stopped, name); return nil },
+		}
+	}
+
+	m, err := New(Options{}, component("scheduler", "db"), component("db"), component("server"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	waitFor(t, "every component", func() bool { return m.Running("scheduler") && m.Running("server") })
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	want := []string{"server", "scheduler", "db"}
+	if len(stopped) != len(want) {
+		t.Fatalf("stopped %v, want %v", stopped, want)
+	}
+	for i := range want {
+		if stopped[i] != want[i] {
+			t.Fatalf("stopped %v, want %v", stopped, want)
+		}
+	}
+}
+
+func TestStartDoesNotWaitForFailingComponents(t *testing.T) {
+	m, err := New(Options{StartTimeout: time.Second}, Component{
+		Name: "db",
+		Start: func(ctx context.Context) error {
+			<-ctx.Done() // Server unreachable until the attempt times out
+			return ctx.Err()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	begin := time.Now()
+	m.Start()
+	if elapsed := time.Since(begin); elapsed > 100*time.Millisecond {
+		t.Errorf("Start() took %v, want it to return before the attempt ends", elapsed)
+	}
+	m.Stop(context.Background())
+}
+
+func TestDependentsStopWhileDependencyIsDown(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	var jobsRunning atomic.Bool
+	var jobStarts atomic.Int32
+
+	m, err := New(Options{CheckInterval: 10 * time.Millisecond},
+		Component{
+			Name:  "db",
+			Start: func(ctx context.Context) error { return nil },
+			Health: func(ctx context.Context) error {
+				if !healthy.Load() {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+		},
+		Component{
+			Name:      "scheduler",
+			DependsOn: []string{"db"},
+			Start: func(ctx context.Context) error {
+				jobStarts.Add(1)
+				jobsRunning.Store(true)
+				return nil
+			},
+			Stop: func(ctx context.Context) error { jobsRunning.Store(false); return nil },
+		},
+		Component{
+			Name:      "reports",
+			DependsOn: []string{"scheduler"},
+			Start:     func(ctx context.Context) error { return nil },
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	defer m.Stop(context.Background())
+
+	waitFor(t, "the first start", func() bool { return m.Running("reports") && jobsRunning.Load() })
+
+	healthy.Store(false)
+	waitFor(t, "the dependents to wait", func() bool {
+		for _, status := range m.Status()[1:] {
+			if status.State != StateWaiting {
+				return false
+			}
+		}
+		return true
+	})
+	if jobsRunning.Load() {
+		t.Error("scheduler still runs while db is down")
+	}
+
+	healthy.Store(true)
+	waitFor(t, "the restart", func() bool { return m.Running("reports") && jobsRunning.Load() })
+	if starts := jobStarts.Load(); starts != 2 {
+		t.Errorf("scheduler started %d times, want 2", starts)
+	}
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup installs a redacting slog logger as the default for both slog and
+// the standard log package. Level is "debug", "info", "warn" or "error";
+// format is "text" or "json".
+func Setup(level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+
+	handler, err := newHandler(os.Stderr, format, lvl)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(NewRedactingHandler(handler)))
+	return nil
+}
+
+func newHandler(w io.Writer, format string, level slog.Level) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q", format)
+}
+
+// NewID returns a short random identifier for a request or operation
+func NewID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type contextKey struct{}
+
+// With returns a context whose log records carry the given key-value
+// pairs, e.g. a request or job run ID
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append(attrsFrom(ctx), argsToAttrs(args)...)
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	// Copy so contexts derived from the same parent do not share storage
+	return append([]slog.Attr(nil), attrs...)
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
@@ -0,0 +1,119 @@
+// Package metrics holds the Prometheus metrics exposed on /metrics
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every metric name so the series stay apart from
+// other services on a shared Prometheus
+const Namespace = "pocket_wallet"
+
+// Payment intent outcomes
+const (
+	PaymentCreated   = "created"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// Login failure reasons
+const (
+	LoginUnknownUser   = "unknown_login"
+	LoginWrongPassword = "wrong_password"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	// AppCalls counts Wails-bound method calls by method and error code,
+	// "OK" for calls that succeeded
+	AppCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "app_calls_total",
+		Help:      "App method calls by method and result code.",
+	}, []string{"method", "code"})
+
+	// AppCallDuration measures Wails-bound method calls
+	AppCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "app_call_duration_seconds",
+		Help:      "App method call latency.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// DBOperationDuration measures MongoDB commands by command name and
+	// collection
+	DBOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "mongodb_operation_duration_seconds",
+		Help:      "MongoDB command latency.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"command", "collection"})
+
+	// DBOperationErrors counts MongoDB commands that failed
+	DBOperationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "mongodb_operation_errors_total",
+		Help:      "Failed MongoDB commands.",
+	}, []string{"command", "collection"})
+
+	// WebhookEvents counts processed webhook events by type and the
+	// outcome recorded in the webhook event log
+	WebhookEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "webhook_events_total",
+		Help:      "Webhook events by type and outcome.",
+	}, []string{"type", "outcome"})
+
+	// PaymentIntents counts card payments by outcome
+	PaymentIntents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "payment_intents_total",
+		Help:      "Payment intents created, succeeded and failed.",
+	}, []string{"outcome"})
+
+	// LoginFailures counts rejected logins by reason
+	LoginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "login_failures_total",
+		Help:      "Failed logins by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		AppCalls,
+		AppCallDuration,
+		DBOperationDuration,
+		DBOperationErrors,
+		WebhookEvents,
+		PaymentIntents,
+		LoginFailures,
+	)
+
+	// Start the outcome series at zero so rates work from the first scrape
+	for _, outcome := range []string{PaymentCreated, PaymentSucceeded, PaymentFailed} {
+		PaymentIntents.WithLabelValues(outcome)
+	}
+	for _, reason := range []string{LoginUnknownUser, LoginWrongPassword} {
+		LoginFailures.WithLabelValues(reason)
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveAppCall records one Wails-bound method call
+func ObserveAppCall(method, code string, elapsed time.Duration) {
+	AppCalls.WithLabelValues(method, code).Inc()
+	AppCallDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor returns a command monitor that records the latency and
+// failures of every command the driver sends
+func MongoMonitor() *event.CommandMonitor {
+	// The finished events carry no collection, so it is remembered from
+	// the started event until the command completes
+	var pending sync.Map
+
+	key := func(connectionID string, requestID int64) string {
+		return connectionID + "/" + strconv.FormatInt(requestID, 10)
+	}
+	collection := func(e event.CommandFinishedEvent) string {
+		if c, ok := pending.LoadAndDelete(key(e.ConnectionID, e.RequestID)); ok {
+			return c.(string)
+		}
+		return ""
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			pending.Store(key(e.ConnectionID, e.RequestID), commandCollection(e.CommandName, e.Command))
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			DBOperationDuration.WithLabelValues(e.CommandName, collection(e.CommandFinishedEvent)).Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			coll := collection(e.CommandFinishedEvent)
+			DBOperationDuration.WithLabelValues(e.CommandName, coll).Observe(e.Duration.Seconds())
+			DBOperationErrors.WithLabelValues(e.CommandName, coll).Inc()
+		},
+	}
+}
+
+// commandCollection finds the collection a command works on: CRUD
+// commands name it as their first value, getMore in its collection field
+func commandCollection(name string, cmd bson.Raw) string {
+	if v, err := cmd.LookupErr(name); err == nil {
+		if s, ok := v.StringValueOK(); ok {
+			return s
+		}
+	}
+	if v, err := cmd.LookupErr("collection"); err == nil {
+		if s, ok := v.StringValueOK(); ok {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package payments
+
+import (
+	"context"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+)
+
+// ErrPaymentNotFound is returned when the provider has no record of a payment
+var ErrPaymentNotFound = errcode.New(errcode.PaymentNotFound, "payment not found at provider")
+
+// Payment is the provider's view of a single charge, with its status
+// mapped onto the statuses used by the transactions collection
+type Payment struct {
+	ID             string // Stored as payment_id on the transaction
+	UserID         string // From the payment metadata, empty when unknown
+	Amount         int64  // Minor units
+	Currency       string // Upper-case ISO-4217 code
+	Status         string // "pending", "completed", "failed" or "cancelled"
+	ProviderStatus string // The provider's own status, for reports
+	Cancellable    bool   // Can still be cancelled at the provider
+	CreatedAt      time.Time
+}
+
+// Provider lists payments from an external payment processor
+type Provider interface {
+	Name() string
+
+	// ListPayments returns payments created in [from, to)
+	ListPayments(ctx context.Context, from, to time.Time) ([]*Payment, error)
+
+	// GetPayment looks up one payment, returning ErrPaymentNotFound if the
+	// provider does not know it
+	GetPayment(ctx context.Context, id string) (*Payment, error)
+
+	// CancelPayment cancels a payment the customer abandoned
+	CancelPayment(ctx context.Context, id string) (*Payment, error)
+}
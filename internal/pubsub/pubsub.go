@@ -0,0 +1,62 @@
+package pubsub
+
+import "sync"
+
+// Hub wakes subscribers when something changes for a user. It carries no
+// payload: subscribers read what changed from the event log, so a missed
+// wake-up is caught up on the next one and nothing is delivered twice.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan struct{}]struct{}
+}
+
+// NewHub creates a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subs: make(map[string]map[chan struct{}]struct{})}
+}
+
+// Subscribe returns a channel that receives after every Notify for the
+// user, and a function that unsubscribes
+func (h *Hub) Subscribe(userID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan struct{}]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[userID], ch)
+		if len(h.subs[userID]) == 0 {
+			delete(h.subs, userID)
+		}
+	}
+}
+
+// Notify wakes the user's subscribers. Wake-ups that arrive while one is
+// still pending are merged, so Notify never blocks.
+func (h *Hub) Notify(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribers counts open subscriptions across all users
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, subs := range h.subs {
+		n += len(subs)
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/payments"
+)
+
+// expiryBatch caps how many deposits one run looks at
+const expiryBatch = 200
+
+// ExpiryResult counts what happened to stale pending deposits
+type ExpiryResult struct {
+	Checked   int
+	Cancelled int // Abandoned and cancelled, at the provider when possible
+	Resolved  int // The provider had already settled them
+	Skipped   int // Still in flight at the provider, or failed to update
+}
+
+// ExpirePendingDeposits settles deposits still pending after maxAge. The
+// provider decides: a payment it already finished takes that status,
+// one still waiting for the customer is cancelled there and here, and
+// one it is still processing is left alone until the next run.
+func (r *Reconciler) ExpirePendingDeposits(ctx context.Context, maxAge time.Duration) (*ExpiryResult, error) {
+	deposits, err := r.db.GetStalePendingDeposits(ctx, time.Now().Add(-maxAge), expiryBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExpiryResult{}
+	for _, t := range deposits {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		result.Checked++
+
+		status, reason, err := r.settleDeposit(ctx, t, maxAge)
+		if err != nil {
+			slog.Warn("Could not expire deposit", "transaction_id", t.TransactionID, "error", err)
+			result.Skipped++
+			continue
+		}
+		if status == "" {
+			result.Skipped++
+			continue
+		}
+
+		changed, err := r.db.TransitionTransactionStatus(ctx, t.TransactionID, "pending", status, reason)
+		switch {
+		case err != nil:
+			slog.Warn("Could not expire deposit", "transaction_id", t.TransactionID, "error", err)
+			result.Skipped++
+		case !changed:
+			// A webhook settled it in the meantime
+			result.Skipped++
+		case status == "cancelled":
+			result.Cancelled++
+			r.changed(ctx, t.TransactionID)
+		default:
+			result.Resolved++
+			r.changed(ctx, t.TransactionID)
+		}
+	}
+
+	return result, nil
+}
+
+// settleDeposit works out the final status of a stale deposit; an empty
+// status means it should stay pending for now
+func (r *Reconciler) settleDeposit(ctx context.Context, t *database.Transaction, maxAge time.Duration) (status, reason string, err error) {
+	abandoned := fmt.Sprintf("abandoned: pending for more than %s", maxAge)
+	if t.PaymentID == "" {
+		return "cancelled", abandoned + ", no payment", nil
+	}
+
+	p, err := r.provider.GetPayment(ctx, t.PaymentID)
+	if errors.Is(err, payments.ErrPaymentNotFound) {
+		return "cancelled", fmt.Sprintf("%s, payment unknown to %s", abandoned, r.provider.Name()), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case p.Status == "completed" || p.Status == "cancelled":
+		return p.Status, fmt.Sprintf("resolved: %s payment %s", r.provider.Name(), p.ProviderStatus), nil
+	case p.Cancellable:
+		if _, err := r.provider.CancelPayment(ctx, p.ID); err != nil {
+			return "", "", err
+		}
+		return "cancelled", fmt.Sprintf("%s, %s payment cancelled", abandoned, r.provider.Name()), nil
+	}
+
+	// Still processing at the provider
+	return "", "", nil
+}
@@ -0,0 +1,170 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pocket-wallet/internal/payments"
+)
+
+const maxAge = 24 * time.Hour
+
+func TestExpirePendingDeposits(t *testing.T) {
+	stale := time.Now().Add(-48 * time.Hour)
+
+	store := newFakeStore("u1")
+	store.add("", "pending", 5000, "PLN", stale)
+	store.add("pi_unknown", "pending", 5000, "PLN", stale)
+	store.add("pi_succeeded", "pending", 5000, "PLN", stale)
+	store.add("pi_canceled", "pending", 5000, "PLN", stale)
+	store.add("pi_abandoned", "pending", 5000, "PLN", stale)
+	store.add("pi_processing", "pending", 5000, "PLN", stale)
+	store.add("pi_recent", "pending", 5000, "PLN", time.Now().Add(-time.Hour))
+	store.add("pi_done", "completed", 5000, "PLN", stale)
+
+	abandoned := payment("pi_abandoned", "pending", 5000, "PLN")
+	abandoned.ProviderStatus, abandoned.Cancellable = "requires_payment_method", true
+	processing := payment("pi_processing", "pending", 5000, "PLN")
+	processing.ProviderStatus = "processing"
+	succeeded := payment("pi_succeeded", "completed", 5000, "PLN")
+	succeeded.ProviderStatus = "succeeded"
+	provider := &fakeProvider{other: []*payments.Payment{
+		succeeded,
+		payment("pi_canceled", "cancelled", 5000, "PLN"),
+		abandoned,
+		processing,
+		payment("pi_recent", "pending", 5000, "PLN"),
+	}}
+
+	r := New(store, provider)
+	var changed []string
+	r.Changed = func(ctx context.Context, transactionID string) { changed = append(changed, transactionID) }
+
+	result, err := r.ExpirePendingDeposits(context.Background(), maxAge)
+	if err != nil {
+		t.Fatalf("ExpirePendingDeposits() error = %v", err)
+	}
+	want := ExpiryResult{Checked: 6, Cancelled: 4, Resolved: 1, Skipped: 1}
+	if *result != want {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+	if len(changed) != 5 {
+		t.Errorf("Changed called for %v, want the 5 settled deposits", changed)
+	}
+
+	wantStatus := map[string]string{
+		"pi_unknown":    "cancelled",
+		"pi_succeeded":  "completed",
+		"pi_canceled":   "cancelled",
+		"pi_abandoned":  "cancelled",
+		"pi_processing": "pending",
+		"pi_recent":     "pending",
+		"pi_done":       "completed",
+	}
+	for paymentID, want := range wantStatus {
+		if got := store.byPaymentID(paymentID).Status; got != want {
+			t.Errorf("deposit for %s is %s, want %s", paymentID, got, want)
+		}
+	}
+	if store.transactions[0].Status != "cancelled" {
+		t.Errorf("deposit without a payment is %s, want cancelled", store.transactions[0].Status)
+	}
+
+	// Only a payment still waiting for the customer is cancelled at Stripe;
+	// one that went through must never be
+	if len(provider.cancelled) != 1 || provider.cancelled[0] != "pi_abandoned" {
+		t.Errorf("cancelled at the provider %v, want only pi_abandoned", provider.cancelled)
+	}
+
+	reasons := strings.Join(store.transitions, "\n")
+	for _, reason := range []string{
+		"pending->completed: resolved: stripe payment succeeded",
+		"pending->cancelled: abandoned: pending for more than 24h0m0s, no payment",
+		"pending->cancelled: abandoned: pending for more than 24h0m0s, payment unknown to stripe",
+		"pending->cancelled: abandoned: pending for more than 24h0m0s, stripe payment cancelled",
+	} {
+		if !strings.Contains(reasons, reason) {
+			t.Errorf("status history lacks %q in\n%s", reason, reasons)
+		}
+	}
+}
+
+// racingProvider lets a test act while a deposit is being looked up, as a
+// webhook arriving mid-run would
+type racingProvider struct {
+	*fakeProvider
+	lookup func(id string) error
+}
+
+func (p *racingProvider) GetPayment(ctx context.Context, id string) (*payments.Payment, error) {
+	if err := p.lookup(id); err != nil {
+		return nil, err
+	}
+	return p.fakeProvider.GetPayment(ctx, id)
+}
+
+func TestExpirePendingDepositsSucceededMeanwhile(t *testing.T) {
+	store := newFakeStore("u1")
+	deposit := store.add("pi_1", "pending", 5000, "PLN", time.Now().Add(-48*time.Hour))
+
+	// The customer finishes paying and the webhook completes the deposit
+	// while the expiry job still sees it as pending
+	abandoned := payment("pi_1", "pending", 5000, "PLN")
+	abandoned.Cancellable = true
+	provider := &racingProvider{
+		fakeProvider: &fakeProvider{other: []*payments.Payment{abandoned}},
+		lookup: func(id string) error {
+			abandoned.Status, abandoned.ProviderStatus, abandoned.Cancellable = "completed", "succeeded", false
+			deposit.Status = "completed"
+			return nil
+		},
+	}
+
+	r := New(store, provider)
+	result, err := r.ExpirePendingDeposits(context.Background(), maxAge)
+	if err != nil {
+		t.Fatalf("ExpirePendingDeposits() error = %v", err)
+	}
+	if result.Skipped != 1 || result.Cancelled != 0 || result.Resolved != 0 {
+		t.Errorf("result = %+v, want the deposit skipped", *result)
+	}
+	if deposit.Status != "completed" {
+		t.Errorf("deposit is %s, want it left completed", deposit.Status)
+	}
+	if len(provider.cancelled) != 0 {
+		t.Errorf("cancelled %v at the provider after it succeeded", provider.cancelled)
+	}
+	if len(store.transitions) != 0 {
+		t.Errorf("status changed %v over the webhook's", store.transitions)
+	}
+}
+
+func TestExpirePendingDepositsProviderError(t *testing.T) {
+	store := newFakeStore("u1")
+	store.add("pi_1", "pending", 5000, "PLN", time.Now().Add(-48*time.Hour))
+	store.add("pi_2", "pending", 5000, "PLN", time.Now().Add(-47*time.Hour))
+
+	provider := &racingProvider{
+		fakeProvider: &fakeProvider{other: []*payments.Payment{payment("pi_2", "completed", 5000, "PLN")}},
+		lookup: func(id string) error {
+			if id == "pi_1" {
+				return errors.New("stripe: rate limited")
+			}
+			return nil
+		},
+	}
+
+	result, err := New(store, provider).ExpirePendingDeposits(context.Background(), maxAge)
+	if err != nil {
+		t.Fatalf("ExpirePendingDeposits() error = %v", err)
+	}
+	if result.Skipped != 1 || result.Resolved != 1 {
+		t.Errorf("result = %+v, want pi_1 skipped and pi_2 resolved", *result)
+	}
+	if status := store.byPaymentID("pi_1").Status; status != "pending" {
+		t.Errorf("deposit pi_1 is %s after a provider error, want it left pending", status)
+	}
+}
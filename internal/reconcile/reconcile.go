@@ -0,0 +1,281 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/payments"
+)
+
+// localSlack extends the local scan past the window end: a transaction is
+// recorded just after its payment is created at the provider
+const localSlack = time.Hour
+
+// Store is the persistence the reconciler needs
+type Store interface {
+	StreamPaymentTransactions(ctx context.Context, from, to time.Time, fn func(*database.Transaction) error) error
+	GetTransactionByPaymentID(ctx context.Context, paymentID string) (*database.Transaction, error)
+	GetStalePendingDeposits(ctx context.Context, cutoff time.Time, limit int) ([]*database.Transaction, error)
+	GetUserByID(ctx context.Context, userID string) (*database.User, error)
+	CreateTransaction(ctx context.Context, req *database.TransactionRequest) (*database.Transaction, error)
+	TransitionTransactionStatus(ctx context.Context, transactionID, from, to, reason string) (bool, error)
+}
+
+// Reconciler compares payment transactions with the payment provider and
+// fixes statuses that a missed webhook left behind
+type Reconciler struct {
+	db       Store
+	provider payments.Provider
+
+	// Changed, when set, is called with every transaction the reconciler
+	// recreated or moved to another status, so the change can be announced
+	Changed func(ctx context.Context, transactionID string)
+}
+
+// New creates a reconciler for one provider
+func New(db Store, provider payments.Provider) *Reconciler {
+	return &Reconciler{db: db, provider: provider}
+}
+
+// Run reconciles payments created in [from, to). With dryRun set nothing
+// is changed and the report lists what would have been fixed.
+func (r *Reconciler) Run(ctx context.Context, from, to time.Time, dryRun bool) (*database.ReconciliationReport, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("reconciliation window is empty: %s - %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	report := &database.ReconciliationReport{
+		Provider:      r.provider.Name(),
+		From:          from,
+		To:            to,
+		DryRun:        dryRun,
+		Discrepancies: []*database.Discrepancy{},
+		StartedAt:     time.Now(),
+	}
+
+	remote, err := r.provider.ListPayments(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	remoteByID := make(map[string]*payments.Payment, len(remote))
+	for _, p := range remote {
+		remoteByID[p.ID] = p
+	}
+
+	var local []*database.Transaction
+	err = r.db.StreamPaymentTransactions(ctx, from, to.Add(localSlack), func(t *database.Transaction) error {
+		local = append(local, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(local))
+	for _, t := range local {
+		seen[t.PaymentID] = true
+
+		p, ok := remoteByID[t.PaymentID]
+		if !ok {
+			// Created at the provider outside the window, or not at all
+			p, err = r.provider.GetPayment(ctx, t.PaymentID)
+			if errors.Is(err, payments.ErrPaymentNotFound) {
+				report.Checked++
+				report.Discrepancies = append(report.Discrepancies, &database.Discrepancy{
+					Kind:          database.DiscrepancyMissingRemote,
+					PaymentID:     t.PaymentID,
+					TransactionID: t.TransactionID,
+					UserID:        t.UserID,
+					LocalStatus:   t.Status,
+					LocalAmount:   currency.ToMinor(t.Amount, t.Currency),
+					Currency:      t.Currency,
+					Detail:        fmt.Sprintf("payment unknown to %s", r.provider.Name()),
+				})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		report.Checked++
+		r.compare(ctx, report, t, p)
+	}
+
+	for _, p := range remote {
+		if seen[p.ID] {
+			continue
+		}
+		report.Checked++
+
+		// The transaction may predate the window
+		t, err := r.db.GetTransactionByPaymentID(ctx, p.ID)
+		switch {
+		case err == nil:
+			r.compare(ctx, report, t, p)
+		case errors.Is(err, database.ErrTransactionNotFound):
+			r.recordMissing(ctx, report, p)
+		default:
+			return nil, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// compare checks one transaction against its provider payment
+func (r *Reconciler) compare(ctx context.Context, report *database.ReconciliationReport, t *database.Transaction, p *payments.Payment) {
+	matched := true
+	localAmount := currency.ToMinor(t.Amount, t.Currency)
+
+	if localAmount != p.Amount || t.Currency != p.Currency {
+		matched = false
+		report.Discrepancies = append(report.Discrepancies, &database.Discrepancy{
+			Kind:           database.DiscrepancyAmountMismatch,
+			PaymentID:      p.ID,
+			TransactionID:  t.TransactionID,
+			UserID:         t.UserID,
+			LocalAmount:    localAmount,
+			ProviderAmount: p.Amount,
+			Currency:       t.Currency,
+			Detail: fmt.Sprintf("local %s, %s %s",
+				currency.FormatMinor(localAmount, t.Currency), r.provider.Name(), currency.FormatMinor(p.Amount, p.Currency)),
+		})
+	}
+
+	if t.Status != p.Status {
+		matched = false
+		d := &database.Discrepancy{
+			Kind:           database.DiscrepancyStatusMismatch,
+			PaymentID:      p.ID,
+			TransactionID:  t.TransactionID,
+			UserID:         t.UserID,
+			LocalStatus:    t.Status,
+			ProviderStatus: p.ProviderStatus,
+			Currency:       t.Currency,
+		}
+
+		if fixable(t.Status, p.Status) {
+			d.Detail = fmt.Sprintf("%s -> %s", t.Status, p.Status)
+			if !report.DryRun {
+				fixed, err := r.db.TransitionTransactionStatus(ctx, t.TransactionID, t.Status, p.Status, r.reason())
+				switch {
+				case err != nil:
+					d.Detail += ": " + err.Error()
+				case !fixed:
+					d.Detail += ": status changed concurrently"
+				default:
+					d.Fixed = true
+					report.Fixed++
+					r.changed(ctx, t.TransactionID)
+				}
+			}
+		} else {
+			d.Detail = fmt.Sprintf("local %s, %s %s; needs manual review", t.Status, r.provider.Name(), p.ProviderStatus)
+		}
+
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	if matched {
+		report.Matched++
+	}
+}
+
+// fixable reports whether a local status may be brought in line with the
+// provider automatically. Pending is stale once the provider has decided,
+// and a failed attempt can still succeed with another payment method.
+// Anything else, such as a completed deposit the provider no longer shows
+// as succeeded, touches money already credited and needs a person.
+func fixable(local, remote string) bool {
+	switch local {
+	case "pending":
+		return remote != "pending"
+	case "failed":
+		return remote == "completed"
+	}
+	return false
+}
+
+// recordMissing reports a provider payment without a transaction and, when
+// its user is known, recreates the deposit record
+func (r *Reconciler) recordMissing(ctx context.Context, report *database.ReconciliationReport, p *payments.Payment) {
+	d := &database.Discrepancy{
+		Kind:           database.DiscrepancyMissingLocal,
+		PaymentID:      p.ID,
+		UserID:         p.UserID,
+		ProviderStatus: p.ProviderStatus,
+		ProviderAmount: p.Amount,
+		Currency:       p.Currency,
+		Detail:         "no transaction for payment",
+	}
+	report.Discrepancies = append(report.Discrepancies, d)
+
+	if p.UserID == "" {
+		d.Detail += "; user unknown, needs manual review"
+		return
+	}
+	if _, err := r.db.GetUserByID(ctx, p.UserID); err != nil {
+		d.Detail += "; user not found, needs manual review"
+		return
+	}
+	if report.DryRun {
+		return
+	}
+
+	t, err := r.db.CreateTransaction(ctx, &database.TransactionRequest{
+		UserID:       p.UserID,
+		Type:         "deposit",
+		Amount:       currency.FromMinor(p.Amount, p.Currency),
+		Currency:     p.Currency,
+		Description:  fmt.Sprintf("Doładowanie portfela - %s", currency.FormatMinor(p.Amount, p.Currency)),
+		Counterparty: r.provider.Name(),
+		PaymentID:    p.ID,
+	})
+	if err != nil {
+		d.Detail += ": " + err.Error()
+		return
+	}
+	d.TransactionID = t.TransactionID
+
+	if p.Status != t.Status {
+		if _, err := r.db.TransitionTransactionStatus(ctx, t.TransactionID, t.Status, p.Status, r.reason()); err != nil {
+			d.Detail += ": " + err.Error()
+			return
+		}
+	}
+
+	d.Fixed = true
+	report.Fixed++
+	slog.Info("Reconciliation recreated transaction", "transaction_id", t.TransactionID, "payment_id", p.ID)
+	r.changed(ctx, t.TransactionID)
+}
+
+// changed reports a transaction changed by the reconciler
+func (r *Reconciler) changed(ctx context.Context, transactionID string) {
+	if r.Changed != nil {
+		r.Changed(ctx, transactionID)
+	}
+}
+
+// reason is recorded in the status history of fixed transactions
+func (r *Reconciler) reason() string {
+	return fmt.Sprintf("reconciled with %s", r.provider.Name())
+}
+
+// Unresolved counts discrepancies that were not fixed
+func Unresolved(report *database.ReconciliationReport) int {
+	count := 0
+	for _, d := range report.Discrepancies {
+		if !d.Fixed {
+			count++
+		}
+	}
+	return count
+}
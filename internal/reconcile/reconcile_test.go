@@ -0,0 +1,378 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/payments"
+)
+
+// fakeStore keeps users and transactions in memory
+type fakeStore struct {
+	users        map[string]bool
+	transactions []*database.Transaction
+	transitions  []string // "transaction_id from->to: reason"
+	nextID       int
+}
+
+func newFakeStore(userIDs ...string) *fakeStore {
+	f := &fakeStore{users: map[string]bool{}}
+	for _, id := range userIDs {
+		f.users[id] = true
+	}
+	return f
+}
+
+// add stores a deposit of amount minor units created at createdAt
+func (f *fakeStore) add(paymentID, status string, amount int64, code string, createdAt time.Time) *database.Transaction {
+	f.nextID++
+	t := &database.Transaction{
+		TransactionID: fmt.Sprintf("tx-%d", f.nextID),
+		UserID:        "u1",
+		Type:          "deposit",
+		Amount:        currency.FromMinor(amount, code),
+		Currency:      code,
+		Status:        status,
+		PaymentID:     paymentID,
+		CreatedAt:     createdAt,
+	}
+	f.transactions = append(f.transactions, t)
+	return t
+}
+
+func (f *fakeStore) byPaymentID(paymentID string) *database.Transaction {
+	for _, t := range f.transactions {
+		if t.PaymentID == paymentID {
+			return t
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) StreamPaymentTransactions(ctx context.Context, from, to time.Time, fn func(*database.Transaction) error) error {
+	for _, t := range f.transactions {
+		if t.PaymentID != "" && !t.CreatedAt.Before(from) && t.CreatedAt.Before(to) {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) GetTransactionByPaymentID(ctx context.Context, paymentID string) (*database.Transaction, error) {
+	if t := f.byPaymentID(paymentID); t != nil {
+		return t, nil
+	}
+	return nil, database.ErrTransactionNotFound
+}
+
+func (f *fakeStore) GetStalePendingDeposits(ctx context.Context, cutoff time.Time, limit int) ([]*database.Transaction, error) {
+	var stale []*database.Transaction
+	for _, t := range f.transactions {
+		if t.Type == "deposit" && t.Status == "pending" && t.CreatedAt.Before(cutoff) {
+			stale = append(stale, t)
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].CreatedAt.Before(stale[j].CreatedAt) })
+	if len(stale) > limit {
+		stale = stale[:limit]
+	}
+	return stale, nil
+}
+
+func (f *fakeStore) GetUserByID(ctx context.Context, userID string) (*database.User, error) {
+	if !f.users[userID] {
+		return nil, database.ErrUserNotFound
+	}
+	return &database.User{UserID: userID}, nil
+}
+
+func (f *fakeStore) CreateTransaction(ctx context.Context, req *database.TransactionRequest) (*database.Transaction, error) {
+	f.nextID++
+	t := &database.Transaction{
+		TransactionID: fmt.Sprintf("tx-%d", f.nextID),
+		UserID:        req.UserID,
+		Type:          req.Type,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Status:        "pending",
+		Description:   req.Description,
+		Counterparty:  req.Counterparty,
+		PaymentID:     req.PaymentID,
+		CreatedAt:     time.Now(),
+	}
+	f.transactions = append(f.transactions, t)
+	return t, nil
+}
+
+func (f *fakeStore) TransitionTransactionStatus(ctx context.Context, transactionID, from, to, reason string) (bool, error) {
+	for _, t := range f.transactions {
+		if t.TransactionID == transactionID && t.Status == from {
+			t.Status = to
+			f.transitions = append(f.transitions, fmt.Sprintf("%s %s->%s: %s", transactionID, from, to, reason))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeProvider serves payments from memory; those listed are created
+// inside the window, the others are only found by ID
+type fakeProvider struct {
+	listed    []*payments.Payment
+	other     []*payments.Payment
+	cancelled []string
+}
+
+func (p *fakeProvider) Name() string { return "stripe" }
+
+func (p *fakeProvider) ListPayments(ctx context.Context, from, to time.Time) ([]*payments.Payment, error) {
+	return p.listed, nil
+}
+
+func (p *fakeProvider) GetPayment(ctx context.Context, id string) (*payments.Payment, error) {
+	for _, payment := range append(p.listed, p.other...) {
+		if payment.ID == id {
+			return payment, nil
+		}
+	}
+	return nil, payments.ErrPaymentNotFound
+}
+
+func (p *fakeProvider) CancelPayment(ctx context.Context, id string) (*payments.Payment, error) {
+	payment, err := p.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p.cancelled = append(p.cancelled, id)
+	payment.Status, payment.ProviderStatus, payment.Cancellable = "cancelled", "canceled", false
+	return payment, nil
+}
+
+func payment(id, status string, amount int64, code string) *payments.Payment {
+	return &payments.Payment{ID: id, UserID: "u1", Amount: amount, Currency: code, Status: status, ProviderStatus: status}
+}
+
+var (
+	windowStart = time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	windowEnd   = windowStart.Add(24 * time.Hour)
+	inWindow    = windowStart.Add(time.Hour)
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		local    func(*fakeStore)
+		provider *fakeProvider
+		dryRun   bool
+
+		wantKinds   []string
+		wantFixed   int
+		wantMatched int
+		wantStatus  map[string]string // Payment ID to local status afterwards
+		wantDetail  string
+	}{
+		{
+			name:        "in agreement",
+			local:       func(f *fakeStore) { f.add("pi_1", "completed", 5000, "PLN", inWindow) },
+			provider:    &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 5000, "PLN")}},
+			wantMatched: 1,
+			wantStatus:  map[string]string{"pi_1": "completed"},
+		},
+		{
+			name:       "missing locally",
+			local:      func(f *fakeStore) {},
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 5000, "PLN")}},
+			wantKinds:  []string{database.DiscrepancyMissingLocal},
+			wantFixed:  1,
+			wantStatus: map[string]string{"pi_1": "completed"},
+		},
+		{
+			name:       "missing locally in a dry run",
+			local:      func(f *fakeStore) {},
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 5000, "PLN")}},
+			dryRun:     true,
+			wantKinds:  []string{database.DiscrepancyMissingLocal},
+			wantStatus: map[string]string{"pi_1": ""},
+		},
+		{
+			name:  "missing locally for an unknown user",
+			local: func(f *fakeStore) {},
+			provider: &fakeProvider{listed: []*payments.Payment{
+				{ID: "pi_1", UserID: "u9", Amount: 5000, Currency: "PLN", Status: "completed"},
+			}},
+			wantKinds:  []string{database.DiscrepancyMissingLocal},
+			wantStatus: map[string]string{"pi_1": ""},
+			wantDetail: "user not found, needs manual review",
+		},
+		{
+			name:       "missing at the provider",
+			local:      func(f *fakeStore) { f.add("pi_1", "pending", 5000, "PLN", inWindow) },
+			provider:   &fakeProvider{},
+			wantKinds:  []string{database.DiscrepancyMissingRemote},
+			wantStatus: map[string]string{"pi_1": "pending"},
+			wantDetail: "payment unknown to stripe",
+		},
+		{
+			name:       "stale pending status",
+			local:      func(f *fakeStore) { f.add("pi_1", "pending", 5000, "PLN", inWindow) },
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 5000, "PLN")}},
+			wantKinds:  []string{database.DiscrepancyStatusMismatch},
+			wantFixed:  1,
+			wantStatus: map[string]string{"pi_1": "completed"},
+		},
+		{
+			name:       "stale pending status in a dry run",
+			local:      func(f *fakeStore) { f.add("pi_1", "pending", 5000, "PLN", inWindow) },
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "failed", 5000, "PLN")}},
+			dryRun:     true,
+			wantKinds:  []string{database.DiscrepancyStatusMismatch},
+			wantStatus: map[string]string{"pi_1": "pending"},
+			wantDetail: "pending -> failed",
+		},
+		{
+			name:       "completed locally, failed at the provider",
+			local:      func(f *fakeStore) { f.add("pi_1", "completed", 5000, "PLN", inWindow) },
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "failed", 5000, "PLN")}},
+			wantKinds:  []string{database.DiscrepancyStatusMismatch},
+			wantStatus: map[string]string{"pi_1": "completed"},
+			wantDetail: "needs manual review",
+		},
+		{
+			name:       "amount mismatch",
+			local:      func(f *fakeStore) { f.add("pi_1", "completed", 5000, "PLN", inWindow) },
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 4999, "PLN")}},
+			wantKinds:  []string{database.DiscrepancyAmountMismatch},
+			wantStatus: map[string]string{"pi_1": "completed"},
+			wantDetail: "local 50.00 PLN, stripe 49.99 PLN",
+		},
+		{
+			name:       "currency mismatch",
+			local:      func(f *fakeStore) { f.add("pi_1", "completed", 5000, "PLN", inWindow) },
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 5000, "EUR")}},
+			wantKinds:  []string{database.DiscrepancyAmountMismatch},
+			wantStatus: map[string]string{"pi_1": "completed"},
+		},
+		{
+			name:        "payment created before the window",
+			local:       func(f *fakeStore) { f.add("pi_1", "pending", 5000, "PLN", inWindow) },
+			provider:    &fakeProvider{other: []*payments.Payment{payment("pi_1", "pending", 5000, "PLN")}},
+			wantMatched: 1,
+			wantStatus:  map[string]string{"pi_1": "pending"},
+		},
+		{
+			name:       "transaction recorded before the window",
+			local:      func(f *fakeStore) { f.add("pi_1", "pending", 5000, "PLN", windowStart.Add(-48*time.Hour)) },
+			provider:   &fakeProvider{listed: []*payments.Payment{payment("pi_1", "cancelled", 5000, "PLN")}},
+			wantKinds:  []string{database.DiscrepancyStatusMismatch},
+			wantFixed:  1,
+			wantStatus: map[string]string{"pi_1": "cancelled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore("u1")
+			tt.local(store)
+			r := New(store, tt.provider)
+			var changed []string
+			r.Changed = func(ctx context.Context, transactionID string) { changed = append(changed, transactionID) }
+
+			report, err := r.Run(context.Background(), windowStart, windowEnd, tt.dryRun)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var kinds []string
+			for _, d := range report.Discrepancies {
+				kinds = append(kinds, d.Kind)
+				if tt.wantDetail != "" && !strings.Contains(d.Detail, tt.wantDetail) {
+					t.Errorf("detail %q, want it to mention %q", d.Detail, tt.wantDetail)
+				}
+			}
+			if strings.Join(kinds, ",") != strings.Join(tt.wantKinds, ",") {
+				t.Errorf("discrepancies %v, want %v", kinds, tt.wantKinds)
+			}
+			if report.Checked != 1 || report.Matched != tt.wantMatched || report.Fixed != tt.wantFixed {
+				t.Errorf("checked %d matched %d fixed %d, want 1, %d and %d",
+					report.Checked, report.Matched, report.Fixed, tt.wantMatched, tt.wantFixed)
+			}
+			if Unresolved(report) != len(tt.wantKinds)-tt.wantFixed {
+				t.Errorf("Unresolved() = %d, want %d", Unresolved(report), len(tt.wantKinds)-tt.wantFixed)
+			}
+			if len(changed) != tt.wantFixed {
+				t.Errorf("Changed called for %v, want %d transactions", changed, tt.wantFixed)
+			}
+			if tt.dryRun && len(store.transitions) > 0 {
+				t.Errorf("dry run changed %v", store.transitions)
+			}
+
+			for paymentID, want := range tt.wantStatus {
+				got := ""
+				if transaction := store.byPaymentID(paymentID); transaction != nil {
+					got = transaction.Status
+				}
+				if got != want {
+					t.Errorf("transaction for %s is %q, want %q", paymentID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunRecreatesMissingDeposit(t *testing.T) {
+	store := newFakeStore("u1")
+	provider := &fakeProvider{listed: []*payments.Payment{payment("pi_1", "completed", 2550, "EUR")}}
+
+	if _, err := New(store, provider).Run(context.Background(), windowStart, windowEnd, false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	recreated := store.byPaymentID("pi_1")
+	if recreated == nil {
+		t.Fatal("no transaction recreated for pi_1")
+	}
+	if recreated.UserID != "u1" || recreated.Type != "deposit" || recreated.Amount != 25.50 || recreated.Currency != "EUR" {
+		t.Errorf("recreated %s %s %v %s, want a 25.50 EUR deposit for u1",
+			recreated.UserID, recreated.Type, recreated.Amount, recreated.Currency)
+	}
+	if want := "tx-1 pending->completed: reconciled with stripe"; len(store.transitions) != 1 || store.transitions[0] != want {
+		t.Errorf("transitions %v, want [%s]", store.transitions, want)
+	}
+}
+
+func TestRunEmptyWindow(t *testing.T) {
+	r := New(newFakeStore(), &fakeProvider{})
+	if _, err := r.Run(context.Background(), windowEnd, windowStart, false); err == nil {
+		t.Error("Run() with an empty window succeeded")
+	}
+}
+
+func TestFixable(t *testing.T) {
+	tests := []struct {
+		local, remote string
+		want          bool
+	}{
+		{"pending", "completed", true},
+		{"pending", "failed", true},
+		{"pending", "cancelled", true},
+		{"pending", "pending", false},
+		{"failed", "completed", true},
+		{"failed", "cancelled", false},
+		{"completed", "failed", false},
+		{"completed", "cancelled", false},
+		{"cancelled", "completed", false},
+	}
+	for _, tt := range tests {
+		if got := fixable(tt.local, tt.remote); got != tt.want {
+			t.Errorf("fixable(%s, %s) = %v, want %v", tt.local, tt.remote, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/logging"
+	"pocket-wallet/internal/tracing"
+)
+
+// Job is a unit of periodic work
+type Job struct {
+	Name    string
+	Spec    string        // See Parse
+	Jitter  time.Duration // Random delay added to every activation
+	Timeout time.Duration // Per-run limit, 0 for none
+	Run     func(ctx context.Context) error
+}
+
+// Store persists job state between runs of the application
+type Store interface {
+	GetJobStates(ctx context.Context) ([]*database.JobState, error)
+	SaveJobState(ctx context.Context, state *database.JobState) error
+}
+
+// Status is a snapshot of one job for display
+type Status struct {
+	database.JobState
+	Spec    string
+	Running bool
+	NextRun time.Time
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+
+	mu      sync.Mutex
+	state   database.JobState
+	running bool
+	nextRun time.Time
+}
+
+// Scheduler runs jobs on their schedules. A job never overlaps itself: an
+// activation that arrives while the previous run is still going is
+// skipped. Jobs that missed a run while the application was closed run
+// once shortly after Start.
+type Scheduler struct {
+	store   Store
+	entries map[string]*entry
+
+	mu         sync.Mutex
+	started    bool
+	stopLoops  context.CancelFunc
+	cancelJobs context.CancelFunc
+	jobCtx     context.Context
+	loops      sync.WaitGroup
+	runs       sync.WaitGroup
+}
+
+// New creates a scheduler that keeps job state in store; store may be nil
+func New(store Store) *Scheduler {
+	return &Scheduler{store: store, entries: make(map[string]*entry)}
+}
+
+// Add registers a job; it must be called before Start
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job needs a name and a run function")
+	}
+	schedule, err := Parse(job.Spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("job %s: scheduler already started", job.Name)
+	}
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+
+	s.entries[job.Name] = &entry{
+		job:      job,
+		schedule: schedule,
+		state:    database.JobState{Name: job.Name},
+	}
+	return nil
+}
+
+// Start loads persisted state and begins scheduling
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	if s.store != nil {
+		states, err := s.store.GetJobStates(context.Background())
+		if err != nil {
+			slog.Warn("Could not load job states", "error", err)
+		}
+		for _, state := range states {
+			if e, ok := s.entries[state.Name]; ok {
+				e.state = *state
+			}
+		}
+	}
+
+	var loopCtx context.Context
+	loopCtx, s.stopLoops = context.WithCancel(context.Background())
+	s.jobCtx, s.cancelJobs = context.WithCancel(context.Background())
+
+	for _, e := range s.entries {
+		s.loops.Add(1)
+		go s.loop(loopCtx, e)
+	}
+	slog.Info("Scheduler started", "jobs", len(s.entries))
+}
+
+// cancelGrace is how long Stop waits for cancelled jobs to return, so
+// they can still record their run
+const cancelGrace = 2 * time.Second
+
+// Stop stops scheduling and waits for running jobs to finish. If ctx ends
+// first, running jobs are cancelled and ctx's error is returned once they
+// return or cancelGrace has passed, whichever comes first.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = false
+	s.mu.Unlock()
+
+	s.stopLoops()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		s.cancelJobs()
+		grace := time.NewTimer(cancelGrace)
+		defer grace.Stop()
+		select {
+		case <-done:
+		case <-grace.C:
+			// A job ignoring its context must not hold up shutdown
+			slog.Warn("Jobs still running after cancellation, not waiting for them")
+		}
+		return ctx.Err()
+	}
+}
+
+// Running reports whether the scheduler has been started and not stopped
+func (s *Scheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+// Status reports every job, ordered by name
+func (s *Scheduler) Status() []Status {
+	statuses := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		e.mu.Lock()
+		statuses = append(statuses, Status{
+			JobState: e.state,
+			Spec:     e.job.Spec,
+			Running:  e.running,
+			NextRun:  e.nextRun,
+		})
+		e.mu.Unlock()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.loops.Done()
+
+	now := time.Now()
+	e.mu.Lock()
+	last := e.state.LastStartedAt
+	e.mu.Unlock()
+
+	next := e.schedule.Next(now)
+	if !last.IsZero() {
+		if missed := e.schedule.Next(last); !missed.IsZero() && missed.Before(now) {
+			next = now
+		}
+	}
+
+	for !next.IsZero() {
+		next = next.Add(jitter(e.job.Jitter))
+		e.mu.Lock()
+		e.nextRun = next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.trigger(e)
+		next = e.schedule.Next(time.Now())
+	}
+	slog.Warn("Job has no future activations", "job", e.job.Name)
+}
+
+// trigger starts a run unless the previous one is still in progress
+func (s *Scheduler) trigger(e *entry) {
+	e.mu.Lock()
+	if e.running {
+		e.state.Skipped++
+		state := e.state
+		e.mu.Unlock()
+		slog.Info("Job still running, skipping activation", "job", e.job.Name)
+		s.save(context.Background(), &state)
+		return
+	}
+	e.running = true
+	e.state.LastStartedAt = time.Now()
+	e.mu.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		ctx := logging.With(s.jobCtx, "job", e.job.Name, "run_id", logging.NewID())
+		ctx, span := tracing.Start(ctx, "Job "+e.job.Name)
+		start := time.Now()
+		err := s.execute(ctx, e)
+		tracing.End(span, err)
+
+		e.mu.Lock()
+		e.running = false
+		e.state.LastFinishedAt = time.Now()
+		e.state.LastDurationMs = time.Since(start).Milliseconds()
+		e.state.Runs++
+		e.state.LastError = ""
+		if err != nil {
+			e.state.Failures++
+			e.state.LastError = err.Error()
+		}
+		state := e.state
+		e.mu.Unlock()
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Job failed", "error", err)
+		}
+		// Record the run even when it was cut short by Stop
+		s.save(context.WithoutCancel(ctx), &state)
+	}()
+}
+
+// execute runs the job, turning a panic into an error so one bad job
+// cannot take down the application
+func (s *Scheduler) execute(ctx context.Context, e *entry) (err error) {
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	err = e.job.Run(ctx)
+	if errors.Is(err, context.DeadlineExceeded) && e.job.Timeout > 0 {
+		err = fmt.Errorf("timed out after %s: %w", e.job.Timeout, err)
+	}
+	return err
+}
+
+func (s *Scheduler) save(ctx context.Context, state *database.JobState) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.SaveJobState(ctx, state); err != nil {
+		slog.Warn("Could not save job state", "job", state.Name, "error", err)
+	}
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
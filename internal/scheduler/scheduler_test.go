@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStopDoesNotWaitForStuckJobs(t *testing.T) {
+	s := New(nil)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	err := s.Add(Job{
+		Name: "stuck",
+		Spec: "@every 1s",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-release // Ignores ctx
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	err = s.Stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(begin); elapsed > cancelGrace+time.Second {
+		t.Errorf("Stop() took %v, want it bounded by the grace period", elapsed)
+	}
+}
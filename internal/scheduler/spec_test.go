@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@fortnightly",
+		"@every",
+		"@every soon",
+		"@every 500ms",
+	}
+
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("Parse(%q) error = nil, want an error", spec)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// Monday 10 June 2024, 12:30:45
+	from := time.Date(2024, 6, 10, 12, 30, 45, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", at(6, 10, 12, 31)},
+		{"*/5 * * * *", at(6, 10, 12, 35)},
+		{"30 * * * *", at(6, 10, 13, 30)},
+		{"0 3 * * *", at(6, 11, 3, 0)},
+		{"15,45 12 * * *", at(6, 10, 12, 45)},
+		{"0 9-17/4 * * *", at(6, 10, 13, 0)},
+		{"10/20 * * * *", at(6, 10, 12, 50)},
+		{"0 0 1 * *", at(7, 1, 0, 0)},
+		{"0 0 * * sun", at(6, 16, 0, 0)},
+		{"0 0 * * 7", at(6, 16, 0, 0)},
+		{"0 0 * * MON-FRI", at(6, 11, 0, 0)},
+		{"0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches
+		{"0 0 13 * 3", at(6, 12, 0, 0)},
+		{"0 0 31 4 *", time.Time{}},
+		{"@hourly", at(6, 10, 13, 0)},
+		{"@daily", at(6, 11, 0, 0)},
+		{"@weekly", at(6, 16, 0, 0)},
+		{"@monthly", at(7, 1, 0, 0)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{" @every 90m ", time.Date(2024, 6, 10, 14, 0, 45, 0, time.UTC)},
+		{"@every 1500ms", time.Date(2024, 6, 10, 12, 30, 46, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextIsStrictlyAfter(t *testing.T) {
+	schedule, err := Parse("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	onTheHour := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	if got, want := schedule.Next(onTheHour), onTheHour.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", onTheHour, got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// A4 page size in points
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+type font string
+
+const (
+	fontRegular font = "F1"
+	fontBold    font = "F2"
+)
+
+// polishDifferences remaps WinAnsi slots to the Windows-1250 layout for
+// Polish letters. All glyphs exist in the standard Helvetica fonts, so no
+// font has to be embedded.
+const polishDifferences = "[140 /Sacute 143 /Zacute 156 /sacute 159 /zacute 163 /Lslash 165 /Aogonek " +
+	"175 /Zdotaccent 179 /lslash 185 /aogonek 191 /zdotaccent 198 /Cacute 202 /Eogonek " +
+	"209 /Nacute 230 /cacute 234 /eogonek 241 /nacute]"
+
+// pdfDocument is a minimal PDF writer for text and rules on A4 pages
+type pdfDocument struct {
+	pages   []*bytes.Buffer
+	current int
+}
+
+func (d *pdfDocument) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.current = len(d.pages) - 1
+}
+
+// selectPage makes an earlier page the drawing target again
+func (d *pdfDocument) selectPage(i int) {
+	d.current = i
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[d.current]
+}
+
+// text draws s with its baseline at (x, y), measured from the top-left corner
+func (d *pdfDocument) text(x, y float64, f font, size float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", f, size, x, pageHeight-y, encodeText(s))
+}
+
+// textRight draws s so that it ends at x
+func (d *pdfDocument) textRight(x, y float64, f font, size float64, s string) {
+	d.text(x-textWidth(s, f, size), y, f, size, s)
+}
+
+// line draws a thin horizontal or vertical rule
+func (d *pdfDocument) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+// fill draws a light grey rectangle behind table headers
+func (d *pdfDocument) fill(x, y, w, h float64) {
+	fmt.Fprintf(d.page(), "0.93 g %.2f %.2f %.2f %.2f re f 0 g\n", x, pageHeight-y-h, w, h)
+}
+
+// writeTo serializes the document with a cross-reference table
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Fixed objects: 1 catalog, 2 page tree, 3 encoding, 4-5 fonts
+	pageIDs := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIDs, " "), len(d.pages)))
+	object("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences " + polishDifferences + " >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding 3 0 R >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding 3 0 R >>")
+
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// encodeText converts UTF-8 to Windows-1250 and escapes PDF string syntax
+func encodeText(s string) string {
+	encoded, err := charmap.Windows1250.NewEncoder().String(s)
+	if err != nil {
+		// Fall back character by character so one odd glyph doesn't blank the line
+		var b strings.Builder
+		for _, r := range s {
+			if c, ok := charmap.Windows1250.EncodeRune(r); ok {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+		encoded = b.String()
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(encoded); i++ {
+		switch c := encoded[i]; c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Glyph widths of the standard Helvetica fonts for ASCII 32-126, in 1/1000 em
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// accentBase maps Polish letters to the ASCII letter with the same width
+var accentBase = strings.NewReplacer(
+	"ą", "a", "ć", "c", "ę", "e", "ł", "l", "ń", "n", "ó", "o", "ś", "s", "ź", "z", "ż", "z",
+	"Ą", "A", "Ć", "C", "Ę", "E", "Ł", "L", "Ń", "N", "Ó", "O", "Ś", "S", "Ź", "Z", "Ż", "Z",
+	"…", "...",
+)
+
+// textWidth measures s in points
+func textWidth(s string, f font, size float64) float64 {
+	widths := &helveticaWidths
+	if f == fontBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range accentBase.Replace(s) {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// fitText shortens s with an ellipsis so it fits in width points
+func fitText(s string, f font, size, width float64) string {
+	if textWidth(s, f, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"…", f, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
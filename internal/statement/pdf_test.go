@@ -0,0 +1,165 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	xrefEntry = regexp.MustCompile(`^(\d{10}) 00000 n $`)
+	lengthKey = regexp.MustCompile(`/Length (\d+) >>\nstream\n`)
+)
+
+// checkPDF verifies the file structure: header, trailer, that every xref
+// entry points at its object and that stream lengths are exact
+func checkPDF(t *testing.T, data []byte) {
+	t.Helper()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", data[:min(len(data), 16)])
+	}
+	if !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("missing %%%%EOF trailer")
+	}
+
+	startxref := bytes.LastIndex(data, []byte("startxref\n"))
+	if startxref < 0 {
+		t.Fatal("missing startxref")
+	}
+	fields := strings.Fields(string(data[startxref+len("startxref\n"):]))
+	xref, err := strconv.Atoi(fields[0])
+	if err != nil || xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %s does not point at the xref table", fields[0])
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	var first, count int
+	if _, err := fmt.Sscan(lines[1], &first, &count); err != nil || first != 0 {
+		t.Fatalf("xref subsection header %q, want 0 and a count", lines[1])
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("xref entry 0 = %q, want the free list head", lines[2])
+	}
+	for i := 1; i < count; i++ {
+		m := xrefEntry.FindStringSubmatch(lines[2+i])
+		if m == nil {
+			t.Fatalf("xref entry %d = %q, want 20 bytes in use", i, lines[2+i])
+		}
+		offset, _ := strconv.Atoi(m[1])
+		if want := strconv.Itoa(i) + " 0 obj\n"; !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i, data[offset:min(len(data), offset+12)], want)
+		}
+	}
+	if !strings.Contains(string(data[xref:]), "/Size "+strconv.Itoa(count)+" ") {
+		t.Errorf("trailer /Size does not match the %d xref entries", count)
+	}
+
+	for _, m := range lengthKey.FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		end := m[1] + length
+		if end > len(data) || !bytes.HasPrefix(data[end:], []byte("endstream")) {
+			t.Errorf("stream at %d: /Length %d does not end at endstream", m[1], length)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	doc := &pdfDocument{}
+	doc.addPage()
+	doc.text(40, 60, fontBold, 16, "Wyciąg (kopia) C:\\wyciągi")
+	doc.line(40, 70, 555, 70)
+	doc.addPage()
+	doc.fill(40, 60, 515, 14)
+	doc.textRight(555, 60, fontRegular, 9, "Strona 2 z 2")
+
+	var b bytes.Buffer
+	if err := doc.writeTo(&b); err != nil {
+		t.Fatalf("writeTo() error = %v", err)
+	}
+	data := b.Bytes()
+	checkPDF(t, data)
+
+	if !bytes.Contains(data, []byte("/Kids [6 0 R 8 0 R] /Count 2")) {
+		t.Error("page tree does not list both pages")
+	}
+	if !bytes.Contains(data, []byte(`(Wyci`+"\xb9"+`g \(kopia\) C:\\wyci`+"\xb9"+`gi) Tj`)) {
+		t.Error("text is not Windows-1250 encoded with escaped delimiters")
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Saldo 100,00 zł", "Saldo 100,00 z\xb3"},
+		{"parentheses", "Wymiana walut (uznanie)", `Wymiana walut \(uznanie\)`},
+		{"unbalanced parenthesis", "a) b", `a\) b`},
+		{"backslash", `C:\wyciąg`, `C:\\wyci` + "\xb9g"},
+		{"escape sequence stays literal", `\n`, `\\n`},
+		{"not in Windows-1250", "Płatność ☕ kawa", "P\xb3atno\x9c\xe6 ? kawa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeText(tt.in); got != tt.want {
+				t.Errorf("encodeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolishDifferences checks that every Polish letter lands on a byte
+// the font encoding maps to the right glyph; WinAnsi slots the
+// Differences array does not override already hold ó and Ó
+func TestPolishDifferences(t *testing.T) {
+	differences := map[byte]string{}
+	fields := strings.Fields(strings.Trim(polishDifferences, "[]"))
+	for i := 0; i+1 < len(fields); i += 2 {
+		code, err := strconv.Atoi(fields[i])
+		if err != nil {
+			t.Fatalf("Differences entry %q is not a code", fields[i])
+		}
+		differences[byte(code)] = strings.TrimPrefix(fields[i+1], "/")
+	}
+	winAnsi := map[byte]string{0xD3: "Oacute", 0xF3: "oacute"}
+
+	glyphs := map[string]string{
+		"ą": "aogonek", "ć": "cacute", "ę": "eogonek", "ł": "lslash", "ń": "nacute",
+		"ó": "oacute", "ś": "sacute", "ź": "zacute", "ż": "zdotaccent",
+		"Ą": "Aogonek", "Ć": "Cacute", "Ę": "Eogonek", "Ł": "Lslash", "Ń": "Nacute",
+		"Ó": "Oacute", "Ś": "Sacute", "Ź": "Zacute", "Ż": "Zdotaccent",
+	}
+	for letter, glyph := range glyphs {
+		encoded := encodeText(letter)
+		if len(encoded) != 1 {
+			t.Errorf("encodeText(%s) = %q, want one byte", letter, encoded)
+			continue
+		}
+		got, ok := differences[encoded[0]]
+		if !ok {
+			got = winAnsi[encoded[0]]
+		}
+		if got != glyph {
+			t.Errorf("%s encodes as %d, which shows /%s, want /%s", letter, encoded[0], got, glyph)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	long := "Przelew za fakturę numer 2024/06/0001 dla Zakładu Gospodarki Komunalnej"
+	fitted := fitText(long, fontRegular, tableSize, 120)
+	if !strings.HasSuffix(fitted, "…") || !strings.HasPrefix(long, strings.TrimSuffix(fitted, "…")) {
+		t.Errorf("fitText() = %q, want a prefix of the text with an ellipsis", fitted)
+	}
+	if w := textWidth(fitted, fontRegular, tableSize); w > 120 {
+		t.Errorf("fitted text is %.1fpt wide, want at most 120", w)
+	}
+	if got := fitText("Czynsz", fontRegular, tableSize, 120); got != "Czynsz" {
+		t.Errorf("fitText() of short text = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,304 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/export"
+)
+
+// Statement is a single-currency account statement for one period.
+// Balances are encrypted on the client, so the opening and closing
+// balances are supplied by the caller; the transaction table always
+// comes from the server.
+type Statement struct {
+	AccountHolder  string
+	AccountID      string
+	Currency       string
+	From           time.Time // Inclusive
+	To             time.Time // Exclusive
+	OpeningBalance *int64    // Minor units, nil when not supplied
+	ClosingBalance *int64    // Minor units, nil when not supplied
+	GeneratedAt    time.Time
+
+	transactions []*database.Transaction
+}
+
+// TypeTotal sums completed transactions of one type
+type TypeTotal struct {
+	Type   string
+	Count  int
+	Amount int64 // Signed minor units
+}
+
+// Add appends a transaction; they are expected oldest first
+func (s *Statement) Add(t *database.Transaction) {
+	s.transactions = append(s.transactions, t)
+}
+
+// Len returns the number of transactions on the statement
+func (s *Statement) Len() int {
+	return len(s.transactions)
+}
+
+// Totals sums completed transactions by type. Pending and failed entries
+// are listed on the statement but do not move the balance.
+func (s *Statement) Totals() []TypeTotal {
+	byType := make(map[string]*TypeTotal)
+	for _, t := range s.transactions {
+		if t.Status != "completed" {
+			continue
+		}
+		total, ok := byType[t.Type]
+		if !ok {
+			total = &TypeTotal{Type: t.Type}
+			byType[t.Type] = total
+		}
+		total.Count++
+		total.Amount += export.SignedAmount(t)
+	}
+
+	totals := make([]TypeTotal, 0, len(byType))
+	for _, total := range byType {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Type < totals[j].Type })
+	return totals
+}
+
+// Net returns the balance movement over the period
+func (s *Statement) Net() int64 {
+	var net int64
+	for _, total := range s.Totals() {
+		net += total.Amount
+	}
+	return net
+}
+
+var typeLabels = map[string]string{
+	"deposit":      "Wpłata",
+	"withdrawal":   "Wypłata",
+	"payment":      "Płatność",
+	"exchange_in":  "Wymiana walut (uznanie)",
+	"exchange_out": "Wymiana walut (obciążenie)",
+}
+
+var statusLabels = map[string]string{
+	"completed": "Zrealizowana",
+	"pending":   "Oczekująca",
+	"failed":    "Nieudana",
+	"cancelled": "Anulowana",
+}
+
+func label(labels map[string]string, key string) string {
+	if l, ok := labels[key]; ok {
+		return l
+	}
+	return key
+}
+
+// Table layout in points
+const (
+	marginLeft   = 40.0
+	marginRight  = pageWidth - 40.0
+	contentTop   = 60.0
+	contentLimit = pageHeight - 60.0
+	rowHeight    = 14.0
+	tableSize    = 8.5
+
+	colDate        = marginLeft
+	colType        = marginLeft + 62
+	colDescription = marginLeft + 180
+	colStatus      = marginLeft + 390
+)
+
+// Render writes the statement as a PDF document
+func (s *Statement) Render(w io.Writer) error {
+	r := &renderer{doc: &pdfDocument{}, s: s}
+	r.render()
+	if err := r.doc.writeTo(w); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+	return nil
+}
+
+type renderer struct {
+	doc *pdfDocument
+	s   *Statement
+	y   float64
+}
+
+func (r *renderer) money(amount int64) string {
+	return currency.FormatMinor(amount, r.s.Currency)
+}
+
+func (r *renderer) newPage() {
+	r.doc.addPage()
+	r.y = contentTop
+}
+
+// ensure starts a new page when the next h points would not fit
+func (r *renderer) ensure(h float64) bool {
+	if r.y+h <= contentLimit {
+		return false
+	}
+	r.newPage()
+	return true
+}
+
+func (r *renderer) render() {
+	s := r.s
+	r.newPage()
+
+	r.doc.text(marginLeft, r.y, fontBold, 16, "Wyciąg z rachunku")
+	r.doc.textRight(marginRight, r.y, fontRegular, 9, "Pocket Wallet")
+	r.y += 26
+
+	last := s.To.AddDate(0, 0, -1)
+	details := [][2]string{
+		{"Posiadacz rachunku", s.AccountHolder},
+		{"Numer rachunku", s.AccountID},
+		{"Okres", fmt.Sprintf("%s – %s", s.From.Format("02.01.2006"), last.Format("02.01.2006"))},
+		{"Waluta", s.Currency},
+		{"Data wygenerowania", s.GeneratedAt.Format("02.01.2006 15:04")},
+	}
+	for _, d := range details {
+		r.doc.text(marginLeft, r.y, fontRegular, 9, d[0]+":")
+		r.doc.text(marginLeft+110, r.y, fontBold, 9, d[1])
+		r.y += 13
+	}
+	r.y += 8
+
+	r.balances()
+	r.y += 12
+
+	r.table()
+	r.y += 16
+
+	r.totals()
+	r.footers()
+}
+
+// balances prints the opening and closing balances. A missing value is
+// derived from the other one and the period's movement; when both are
+// given but disagree with the transactions the difference is flagged.
+func (r *renderer) balances() {
+	s := r.s
+	net := s.Net()
+
+	opening, closing := s.OpeningBalance, s.ClosingBalance
+	openingNote, closingNote := "", ""
+	switch {
+	case opening != nil && closing == nil:
+		c := *opening + net
+		closing, closingNote = &c, " (wyliczone)"
+	case opening == nil && closing != nil:
+		o := *closing - net
+		opening, openingNote = &o, " (wyliczone)"
+	}
+
+	row := func(name string, value *int64, note string) {
+		r.doc.text(marginLeft, r.y, fontRegular, 10, name)
+		text := "nie podano"
+		if value != nil {
+			text = r.money(*value) + note
+		}
+		r.doc.textRight(marginRight, r.y, fontBold, 10, text)
+		r.y += 15
+	}
+
+	r.doc.line(marginLeft, r.y-11, marginRight, r.y-11)
+	row("Saldo początkowe", opening, openingNote)
+	row("Suma operacji w okresie", &net, "")
+	row("Saldo końcowe", closing, closingNote)
+	r.doc.line(marginLeft, r.y-10, marginRight, r.y-10)
+
+	if s.OpeningBalance != nil && s.ClosingBalance != nil {
+		if diff := *s.ClosingBalance - (*s.OpeningBalance + net); diff != 0 {
+			r.y += 4
+			r.doc.text(marginLeft, r.y, fontBold, 9, fmt.Sprintf(
+				"Uwaga: saldo końcowe różni się od salda początkowego powiększonego o operacje o %s.", r.money(diff)))
+			r.y += 13
+		}
+	}
+}
+
+func (r *renderer) tableHeader() {
+	r.doc.fill(marginLeft, r.y-10, marginRight-marginLeft, rowHeight)
+	r.doc.text(colDate+2, r.y, fontBold, tableSize, "Data")
+	r.doc.text(colType, r.y, fontBold, tableSize, "Typ")
+	r.doc.text(colDescription, r.y, fontBold, tableSize, "Opis")
+	r.doc.text(colStatus, r.y, fontBold, tableSize, "Status")
+	r.doc.textRight(marginRight-2, r.y, fontBold, tableSize, "Kwota")
+	r.y += rowHeight + 2
+}
+
+func (r *renderer) table() {
+	r.ensure(3 * rowHeight)
+	r.tableHeader()
+
+	if len(r.s.transactions) == 0 {
+		r.doc.text(colDate+2, r.y, fontRegular, tableSize, "Brak operacji w tym okresie.")
+		r.y += rowHeight
+		return
+	}
+
+	for _, t := range r.s.transactions {
+		if r.ensure(rowHeight) {
+			r.tableHeader()
+		}
+
+		description := t.Description
+		if t.Counterparty != "" && t.Counterparty != description {
+			description = t.Counterparty + " – " + description
+		}
+
+		r.doc.text(colDate+2, r.y, fontRegular, tableSize, t.CreatedAt.Format("02.01.2006"))
+		r.doc.text(colType, r.y, fontRegular, tableSize, fitText(label(typeLabels, t.Type), fontRegular, tableSize, colDescription-colType-6))
+		r.doc.text(colDescription, r.y, fontRegular, tableSize, fitText(description, fontRegular, tableSize, colStatus-colDescription-6))
+		r.doc.text(colStatus, r.y, fontRegular, tableSize, label(statusLabels, t.Status))
+		r.doc.textRight(marginRight-2, r.y, fontRegular, tableSize, r.money(export.SignedAmount(t)))
+		r.y += rowHeight
+	}
+	r.doc.line(marginLeft, r.y-rowHeight+4, marginRight, r.y-rowHeight+4)
+}
+
+func (r *renderer) totals() {
+	totals := r.s.Totals()
+	r.ensure(float64(len(totals)+3) * rowHeight)
+
+	r.doc.text(marginLeft, r.y, fontBold, 11, "Podsumowanie według typu (operacje zrealizowane)")
+	r.y += rowHeight + 4
+
+	r.doc.fill(marginLeft, r.y-10, marginRight-marginLeft, rowHeight)
+	r.doc.text(colDate+2, r.y, fontBold, tableSize, "Typ")
+	r.doc.textRight(colStatus+40, r.y, fontBold, tableSize, "Liczba")
+	r.doc.textRight(marginRight-2, r.y, fontBold, tableSize, "Suma")
+	r.y += rowHeight + 2
+
+	for _, total := range totals {
+		r.doc.text(colDate+2, r.y, fontRegular, tableSize, label(typeLabels, total.Type))
+		r.doc.textRight(colStatus+40, r.y, fontRegular, tableSize, fmt.Sprint(total.Count))
+		r.doc.textRight(marginRight-2, r.y, fontRegular, tableSize, r.money(total.Amount))
+		r.y += rowHeight
+	}
+	if len(totals) == 0 {
+		r.doc.text(colDate+2, r.y, fontRegular, tableSize, "Brak zrealizowanych operacji.")
+		r.y += rowHeight
+	}
+}
+
+// footers numbers the pages once their count is known
+func (r *renderer) footers() {
+	count := len(r.doc.pages)
+	for i := range r.doc.pages {
+		r.doc.selectPage(i)
+		r.doc.line(marginLeft, pageHeight-40, marginRight, pageHeight-40)
+		r.doc.text(marginLeft, pageHeight-28, fontRegular, 7.5, r.s.AccountHolder+" · "+r.s.AccountID)
+		r.doc.textRight(marginRight, pageHeight-28, fontRegular, 7.5, fmt.Sprintf("Strona %d z %d", i+1, count))
+	}
+}
@@ -0,0 +1,187 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"pocket-wallet/internal/database"
+)
+
+var june = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func juneStatement(opening, closing *int64) *Statement {
+	s := &Statement{
+		AccountHolder:  "Jan Kowalski (konto osobiste)",
+		AccountID:      "PW-0001",
+		Currency:       "PLN",
+		From:           june,
+		To:             june.AddDate(0, 1, 0),
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		GeneratedAt:    june.AddDate(0, 1, 0),
+	}
+	add := func(day int, txType, status string, amount float64, description string) {
+		s.Add(&database.Transaction{
+			Type:        txType,
+			Amount:      amount,
+			Currency:    "PLN",
+			Status:      status,
+			Description: description,
+			CreatedAt:   june.AddDate(0, 0, day),
+		})
+	}
+	add(1, "deposit", "completed", 500, "Doładowanie portfela")
+	add(3, "payment", "completed", 120.50, "Zakupy (spożywcze)")
+	add(5, "payment", "completed", 9.50, `Bilet \ ulgowy`)
+	add(7, "withdrawal", "pending", 200, "Wypłata na konto")
+	add(9, "deposit", "failed", 1000, "Doładowanie odrzucone")
+	add(12, "exchange_out", "completed", 40, "Wymiana PLN na EUR")
+	return s
+}
+
+func TestTotals(t *testing.T) {
+	s := juneStatement(nil, nil)
+
+	want := []TypeTotal{
+		{Type: "deposit", Count: 1, Amount: 50000},
+		{Type: "exchange_out", Count: 1, Amount: -4000},
+		{Type: "payment", Count: 2, Amount: -13000},
+	}
+	got := s.Totals()
+	if len(got) != len(want) {
+		t.Fatalf("Totals() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Totals()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if net := s.Net(); net != 33000 {
+		t.Errorf("Net() = %d, want 33000", net)
+	}
+	if s.Len() != 6 {
+		t.Errorf("Len() = %d, want every transaction listed, pending and failed too", s.Len())
+	}
+
+	empty := &Statement{Currency: "PLN"}
+	if totals := empty.Totals(); len(totals) != 0 || empty.Net() != 0 {
+		t.Errorf("empty statement totals %+v, net %d, want none", totals, empty.Net())
+	}
+}
+
+func balance(minor int64) *int64 { return &minor }
+
+func TestRenderBalances(t *testing.T) {
+	tests := []struct {
+		name    string
+		opening *int64
+		closing *int64
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "closing derived",
+			opening: balance(100000),
+			want:    []string{"1000.00 PLN", "1330.00 PLN (wyliczone)"},
+			notWant: []string{"Uwaga"},
+		},
+		{
+			name:    "opening derived",
+			closing: balance(100000),
+			want:    []string{"670.00 PLN (wyliczone)", "1000.00 PLN"},
+			notWant: []string{"Uwaga"},
+		},
+		{
+			name:    "both agree",
+			opening: balance(100000),
+			closing: balance(133000),
+			want:    []string{"1000.00 PLN", "1330.00 PLN"},
+			notWant: []string{"wyliczone", "Uwaga"},
+		},
+		{
+			name:    "both disagree",
+			opening: balance(100000),
+			closing: balance(130000),
+			want:    []string{"Uwaga: saldo końcowe różni się od salda początkowego powiększonego o operacje o -30.00 PLN."},
+		},
+		{
+			name: "neither",
+			want: []string{"nie podano"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := juneStatement(tt.opening, tt.closing).Render(&b); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			checkPDF(t, b.Bytes())
+
+			for _, text := range append(tt.want, "330.00 PLN") {
+				if !containsText(b.Bytes(), text) {
+					t.Errorf("statement does not show %q", text)
+				}
+			}
+			for _, text := range tt.notWant {
+				if bytes.Contains(b.Bytes(), []byte(encodeText(text))) {
+					t.Errorf("statement shows %q", text)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTotalsAndEscaping(t *testing.T) {
+	var b bytes.Buffer
+	if err := juneStatement(balance(0), nil).Render(&b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	data := b.Bytes()
+
+	for _, text := range []string{
+		"Okres:", "01.06.2024 – 30.06.2024",
+		"Wymiana walut (obciążenie)", "-40.00 PLN",
+		"Płatność", "-130.00 PLN",
+		"Wpłata", "500.00 PLN",
+		"Zakupy (spożywcze)", `Bilet \ ulgowy`, "Jan Kowalski (konto osobiste)",
+		"Oczekująca", "Nieudana",
+	} {
+		if !containsText(data, text) {
+			t.Errorf("statement does not show %q", text)
+		}
+	}
+	if bytes.Contains(data, []byte("(Zakupy (spo")) {
+		t.Error("parentheses in a description are not escaped")
+	}
+}
+
+func TestRenderPages(t *testing.T) {
+	s := &Statement{AccountHolder: "Jan Kowalski", AccountID: "PW-0001", Currency: "PLN", From: june, To: june.AddDate(0, 1, 0)}
+	for i := 0; i < 120; i++ {
+		s.Add(&database.Transaction{Type: "payment", Amount: 1, Currency: "PLN", Status: "completed", Description: fmt.Sprintf("Opłata %d", i), CreatedAt: june})
+	}
+
+	var b bytes.Buffer
+	if err := s.Render(&b); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	checkPDF(t, b.Bytes())
+
+	pages := bytes.Count(b.Bytes(), []byte("/Type /Page "))
+	if pages < 3 {
+		t.Fatalf("120 rows fit on %d pages, want a table spanning at least 3", pages)
+	}
+	if !containsText(b.Bytes(), fmt.Sprintf("Strona %d z %d", pages, pages)) {
+		t.Errorf("last page is not numbered %d of %d", pages, pages)
+	}
+	if headers := bytes.Count(b.Bytes(), []byte("(Kwota) Tj")); headers != pages {
+		t.Errorf("table header drawn %d times, want once per page (%d)", headers, pages)
+	}
+}
+
+// containsText reports whether text is drawn as one string in the PDF
+func containsText(data []byte, text string) bool {
+	return bytes.Contains(data, []byte("("+encodeText(text)+") Tj"))
+}
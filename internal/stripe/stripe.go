@@ -1,18 +1,29 @@
 package stripe
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+	"pocket-wallet/internal/payments"
+	"pocket-wallet/internal/tracing"
 	"pocket-wallet/pkg/config"
 
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/paymentintent"
 	"github.com/stripe/stripe-go/v76/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Local types matching main package
 type StripePaymentIntentRequest struct {
-	UserID string `json:"user_id"`
-	Amount int64  `json:"amount"` // Amount in cents
+	UserID   string `json:"user_id"`
+	Amount   int64  `json:"amount"`   // Amount in minor units
+	Currency string `json:"currency"` // ISO-4217 code
 }
 
 type StripePaymentIntentResponse struct {
@@ -31,19 +42,24 @@ func NewStripeService(cfg *config.Config) *StripeService {
 	}
 }
 
-func (s *StripeService) CreatePaymentIntent(req *StripePaymentIntentRequest) (*StripePaymentIntentResponse, error) {
+func (s *StripeService) CreatePaymentIntent(ctx context.Context, req *StripePaymentIntentRequest) (_ *StripePaymentIntentResponse, err error) {
+	ctx, span := startSpan(ctx, "CreatePaymentIntent")
+	defer func() { tracing.End(span, err) }()
+
 	params := &stripe.PaymentIntentParams{
 		Amount:   stripe.Int64(req.Amount),
-		Currency: stripe.String(string(stripe.CurrencyPLN)),
+		Currency: stripe.String(strings.ToLower(req.Currency)),
 		Metadata: map[string]string{
 			"user_id": req.UserID,
 		},
 	}
+	params.Context = ctx
 
 	pi, err := paymentintent.New(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+		return nil, providerError("failed to create payment intent", err)
 	}
+	span.SetAttributes(tracing.PaymentID(pi.ID))
 
 	return &StripePaymentIntentResponse{
 		ClientSecret: pi.ClientSecret,
@@ -58,3 +74,126 @@ func (s *StripeService) VerifyWebhookSignature(payload []byte, signature string)
 	}
 	return nil
 }
+
+// Name identifies the provider in reconciliation reports and as the
+// counterparty of deposits
+func (s *StripeService) Name() string {
+	return "Stripe"
+}
+
+// ListPayments returns the PaymentIntents created in [from, to)
+func (s *StripeService) ListPayments(ctx context.Context, from, to time.Time) (_ []*payments.Payment, err error) {
+	ctx, span := startSpan(ctx, "ListPayments")
+	defer func() { tracing.End(span, err) }()
+
+	params := &stripe.PaymentIntentListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.Context = ctx
+	params.Limit = stripe.Int64(100)
+
+	var result []*payments.Payment
+	iter := paymentintent.List(params)
+	for iter.Next() {
+		result = append(result, paymentFromIntent(iter.PaymentIntent()))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, providerError("failed to list payment intents", err)
+	}
+
+	return result, nil
+}
+
+// GetPayment fetches a single PaymentIntent
+func (s *StripeService) GetPayment(ctx context.Context, id string) (_ *payments.Payment, err error) {
+	ctx, span := startSpan(ctx, "GetPayment", tracing.PaymentID(id))
+	defer func() { tracing.End(span, err) }()
+
+	params := &stripe.PaymentIntentParams{}
+	params.Context = ctx
+
+	pi, err := paymentintent.Get(id, params)
+	if err != nil {
+		return nil, providerError("failed to get payment intent", err)
+	}
+
+	return paymentFromIntent(pi), nil
+}
+
+// CancelPayment cancels an abandoned PaymentIntent
+func (s *StripeService) CancelPayment(ctx context.Context, id string) (_ *payments.Payment, err error) {
+	ctx, span := startSpan(ctx, "CancelPayment", tracing.PaymentID(id))
+	defer func() { tracing.End(span, err) }()
+
+	params := &stripe.PaymentIntentCancelParams{
+		CancellationReason: stripe.String(string(stripe.PaymentIntentCancellationReasonAbandoned)),
+	}
+	params.Context = ctx
+
+	pi, err := paymentintent.Cancel(id, params)
+	if err != nil {
+		return nil, providerError("failed to cancel payment intent", err)
+	}
+
+	return paymentFromIntent(pi), nil
+}
+
+// startSpan traces one call to the Stripe API
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "Stripe."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// providerError gives a Stripe API error its code: card errors are
+// declines, a missing object is ErrPaymentNotFound and anything else is a
+// provider failure
+func providerError(message string, err error) error {
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) {
+		switch {
+		case stripeErr.Type == stripe.ErrorTypeCard:
+			return errcode.Wrap(errcode.PaymentDeclined, message, err)
+		case stripeErr.Code == stripe.ErrorCodeResourceMissing:
+			return payments.ErrPaymentNotFound
+		}
+	}
+	return errcode.Wrap(errcode.PaymentFailed, message, err)
+}
+
+// paymentFromIntent maps a PaymentIntent onto transaction statuses. An
+// intent waiting for a new payment method after a declined attempt counts
+// as failed; every other unfinished state is still pending.
+func paymentFromIntent(pi *stripe.PaymentIntent) *payments.Payment {
+	status := "pending"
+	cancellable := false
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		status = "completed"
+	case stripe.PaymentIntentStatusCanceled:
+		status = "cancelled"
+	case stripe.PaymentIntentStatusRequiresPaymentMethod:
+		if pi.LastPaymentError != nil {
+			status = "failed"
+		}
+		cancellable = true
+	case stripe.PaymentIntentStatusRequiresConfirmation,
+		stripe.PaymentIntentStatusRequiresAction,
+		stripe.PaymentIntentStatusRequiresCapture:
+		cancellable = true
+	}
+
+	return &payments.Payment{
+		ID:             pi.ID,
+		UserID:         pi.Metadata["user_id"],
+		Amount:         pi.Amount,
+		Currency:       strings.ToUpper(string(pi.Currency)),
+		Status:         status,
+		ProviderStatus: string(pi.Status),
+		Cancellable:    cancellable,
+		CreatedAt:      time.Unix(pi.Created, 0),
+	}
+}
@@ -0,0 +1,54 @@
+package stripe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+func TestPaymentFromIntent(t *testing.T) {
+	declined := &stripe.Error{Code: stripe.ErrorCodeCardDeclined}
+
+	tests := []struct {
+		name            string
+		status          stripe.PaymentIntentStatus
+		lastError       *stripe.Error
+		wantStatus      string
+		wantCancellable bool
+	}{
+		{"succeeded", stripe.PaymentIntentStatusSucceeded, nil, "completed", false},
+		{"canceled", stripe.PaymentIntentStatusCanceled, nil, "cancelled", false},
+		{"awaiting a payment method", stripe.PaymentIntentStatusRequiresPaymentMethod, nil, "pending", true},
+		{"declined", stripe.PaymentIntentStatusRequiresPaymentMethod, declined, "failed", true},
+		{"awaiting confirmation", stripe.PaymentIntentStatusRequiresConfirmation, nil, "pending", true},
+		{"awaiting 3-D Secure", stripe.PaymentIntentStatusRequiresAction, nil, "pending", true},
+		{"authorized", stripe.PaymentIntentStatusRequiresCapture, nil, "pending", true},
+		{"processing", stripe.PaymentIntentStatusProcessing, nil, "pending", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := paymentFromIntent(&stripe.PaymentIntent{
+				ID:               "pi_1",
+				Amount:           2550,
+				Currency:         "eur",
+				Status:           tt.status,
+				LastPaymentError: tt.lastError,
+				Metadata:         map[string]string{"user_id": "u1"},
+				Created:          1718020800,
+			})
+			if p.Status != tt.wantStatus || p.Cancellable != tt.wantCancellable {
+				t.Errorf("status %s cancellable %v, want %s and %v", p.Status, p.Cancellable, tt.wantStatus, tt.wantCancellable)
+			}
+			if p.ProviderStatus != string(tt.status) {
+				t.Errorf("ProviderStatus = %q, want %q", p.ProviderStatus, tt.status)
+			}
+			if p.ID != "pi_1" || p.UserID != "u1" || p.Amount != 2550 || p.Currency != "EUR" {
+				t.Errorf("payment %s for %s: %d %s, want pi_1 for u1: 2550 EUR", p.ID, p.UserID, p.Amount, p.Currency)
+			}
+			if !p.CreatedAt.Equal(time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("CreatedAt = %v, want 2024-06-10 12:00 UTC", p.CreatedAt)
+			}
+		})
+	}
+}
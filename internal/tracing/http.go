@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"pocket-wallet/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware traces every request, continuing a trace the caller started
+// through W3C trace headers. Spans are named after the matched route and
+// their trace ID is added to the request's log records.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLPath(r.URL.Path)))
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = logging.With(ctx, "trace_id", sc.TraceID().String())
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		req := r.WithContext(ctx)
+		next.ServeHTTP(rec, req)
+
+		// The mux fills in the pattern while routing
+		if req.Pattern != "" {
+			route := req.Pattern
+			if !strings.HasPrefix(route, r.Method+" ") {
+				route = r.Method + " " + route
+			}
+			span.SetName(route)
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// statusRecorder remembers the response status
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush keeps streaming responses working through the recorder
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,97 @@
+// Package tracing sets up OpenTelemetry tracing. Without a configured
+// collector the global no-op tracer stays in place and spans cost nothing.
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"pocket-wallet/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the application in traces
+const ServiceName = "pocket-wallet"
+
+// Setup installs an OTLP/HTTP exporter when cfg.OTLPEndpoint is set. The
+// returned function flushes and stops it; it is safe to call either way.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	// Propagation is installed even without an exporter so incoming trace
+	// headers are passed on
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("Tracing enabled", "endpoint", cfg.OTLPEndpoint)
+
+	return provider.Shutdown, nil
+}
+
+// Start begins a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(ServiceName).Start(ctx, name, opts...)
+}
+
+// PaymentID tags a span with the provider's payment ID, which ties a
+// top-up to the webhooks that settle it
+func PaymentID(id string) attribute.KeyValue {
+	return attribute.String("payment.id", id)
+}
+
+// End marks span failed when err is set and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceParent encodes the span context in ctx as a W3C traceparent value,
+// empty when ctx has no sampled span
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier["traceparent"]
+}
+
+// LinkTo returns a link to the span encoded by TraceParent, or false when
+// traceParent is empty or invalid
+func LinkTo(traceParent string) (trace.Link, bool) {
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return trace.Link{}, false
+	}
+	return trace.Link{SpanContext: sc}, true
+}
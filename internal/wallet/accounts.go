@@ -0,0 +1,160 @@
+package wallet
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"pocket-wallet/internal/database"
+)
+
+// Register creates a user account. The client derives the salt, password
+// hash and encrypted balance; the server never sees the password.
+func (s *Service) Register(ctx context.Context, req database.RegisterRequest) (*database.User, error) {
+	if req.Login == "" || req.Email == "" || req.Salt == "" || req.PasswordHash == "" {
+		return nil, invalid("all fields are required")
+	}
+
+	if !strings.Contains(req.Email, "@") || !strings.Contains(req.Email, ".") {
+		return nil, invalid("invalid email format")
+	}
+
+	user, err := s.store.CreateUser(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "User registered", "user_id", user.UserID, "login", req.Login)
+	return user, nil
+}
+
+// UserByLogin looks up a user for the login process
+func (s *Service) UserByLogin(ctx context.Context, login string) (*database.User, error) {
+	if login == "" {
+		return nil, invalid("login is required")
+	}
+	return s.store.GetUserByLogin(ctx, login)
+}
+
+// User looks up a user by ID
+func (s *Service) User(ctx context.Context, userID string) (*database.User, error) {
+	if userID == "" {
+		return nil, invalid("user_id is required")
+	}
+	return s.store.GetUserByID(ctx, userID)
+}
+
+// Balance returns a user's encrypted balance and the ID of the last
+// deposit event credited to it
+func (s *Service) Balance(ctx context.Context, userID string) (string, int64, error) {
+	user, err := s.User(ctx, userID)
+	if err != nil {
+		return "", 0, err
+	}
+	var credited int64
+	if user.CreditedEventID != nil {
+		credited = *user.CreditedEventID
+	}
+	return user.EncryptedBalance, credited, nil
+}
+
+// UpdateBalance replaces a user's encrypted balance. With expected set the
+// update fails with ErrBalanceConflict unless the stored balance still
+// equals it. A positive creditedEventID marks the deposit event the new
+// balance adds; it needs expected, and an event credited before also fails
+// with ErrBalanceConflict.
+func (s *Service) UpdateBalance(ctx context.Context, userID, encryptedBalance string, expected *string, creditedEventID int64) error {
+	if userID == "" || encryptedBalance == "" {
+		return invalid("user_id and encrypted_balance are required")
+	}
+	if creditedEventID > 0 && expected == nil {
+		return invalid("expected_balance is required with credited_event_id")
+	}
+
+	var err error
+	if expected != nil {
+		err = s.store.UpdateUserBalanceIf(ctx, userID, *expected, encryptedBalance, creditedEventID)
+	} else {
+		err = s.store.UpdateUserBalance(ctx, userID, encryptedBalance)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Balance updated", "user_id", userID)
+	return nil
+}
+
+// LoginSalt returns the salt a client derives its password hash from. An
+// unknown login gets a made-up salt, the same on every call and shaped
+// like a real one, so the answer does not tell which logins exist.
+func (s *Service) LoginSalt(ctx context.Context, login string) (string, error) {
+	user, err := s.UserByLogin(ctx, login)
+	if errors.Is(err, ErrUserNotFound) {
+		return s.fakeSalt(login), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.Salt, nil
+}
+
+// fakeSalt derives a salt for a login no user has. Clients generate 16
+// random bytes, base64 encoded.
+func (s *Service) fakeSalt(login string) string {
+	mac := hmac.New(sha256.New, s.opts.SaltKey)
+	mac.Write([]byte(login))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Login is an opened API session
+type Login struct {
+	Token   string
+	Session *database.Session
+	User    *database.User
+}
+
+// Login checks a client-derived password hash and opens an API session.
+// An unknown login and a wrong hash fail the same way.
+func (s *Service) Login(ctx context.Context, login, passwordHash string) (*Login, error) {
+	if login == "" || passwordHash == "" {
+		return nil, invalid("login and password_hash are required")
+	}
+
+	user, err := s.store.GetUserByLogin(ctx, login)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, &LoginError{UnknownLogin: true}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(user.PasswordHash), []byte(passwordHash)) != 1 {
+		return nil, &LoginError{}
+	}
+
+	token, session, err := s.store.CreateSession(ctx, user.UserID, s.opts.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "API session opened", "user_id", user.UserID)
+	return &Login{Token: token, Session: session, User: user}, nil
+}
+
+// Authenticate resolves an API session token
+func (s *Service) Authenticate(ctx context.Context, token string) (*database.Session, error) {
+	if token == "" {
+		return nil, ErrSessionNotFound
+	}
+	return s.store.GetSession(ctx, token)
+}
+
+// Logout revokes an API session token
+func (s *Service) Logout(ctx context.Context, token string) error {
+	return s.store.DeleteSession(ctx, token)
+}
@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoginSalt(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "c2FsdC1vZi1hbGljZQ==", "hash")
+	s := New(store, nil, nil, Options{SessionTTL: time.Hour, SaltKey: []byte("secret")})
+	ctx := context.Background()
+
+	salt, err := s.LoginSalt(ctx, "alice")
+	if err != nil {
+		t.Fatalf("LoginSalt(alice) error = %v", err)
+	}
+	if salt != "c2FsdC1vZi1hbGljZQ==" {
+		t.Errorf("LoginSalt(alice) = %q, want the stored salt", salt)
+	}
+
+	fake, err := s.LoginSalt(ctx, "mallory")
+	if err != nil {
+		t.Fatalf("LoginSalt(mallory) error = %v, want a made-up salt", err)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(fake); err != nil || len(raw) != 16 {
+		t.Errorf("fake salt %q is not 16 base64 bytes like a client salt", fake)
+	}
+	if again, _ := s.LoginSalt(ctx, "mallory"); again != fake {
+		t.Errorf("fake salt changed between calls: %q, then %q", fake, again)
+	}
+	if other, _ := s.LoginSalt(ctx, "trudy"); other == fake {
+		t.Errorf("two unknown logins got the same salt %q", fake)
+	}
+	rekeyed := New(store, nil, nil, Options{SessionTTL: time.Hour, SaltKey: []byte("other secret")})
+	if other, _ := rekeyed.LoginSalt(ctx, "mallory"); other == fake {
+		t.Errorf("fake salt %q does not depend on the key", fake)
+	}
+
+	if _, err := s.LoginSalt(ctx, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("LoginSalt(\"\") error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "right")
+	s := newTestService(store)
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		login        string
+		hash         string
+		unknownLogin bool
+	}{
+		{"wrong hash", "alice", "wrong", false},
+		{"unknown login", "mallory", "right", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Login(ctx, tt.login, tt.hash)
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Login error = %v, want ErrInvalidCredentials", err)
+			}
+			if err.Error() != ErrInvalidCredentials.Error() {
+				t.Errorf("Login error reads %q, want %q", err, ErrInvalidCredentials)
+			}
+			var loginErr *LoginError
+			if !errors.As(err, &loginErr) || loginErr.UnknownLogin != tt.unknownLogin {
+				t.Errorf("UnknownLogin = %v, want %v", loginErr != nil && loginErr.UnknownLogin, tt.unknownLogin)
+			}
+		})
+	}
+
+	login, err := s.Login(ctx, "alice", "right")
+	if err != nil {
+		t.Fatalf("Login error = %v", err)
+	}
+	session, err := s.Authenticate(ctx, login.Token)
+	if err != nil || session.UserID != "u1" {
+		t.Errorf("Authenticate(token) = %v, %v, want the session of u1", session, err)
+	}
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+)
+
+// QuoteExchange prices selling amount minor units of one of a user's
+// sub-balances for another currency and stores the offer. It must be
+// executed with ExecuteExchange before it expires.
+func (s *Service) QuoteExchange(ctx context.Context, userID, fromCurrency, toCurrency string, amount int64) (*database.FXQuote, error) {
+	if s.quoter == nil {
+		return nil, ErrExchangeUnavailable
+	}
+	if userID == "" || amount <= 0 {
+		return nil, invalid("valid user_id and amount are required")
+	}
+
+	from, err := currency.Normalize(fromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	to, err := currency.Normalize(toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	quote, err := s.quoter.Quote(ctx, from, to, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote exchange: %w", err)
+	}
+
+	saved, err := s.store.CreateFXQuote(ctx, &database.FXQuote{
+		UserID:       userID,
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		SellAmount:   quote.SellAmount,
+		BuyAmount:    quote.BuyAmount,
+		MidRate:      quote.MidRate,
+		Rate:         quote.Rate,
+		SpreadBps:    quote.SpreadBps,
+		RateSource:   quote.Source,
+		RateDate:     quote.RateDate,
+		ExpiresAt:    quote.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save exchange quote: %w", err)
+	}
+	return saved, nil
+}
+
+// ExecuteExchange books a quoted conversion as a debit and a credit
+// transaction
+func (s *Service) ExecuteExchange(ctx context.Context, userID, quoteID string) (*database.FXConversion, error) {
+	if userID == "" || quoteID == "" {
+		return nil, invalid("user_id and quote_id are required")
+	}
+
+	conversion, err := s.store.ExecuteFXQuote(ctx, userID, quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute exchange: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Exchange executed",
+		"conversion_id", conversion.ConversionID, "user_id", userID,
+		"sold", currency.FormatMinor(conversion.SellAmount, conversion.FromCurrency),
+		"bought", currency.FormatMinor(conversion.BuyAmount, conversion.ToCurrency))
+	return conversion, nil
+}
+
+// Conversions lists a user's executed conversions, newest first
+func (s *Service) Conversions(ctx context.Context, userID string, limit int) ([]*database.FXConversion, error) {
+	if userID == "" {
+		return nil, invalid("user_id is required")
+	}
+
+	conversions, err := s.store.GetUserConversions(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversions: %w", err)
+	}
+	return conversions, nil
+}
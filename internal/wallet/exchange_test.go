@@ -0,0 +1,100 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pocket-wallet/internal/database"
+)
+
+func TestQuoteExchange(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "hash")
+	s := newTestService(store)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		userID   string
+		from, to string
+		amount   int64
+		wantErr  error
+	}{
+		{"no user", "", "PLN", "EUR", 100, ErrInvalidInput},
+		{"zero amount", "u1", "PLN", "EUR", 0, ErrInvalidInput},
+		{"unknown currency", "u1", "PLN", "XYZ", 100, ErrInvalidInput},
+		{"unknown user", "u2", "PLN", "EUR", 100, ErrUserNotFound},
+		{"same currency", "u1", "EUR", "eur", 100, ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.QuoteExchange(ctx, tt.userID, tt.from, tt.to, tt.amount); !errors.Is(err, tt.wantErr) {
+				t.Errorf("QuoteExchange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	quote, err := s.QuoteExchange(ctx, "u1", "pln", "eur", 4000)
+	if err != nil {
+		t.Fatalf("QuoteExchange() error = %v", err)
+	}
+	if quote.QuoteID == "" || quote.UserID != "u1" {
+		t.Errorf("quote %q for %q, want a stored quote for u1", quote.QuoteID, quote.UserID)
+	}
+	if quote.FromCurrency != "PLN" || quote.ToCurrency != "EUR" || quote.SellAmount != 4000 || quote.BuyAmount != 1000 {
+		t.Errorf("quote sells %d %s for %d %s, want 4000 PLN for 1000 EUR",
+			quote.SellAmount, quote.FromCurrency, quote.BuyAmount, quote.ToCurrency)
+	}
+
+	unavailable := New(store, nil, nil, Options{})
+	if _, err := unavailable.QuoteExchange(ctx, "u1", "PLN", "EUR", 4000); !errors.Is(err, ErrExchangeUnavailable) {
+		t.Errorf("QuoteExchange() without a rate source error = %v, want ErrExchangeUnavailable", err)
+	}
+}
+
+func TestExecuteExchange(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "hash")
+	store.addCompleted("u1", "deposit", 5000, "PLN")
+	s := newTestService(store)
+	ctx := context.Background()
+
+	quote, err := s.QuoteExchange(ctx, "u1", "PLN", "EUR", 4000)
+	if err != nil {
+		t.Fatalf("QuoteExchange() error = %v", err)
+	}
+
+	if _, err := s.ExecuteExchange(ctx, "u1", ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("ExecuteExchange() without quote_id error = %v, want ErrInvalidInput", err)
+	}
+	if _, err := s.ExecuteExchange(ctx, "u2", quote.QuoteID); !errors.Is(err, database.ErrQuoteNotFound) {
+		t.Errorf("ExecuteExchange() of another user's quote error = %v, want ErrQuoteNotFound", err)
+	}
+
+	conversion, err := s.ExecuteExchange(ctx, "u1", quote.QuoteID)
+	if err != nil {
+		t.Fatalf("ExecuteExchange() error = %v", err)
+	}
+	if conversion.QuoteID != quote.QuoteID || conversion.DebitTransactionID == "" || conversion.CreditTransactionID == "" {
+		t.Errorf("conversion = %+v, want both legs of quote %s", conversion, quote.QuoteID)
+	}
+	if _, err := s.ExecuteExchange(ctx, "u1", quote.QuoteID); !errors.Is(err, database.ErrQuoteUsed) {
+		t.Errorf("second ExecuteExchange() error = %v, want ErrQuoteUsed", err)
+	}
+
+	for code, want := range map[string]int64{"PLN": 1000, "EUR": 1000} {
+		balance, _ := s.AvailableBalance(ctx, "u1", code)
+		if balance.Total != want {
+			t.Errorf("%s balance = %d, want %d", code, balance.Total, want)
+		}
+	}
+
+	conversions, err := s.Conversions(ctx, "u1", 10)
+	if err != nil || len(conversions) != 1 || conversions[0].ConversionID != conversion.ConversionID {
+		t.Errorf("Conversions() = %v, %v, want the executed conversion", conversions, err)
+	}
+	if _, err := s.Conversions(ctx, "", 10); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Conversions() without user_id error = %v, want ErrInvalidInput", err)
+	}
+}
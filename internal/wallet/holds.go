@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+)
+
+// CreateHold reserves part of a user's available balance for a pending
+// withdrawal or payment. A zero TTL uses database.DefaultHoldTTL.
+func (s *Service) CreateHold(ctx context.Context, req database.HoldRequest) (*database.Hold, error) {
+	if req.UserID == "" || req.Amount <= 0 {
+		return nil, invalid("valid user_id and amount are required")
+	}
+	if req.Type != "withdrawal" && req.Type != "payment" {
+		return nil, invalid("hold type must be withdrawal or payment")
+	}
+
+	code, err := currency.Normalize(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	req.Currency = code
+
+	if _, err := s.store.GetUserByID(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	hold, err := s.store.CreateHold(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Hold created", "hold_id", hold.HoldID, "user_id", req.UserID, "amount", currency.FormatMinor(req.Amount, code))
+	return hold, nil
+}
+
+// CaptureHold debits a held amount, creating a completed transaction
+func (s *Service) CaptureHold(ctx context.Context, userID, holdID string, amount int64) (*database.Transaction, error) {
+	if userID == "" || holdID == "" {
+		return nil, invalid("user_id and hold_id are required")
+	}
+
+	transaction, err := s.store.CaptureHold(ctx, userID, holdID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Hold captured", "hold_id", holdID, "user_id", userID)
+	return transaction, nil
+}
+
+// ReleaseHold cancels a hold and makes its funds available again
+func (s *Service) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	if userID == "" || holdID == "" {
+		return invalid("user_id and hold_id are required")
+	}
+
+	if err := s.store.ReleaseHold(ctx, userID, holdID); err != nil {
+		return fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Hold released", "hold_id", holdID, "user_id", userID)
+	return nil
+}
+
+// Holds lists a user's active holds
+func (s *Service) Holds(ctx context.Context, userID string) ([]*database.Hold, error) {
+	if userID == "" {
+		return nil, invalid("user_id is required")
+	}
+
+	holds, err := s.store.GetUserHolds(ctx, userID, database.HoldStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holds: %w", err)
+	}
+	return holds, nil
+}
+
+// AvailableBalance returns a user's total, held and available ledger
+// balance in one currency
+func (s *Service) AvailableBalance(ctx context.Context, userID, currencyCode string) (*database.BalanceSummary, error) {
+	if userID == "" {
+		return nil, invalid("user_id is required")
+	}
+
+	code, err := currency.Normalize(currencyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.store.GetBalanceSummary(ctx, userID, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance summary: %w", err)
+	}
+	return summary, nil
+}
+
+// CurrencyBalances returns the ledger balance of every currency a user holds
+func (s *Service) CurrencyBalances(ctx context.Context, userID string) ([]*database.BalanceSummary, error) {
+	if userID == "" {
+		return nil, invalid("user_id is required")
+	}
+
+	summaries, err := s.store.GetBalanceSummaries(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance summaries: %w", err)
+	}
+	return summaries, nil
+}
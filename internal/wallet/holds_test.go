@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pocket-wallet/internal/database"
+)
+
+func TestCreateHold(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "hash")
+	store.addCompleted("u1", "deposit", 10000, "PLN")
+	s := newTestService(store)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     database.HoldRequest
+		wantErr error
+	}{
+		{"no user", database.HoldRequest{Type: "payment", Amount: 100}, ErrInvalidInput},
+		{"zero amount", database.HoldRequest{UserID: "u1", Type: "payment"}, ErrInvalidInput},
+		{"unknown type", database.HoldRequest{UserID: "u1", Type: "deposit", Amount: 100}, ErrInvalidInput},
+		{"unknown currency", database.HoldRequest{UserID: "u1", Type: "payment", Amount: 100, Currency: "XYZ"}, ErrInvalidInput},
+		{"unknown user", database.HoldRequest{UserID: "u2", Type: "payment", Amount: 100}, ErrUserNotFound},
+		{"over the balance", database.HoldRequest{UserID: "u1", Type: "withdrawal", Amount: 10001}, database.ErrInsufficientFunds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CreateHold(ctx, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateHold() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	hold, err := s.CreateHold(ctx, database.HoldRequest{UserID: "u1", Type: "payment", Amount: 2500, Currency: "pln"})
+	if err != nil {
+		t.Fatalf("CreateHold() error = %v", err)
+	}
+	if hold.Currency != "PLN" || hold.Status != database.HoldStatusActive {
+		t.Errorf("hold %s %s, want an active PLN hold", hold.Currency, hold.Status)
+	}
+
+	balance, err := s.AvailableBalance(ctx, "u1", "")
+	if err != nil {
+		t.Fatalf("AvailableBalance() error = %v", err)
+	}
+	if balance.Total != 10000 || balance.Held != 2500 || balance.Available != 7500 {
+		t.Errorf("balance = %+v, want 10000 total, 2500 held, 7500 available", balance)
+	}
+}
+
+func TestCaptureAndReleaseHold(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "hash")
+	store.addCompleted("u1", "deposit", 10000, "PLN")
+	s := newTestService(store)
+	ctx := context.Background()
+
+	captured, _ := s.CreateHold(ctx, database.HoldRequest{UserID: "u1", Type: "payment", Amount: 3000})
+	released, _ := s.CreateHold(ctx, database.HoldRequest{UserID: "u1", Type: "withdrawal", Amount: 2000})
+
+	if _, err := s.CaptureHold(ctx, "u1", "", 0); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("CaptureHold() without hold_id error = %v, want ErrInvalidInput", err)
+	}
+	if _, err := s.CaptureHold(ctx, "u2", captured.HoldID, 0); !errors.Is(err, database.ErrHoldNotFound) {
+		t.Errorf("CaptureHold() of another user's hold error = %v, want ErrHoldNotFound", err)
+	}
+
+	transaction, err := s.CaptureHold(ctx, "u1", captured.HoldID, 0)
+	if err != nil {
+		t.Fatalf("CaptureHold() error = %v", err)
+	}
+	if transaction.Type != "payment" || transaction.HoldID != captured.HoldID {
+		t.Errorf("captured into a %s for hold %q, want a payment for %s", transaction.Type, transaction.HoldID, captured.HoldID)
+	}
+	if err := s.ReleaseHold(ctx, "u1", released.HoldID); err != nil {
+		t.Fatalf("ReleaseHold() error = %v", err)
+	}
+	if err := s.ReleaseHold(ctx, "u1", released.HoldID); !errors.Is(err, database.ErrHoldNotActive) {
+		t.Errorf("second ReleaseHold() error = %v, want ErrHoldNotActive", err)
+	}
+
+	holds, err := s.Holds(ctx, "u1")
+	if err != nil || len(holds) != 0 {
+		t.Errorf("Holds() = %d holds, %v, want none active", len(holds), err)
+	}
+	balances, err := s.CurrencyBalances(ctx, "u1")
+	if err != nil {
+		t.Fatalf("CurrencyBalances() error = %v", err)
+	}
+	if len(balances) != 1 || balances[0].Total != 7000 || balances[0].Held != 0 {
+		t.Errorf("balances = %+v, want 7000 PLN with nothing held", balances)
+	}
+}
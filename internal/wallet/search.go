@@ -0,0 +1,36 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"pocket-wallet/internal/database"
+)
+
+// SearchTransactions finds a user's transactions matching a free-text
+// query over description, counterparty, notes and payment ID
+func (s *Service) SearchTransactions(ctx context.Context, userID, query string, limit int) ([]*database.SearchResult, error) {
+	if userID == "" || strings.TrimSpace(query) == "" {
+		return nil, invalid("user_id and query are required")
+	}
+
+	results, err := s.store.SearchTransactions(ctx, userID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	return results, nil
+}
+
+// UpdateTransactionNotes attaches searchable notes to one of a user's
+// transactions
+func (s *Service) UpdateTransactionNotes(ctx context.Context, userID, transactionID, notes string) error {
+	if userID == "" || transactionID == "" {
+		return invalid("user_id and transaction_id are required")
+	}
+
+	if err := s.store.UpdateTransactionNotes(ctx, userID, transactionID, notes); err != nil {
+		return fmt.Errorf("failed to update notes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pocket-wallet/internal/database"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "hash")
+	rent := store.addCompleted("u1", "payment", 150000, "PLN")
+	rent.Description = "Czynsz"
+	store.addCompleted("u1", "deposit", 5000, "PLN").Description = "Doładowanie portfela"
+	store.addCompleted("u2", "payment", 150000, "PLN").Description = "Czynsz"
+	s := newTestService(store)
+	ctx := context.Background()
+
+	for _, query := range []string{"", "   "} {
+		if _, err := s.SearchTransactions(ctx, "u1", query, 10); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("SearchTransactions(%q) error = %v, want ErrInvalidInput", query, err)
+		}
+	}
+
+	results, err := s.SearchTransactions(ctx, "u1", "czynsz", 10)
+	if err != nil {
+		t.Fatalf("SearchTransactions() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Transaction != rent {
+		t.Fatalf("SearchTransactions() = %d results, want only u1's rent", len(results))
+	}
+
+	if err := s.UpdateTransactionNotes(ctx, "u1", "", "note"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("UpdateTransactionNotes() without transaction_id error = %v, want ErrInvalidInput", err)
+	}
+	if err := s.UpdateTransactionNotes(ctx, "u2", rent.TransactionID, "note"); !errors.Is(err, database.ErrTransactionNotFound) {
+		t.Errorf("UpdateTransactionNotes() of another user's transaction error = %v, want ErrTransactionNotFound", err)
+	}
+	if err := s.UpdateTransactionNotes(ctx, "u1", rent.TransactionID, "za październik"); err != nil {
+		t.Fatalf("UpdateTransactionNotes() error = %v", err)
+	}
+	if results, _ := s.SearchTransactions(ctx, "u1", "październik", 10); len(results) != 1 {
+		t.Errorf("search by the new note found %d results, want 1", len(results))
+	}
+}
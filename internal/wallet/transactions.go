@@ -0,0 +1,100 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	stripeService "pocket-wallet/internal/stripe"
+	"pocket-wallet/internal/tracing"
+)
+
+// TopUp is a started card payment
+type TopUp struct {
+	ClientSecret string
+	PaymentID    string
+}
+
+// TopUp starts a card payment into a user's wallet and records it as a
+// pending deposit until the payment provider confirms it
+func (s *Service) TopUp(ctx context.Context, userID string, amount int64, currencyCode string) (*TopUp, error) {
+	if userID == "" || amount <= 0 {
+		return nil, invalid("valid user_id and amount are required")
+	}
+
+	code, err := currency.Normalize(currencyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce Stripe's per-currency minimum charge
+	if err := currency.ValidateStripeAmount(code, amount); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	intent, err := s.payments.CreatePaymentIntent(ctx, &stripeService.StripePaymentIntentRequest{
+		UserID:   userID,
+		Amount:   amount,
+		Currency: code,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.store.CreateTransaction(ctx, &database.TransactionRequest{
+		UserID:       userID,
+		Type:         "deposit",
+		Amount:       currency.FromMinor(amount, code),
+		Currency:     code,
+		Description:  fmt.Sprintf("Doładowanie portfela - %s", currency.FormatMinor(amount, code)),
+		Counterparty: "Stripe",
+		PaymentID:    intent.PaymentID,
+		// Lets the webhook that settles the payment link back to this call
+		TraceParent: tracing.TraceParent(ctx),
+	})
+	if err != nil {
+		// The payment is already open; reconciliation records it later
+		slog.WarnContext(ctx, "Could not record pending deposit", "payment_id", intent.PaymentID, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Payment intent created", "user_id", userID, "payment_id", intent.PaymentID, "amount", currency.FormatMinor(amount, code))
+	return &TopUp{ClientSecret: intent.ClientSecret, PaymentID: intent.PaymentID}, nil
+}
+
+// RecentTransactions returns a user's latest transactions and how many
+// they have in total
+func (s *Service) RecentTransactions(ctx context.Context, userID string, limit int) ([]*database.Transaction, int64, error) {
+	if _, err := s.User(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	transactions, err := s.store.GetUserTransactions(ctx, userID, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountUserTransactions(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved transactions", "user_id", userID, "count", len(transactions))
+	return transactions, total, nil
+}
+
+// QueryTransactions returns one page of a user's filtered history
+func (s *Service) QueryTransactions(ctx context.Context, q *database.TransactionQuery) (*database.TransactionPage, error) {
+	if q.UserID == "" {
+		return nil, invalid("user_id is required")
+	}
+	if q.MinAmount < 0 || q.MaxAmount < 0 || (q.MaxAmount > 0 && q.MinAmount > q.MaxAmount) {
+		return nil, invalid("invalid amount range")
+	}
+	return s.store.QueryTransactions(ctx, q)
+}
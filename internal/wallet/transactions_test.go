@@ -0,0 +1,55 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTopUp(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("u1", "alice", "salt", "hash")
+	payments := &fakePayments{}
+	s := New(store, payments, nil, Options{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		userID   string
+		amount   int64
+		currency string
+		wantErr  error
+	}{
+		{"no user", "", 1000, "PLN", ErrInvalidInput},
+		{"zero amount", "u1", 0, "PLN", ErrInvalidInput},
+		{"below the Stripe minimum", "u1", 1, "PLN", ErrInvalidInput},
+		{"unknown currency", "u1", 1000, "XYZ", ErrInvalidInput},
+		{"unknown user", "u2", 1000, "PLN", ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.TopUp(ctx, tt.userID, tt.amount, tt.currency); !errors.Is(err, tt.wantErr) {
+				t.Errorf("TopUp() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if len(payments.requests) != 0 {
+		t.Fatalf("%d payment intents opened for refused top-ups", len(payments.requests))
+	}
+
+	topUp, err := s.TopUp(ctx, "u1", 2550, "eur")
+	if err != nil {
+		t.Fatalf("TopUp() error = %v", err)
+	}
+	if req := payments.requests[0]; req.Currency != "EUR" || req.Amount != 2550 {
+		t.Errorf("payment intent for %d %s, want 2550 EUR", req.Amount, req.Currency)
+	}
+
+	pending := store.userTransactions("u1")
+	if len(pending) != 1 {
+		t.Fatalf("%d transactions recorded, want the pending deposit", len(pending))
+	}
+	if d := pending[0]; d.PaymentID != topUp.PaymentID || d.Status != "pending" || d.Amount != 25.50 || d.Currency != "EUR" {
+		t.Errorf("deposit = %s %s %v %s, want pending 25.50 EUR for %s", d.PaymentID, d.Status, d.Amount, d.Currency, topUp.PaymentID)
+	}
+}
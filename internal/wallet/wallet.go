@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/errcode"
+	"pocket-wallet/internal/fx"
+	stripeService "pocket-wallet/internal/stripe"
+)
+
+var (
+	// ErrInvalidInput matches every request the service refuses before
+	// touching any storage
+	ErrInvalidInput = errcode.New(errcode.InvalidInput, "invalid input")
+
+	ErrUserNotFound       = database.ErrUserNotFound
+	ErrLoginTaken         = database.ErrLoginTaken
+	ErrBalanceConflict    = database.ErrBalanceConflict
+	ErrSessionNotFound    = database.ErrSessionNotFound
+	ErrInvalidCredentials = errcode.New(errcode.InvalidCredentials, "invalid login or password")
+
+	// ErrExchangeUnavailable is returned when no rate source is configured
+	ErrExchangeUnavailable = errcode.New(errcode.Unavailable, "currency exchange not available")
+)
+
+// LoginError is a rejected login. Whatever the reason, it reads and
+// matches as ErrInvalidCredentials, so clients cannot tell which part was
+// wrong; UnknownLogin is for the caller's logs and metrics.
+type LoginError struct {
+	UnknownLogin bool // No user has the login; otherwise the password hash was wrong
+}
+
+func (e *LoginError) Error() string {
+	return ErrInvalidCredentials.Error()
+}
+
+func (e *LoginError) Unwrap() error {
+	return ErrInvalidCredentials
+}
+
+func invalid(format string, args ...any) error {
+	return errcode.New(errcode.InvalidInput, fmt.Sprintf(format, args...))
+}
+
+// Store is the persistence the service needs
+type Store interface {
+	CreateUser(ctx context.Context, req *database.RegisterRequest) (*database.User, error)
+	GetUserByLogin(ctx context.Context, login string) (*database.User, error)
+	GetUserByID(ctx context.Context, userID string) (*database.User, error)
+	UpdateUserBalance(ctx context.Context, userID, encryptedBalance string) error
+	UpdateUserBalanceIf(ctx context.Context, userID, expected, encryptedBalance string, creditedEventID int64) error
+
+	CreateSession(ctx context.Context, userID string, ttl time.Duration) (string, *database.Session, error)
+	GetSession(ctx context.Context, token string) (*database.Session, error)
+	DeleteSession(ctx context.Context, token string) error
+
+	CreateTransaction(ctx context.Context, req *database.TransactionRequest) (*database.Transaction, error)
+	GetUserTransactions(ctx context.Context, userID string, limit int) ([]*database.Transaction, error)
+	CountUserTransactions(ctx context.Context, userID string) (int64, error)
+	QueryTransactions(ctx context.Context, q *database.TransactionQuery) (*database.TransactionPage, error)
+	SearchTransactions(ctx context.Context, userID, query string, limit int) ([]*database.SearchResult, error)
+	UpdateTransactionNotes(ctx context.Context, userID, transactionID, notes string) error
+
+	CreateHold(ctx context.Context, req *database.HoldRequest) (*database.Hold, error)
+	CaptureHold(ctx context.Context, userID, holdID string, amount int64) (*database.Transaction, error)
+	ReleaseHold(ctx context.Context, userID, holdID string) error
+	GetUserHolds(ctx context.Context, userID, status string) ([]*database.Hold, error)
+	GetBalanceSummary(ctx context.Context, userID, code string) (*database.BalanceSummary, error)
+	GetBalanceSummaries(ctx context.Context, userID string) ([]*database.BalanceSummary, error)
+
+	CreateFXQuote(ctx context.Context, quote *database.FXQuote) (*database.FXQuote, error)
+	ExecuteFXQuote(ctx context.Context, userID, quoteID string) (*database.FXConversion, error)
+	GetUserConversions(ctx context.Context, userID string, limit int) ([]*database.FXConversion, error)
+}
+
+// PaymentGateway starts card payments
+type PaymentGateway interface {
+	CreatePaymentIntent(ctx context.Context, req *stripeService.StripePaymentIntentRequest) (*stripeService.StripePaymentIntentResponse, error)
+}
+
+// Quoter prices currency conversions, see fx.Exchanger
+type Quoter interface {
+	Quote(ctx context.Context, from, to string, amount int64) (*fx.Quote, error)
+}
+
+// Options tune a service
+type Options struct {
+	SessionTTL time.Duration // How long API sessions last
+	SaltKey    []byte        // Derives the salts handed out for unknown logins, see LoginSalt
+}
+
+// Service holds the account, session, top-up, transaction history, hold,
+// currency exchange and search operations shared by the Wails bindings,
+// the REST API and the command line. It does no instrumentation; callers
+// record metrics and spans.
+type Service struct {
+	store    Store
+	payments PaymentGateway
+	quoter   Quoter
+	opts     Options
+}
+
+// New creates a service. quoter may be nil when no rate source is
+// configured; exchanges then fail with ErrExchangeUnavailable.
+func New(store Store, payments PaymentGateway, quoter Quoter, opts Options) *Service {
+	return &Service{store: store, payments: payments, quoter: quoter, opts: opts}
+}
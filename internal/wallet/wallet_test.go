@@ -0,0 +1,382 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/fx"
+	stripeService "pocket-wallet/internal/stripe"
+)
+
+// fakeStore keeps users, sessions, transactions, holds and exchange
+// quotes in memory
+type fakeStore struct {
+	users        map[string]*database.User // By user ID
+	sessions     map[string]*database.Session
+	transactions []*database.Transaction
+	holds        map[string]*database.Hold
+	quotes       map[string]*database.FXQuote
+	conversions  []*database.FXConversion
+	nextID       int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:    map[string]*database.User{},
+		sessions: map[string]*database.Session{},
+		holds:    map[string]*database.Hold{},
+		quotes:   map[string]*database.FXQuote{},
+	}
+}
+
+func (f *fakeStore) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+// addUser stores a user with a known ID and login
+func (f *fakeStore) addUser(userID, login, salt, passwordHash string) *database.User {
+	user := &database.User{UserID: userID, Login: login, Salt: salt, PasswordHash: passwordHash, CreatedAt: time.Now()}
+	f.users[userID] = user
+	return user
+}
+
+func (f *fakeStore) CreateUser(ctx context.Context, req *database.RegisterRequest) (*database.User, error) {
+	if _, err := f.GetUserByLogin(ctx, req.Login); err == nil {
+		return nil, database.ErrLoginTaken
+	}
+	return f.addUser(f.id("user"), req.Login, req.Salt, req.PasswordHash), nil
+}
+
+func (f *fakeStore) GetUserByLogin(ctx context.Context, login string) (*database.User, error) {
+	for _, user := range f.users {
+		if user.Login == login {
+			return user, nil
+		}
+	}
+	return nil, database.ErrUserNotFound
+}
+
+func (f *fakeStore) GetUserByID(ctx context.Context, userID string) (*database.User, error) {
+	if user, ok := f.users[userID]; ok {
+		return user, nil
+	}
+	return nil, database.ErrUserNotFound
+}
+
+func (f *fakeStore) UpdateUserBalance(ctx context.Context, userID, encryptedBalance string) error {
+	user, err := f.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.EncryptedBalance = encryptedBalance
+	return nil
+}
+
+func (f *fakeStore) UpdateUserBalanceIf(ctx context.Context, userID, expected, encryptedBalance string, creditedEventID int64) error {
+	user, err := f.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EncryptedBalance != expected {
+		return database.ErrBalanceConflict
+	}
+	if creditedEventID > 0 {
+		if user.CreditedEventID != nil && *user.CreditedEventID >= creditedEventID {
+			return database.ErrBalanceConflict
+		}
+		user.CreditedEventID = &creditedEventID
+	}
+	user.EncryptedBalance = encryptedBalance
+	return nil
+}
+
+func (f *fakeStore) CreateSession(ctx context.Context, userID string, ttl time.Duration) (string, *database.Session, error) {
+	token := f.id("token")
+	session := &database.Session{UserID: userID, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(ttl)}
+	f.sessions[token] = session
+	return token, session, nil
+}
+
+func (f *fakeStore) GetSession(ctx context.Context, token string) (*database.Session, error) {
+	if session, ok := f.sessions[token]; ok {
+		return session, nil
+	}
+	return nil, database.ErrSessionNotFound
+}
+
+func (f *fakeStore) DeleteSession(ctx context.Context, token string) error {
+	delete(f.sessions, token)
+	return nil
+}
+
+// addCompleted books a completed transaction of amount minor units
+func (f *fakeStore) addCompleted(userID, txType string, amount int64, code string) *database.Transaction {
+	t := &database.Transaction{
+		TransactionID: f.id("tx"),
+		UserID:        userID,
+		Type:          txType,
+		Amount:        currency.FromMinor(amount, code),
+		Currency:      code,
+		Status:        "completed",
+		CreatedAt:     time.Now(),
+	}
+	f.transactions = append(f.transactions, t)
+	return t
+}
+
+func (f *fakeStore) CreateTransaction(ctx context.Context, req *database.TransactionRequest) (*database.Transaction, error) {
+	t := &database.Transaction{
+		TransactionID: f.id("tx"),
+		UserID:        req.UserID,
+		Type:          req.Type,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Status:        "pending",
+		Description:   req.Description,
+		Counterparty:  req.Counterparty,
+		PaymentID:     req.PaymentID,
+		CreatedAt:     time.Now(),
+	}
+	f.transactions = append(f.transactions, t)
+	return t, nil
+}
+
+// userTransactions lists a user's transactions newest first
+func (f *fakeStore) userTransactions(userID string) []*database.Transaction {
+	var list []*database.Transaction
+	for _, t := range f.transactions {
+		if t.UserID == userID {
+			list = append(list, t)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list
+}
+
+func (f *fakeStore) GetUserTransactions(ctx context.Context, userID string, limit int) ([]*database.Transaction, error) {
+	list := f.userTransactions(userID)
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (f *fakeStore) CountUserTransactions(ctx context.Context, userID string) (int64, error) {
+	return int64(len(f.userTransactions(userID))), nil
+}
+
+func (f *fakeStore) QueryTransactions(ctx context.Context, q *database.TransactionQuery) (*database.TransactionPage, error) {
+	list := f.userTransactions(q.UserID)
+	return &database.TransactionPage{Transactions: list, Total: int64(len(list))}, nil
+}
+
+func (f *fakeStore) SearchTransactions(ctx context.Context, userID, query string, limit int) ([]*database.SearchResult, error) {
+	var results []*database.SearchResult
+	for _, t := range f.userTransactions(userID) {
+		for _, text := range []string{t.Description, t.Counterparty, t.Notes, t.PaymentID} {
+			if strings.Contains(strings.ToLower(text), strings.ToLower(query)) {
+				results = append(results, &database.SearchResult{Transaction: t, Score: 1})
+				break
+			}
+		}
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (f *fakeStore) UpdateTransactionNotes(ctx context.Context, userID, transactionID, notes string) error {
+	for _, t := range f.userTransactions(userID) {
+		if t.TransactionID == transactionID {
+			t.Notes = notes
+			return nil
+		}
+	}
+	return database.ErrTransactionNotFound
+}
+
+func (f *fakeStore) CreateHold(ctx context.Context, req *database.HoldRequest) (*database.Hold, error) {
+	summary, _ := f.GetBalanceSummary(ctx, req.UserID, req.Currency)
+	if summary.Available < req.Amount {
+		return nil, database.ErrInsufficientFunds
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = database.DefaultHoldTTL
+	}
+	hold := &database.Hold{
+		HoldID:      f.id("hold"),
+		UserID:      req.UserID,
+		Type:        req.Type,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Status:      database.HoldStatusActive,
+		Description: req.Description,
+		ExpiresAt:   time.Now().Add(ttl),
+		CreatedAt:   time.Now(),
+	}
+	f.holds[hold.HoldID] = hold
+	return hold, nil
+}
+
+// activeHold finds one of a user's active holds
+func (f *fakeStore) activeHold(userID, holdID string) (*database.Hold, error) {
+	hold, ok := f.holds[holdID]
+	if !ok || hold.UserID != userID {
+		return nil, database.ErrHoldNotFound
+	}
+	if hold.Status != database.HoldStatusActive {
+		return nil, database.ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+func (f *fakeStore) CaptureHold(ctx context.Context, userID, holdID string, amount int64) (*database.Transaction, error) {
+	hold, err := f.activeHold(userID, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 || amount > hold.Amount {
+		amount = hold.Amount
+	}
+	t := f.addCompleted(userID, hold.Type, amount, hold.Currency)
+	t.HoldID = holdID
+	hold.Status = database.HoldStatusCaptured
+	hold.TransactionID = t.TransactionID
+	return t, nil
+}
+
+func (f *fakeStore) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	hold, err := f.activeHold(userID, holdID)
+	if err != nil {
+		return err
+	}
+	hold.Status = database.HoldStatusReleased
+	return nil
+}
+
+func (f *fakeStore) GetUserHolds(ctx context.Context, userID, status string) ([]*database.Hold, error) {
+	var holds []*database.Hold
+	for _, hold := range f.holds {
+		if hold.UserID == userID && (status == "" || hold.Status == status) {
+			holds = append(holds, hold)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].HoldID < holds[j].HoldID })
+	return holds, nil
+}
+
+func (f *fakeStore) GetBalanceSummary(ctx context.Context, userID, code string) (*database.BalanceSummary, error) {
+	summary := &database.BalanceSummary{Currency: code}
+	for _, t := range f.userTransactions(userID) {
+		if t.Currency != code || t.Status != "completed" {
+			continue
+		}
+		amount := currency.ToMinor(t.Amount, code)
+		if t.Type == "deposit" || t.Type == "exchange_in" {
+			summary.Total += amount
+		} else {
+			summary.Total -= amount
+		}
+	}
+	for _, hold := range f.holds {
+		if hold.UserID == userID && hold.Currency == code && hold.Status == database.HoldStatusActive {
+			summary.Held += hold.Amount
+		}
+	}
+	summary.Available = summary.Total - summary.Held
+	return summary, nil
+}
+
+func (f *fakeStore) GetBalanceSummaries(ctx context.Context, userID string) ([]*database.BalanceSummary, error) {
+	seen := map[string]bool{}
+	var summaries []*database.BalanceSummary
+	for _, t := range f.userTransactions(userID) {
+		if !seen[t.Currency] {
+			seen[t.Currency] = true
+			summary, _ := f.GetBalanceSummary(ctx, userID, t.Currency)
+			summaries = append(summaries, summary)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Currency < summaries[j].Currency })
+	return summaries, nil
+}
+
+func (f *fakeStore) CreateFXQuote(ctx context.Context, quote *database.FXQuote) (*database.FXQuote, error) {
+	saved := *quote
+	saved.QuoteID = f.id("quote")
+	saved.Status = "open"
+	saved.CreatedAt = time.Now()
+	f.quotes[saved.QuoteID] = &saved
+	return &saved, nil
+}
+
+func (f *fakeStore) ExecuteFXQuote(ctx context.Context, userID, quoteID string) (*database.FXConversion, error) {
+	quote, ok := f.quotes[quoteID]
+	if !ok || quote.UserID != userID {
+		return nil, database.ErrQuoteNotFound
+	}
+	if quote.Status != "open" {
+		return nil, database.ErrQuoteUsed
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return nil, database.ErrQuoteExpired
+	}
+	if summary, _ := f.GetBalanceSummary(ctx, userID, quote.FromCurrency); summary.Available < quote.SellAmount {
+		return nil, database.ErrInsufficientFunds
+	}
+	quote.Status = "executed"
+	conversion := &database.FXConversion{
+		ConversionID:        f.id("conversion"),
+		QuoteID:             quoteID,
+		UserID:              userID,
+		FromCurrency:        quote.FromCurrency,
+		ToCurrency:          quote.ToCurrency,
+		SellAmount:          quote.SellAmount,
+		BuyAmount:           quote.BuyAmount,
+		Rate:                quote.Rate,
+		DebitTransactionID:  f.addCompleted(userID, "exchange_out", quote.SellAmount, quote.FromCurrency).TransactionID,
+		CreditTransactionID: f.addCompleted(userID, "exchange_in", quote.BuyAmount, quote.ToCurrency).TransactionID,
+		ExecutedAt:          time.Now(),
+	}
+	f.conversions = append(f.conversions, conversion)
+	return conversion, nil
+}
+
+func (f *fakeStore) GetUserConversions(ctx context.Context, userID string, limit int) ([]*database.FXConversion, error) {
+	var list []*database.FXConversion
+	for i := len(f.conversions) - 1; i >= 0; i-- {
+		if f.conversions[i].UserID == userID {
+			list = append(list, f.conversions[i])
+		}
+	}
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// fakePayments opens payment intents without calling Stripe
+type fakePayments struct {
+	requests []*stripeService.StripePaymentIntentRequest
+}
+
+func (p *fakePayments) CreatePaymentIntent(ctx context.Context, req *stripeService.StripePaymentIntentRequest) (*stripeService.StripePaymentIntentResponse, error) {
+	p.requests = append(p.requests, req)
+	id := fmt.Sprintf("pi_%d", len(p.requests))
+	return &stripeService.StripePaymentIntentResponse{ClientSecret: id + "_secret", PaymentID: id}, nil
+}
+
+// newTestService creates a service over a fake store with fixed exchange
+// rates against PLN
+func newTestService(store *fakeStore) *Service {
+	exchanger := fx.NewExchanger(fx.NewStaticSource("PLN", map[string]float64{"EUR": 4.00}), 0, time.Minute)
+	return New(store, &fakePayments{}, exchanger, Options{SessionTTL: time.Hour})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"pocket-wallet/internal/scheduler"
+)
+
+// newScheduler registers the background jobs; the lifecycle starts it once
+// MongoDB is up
+func (a *App) newScheduler() *scheduler.Scheduler {
+	s := scheduler.New(a.db)
+
+	jobs := []scheduler.Job{
+		{
+			Name:    "expire-holds",
+			Spec:    "*/5 * * * *",
+			Jitter:  30 * time.Second,
+			Timeout: time.Minute,
+			Run: func(ctx context.Context) error {
+				_, err := a.db.ExpireHolds(ctx, time.Now())
+				return err
+			},
+		},
+	}
+
+	// Jobs that talk to Stripe
+	if a.config.StripeSecretKey == "" {
+		slog.Warn("Payment reconciliation and deposit expiry disabled: Stripe secret key is empty")
+	} else {
+		if a.config.ReconcileSchedule != "" {
+			jobs = append(jobs, scheduler.Job{
+				Name:    "reconcile-payments",
+				Spec:    a.config.ReconcileSchedule,
+				Jitter:  time.Minute,
+				Timeout: 10 * time.Minute,
+				Run:     a.runReconciliation,
+			})
+		}
+		if a.config.PendingExpirySchedule != "" {
+			jobs = append(jobs, scheduler.Job{
+				Name:    "expire-pending-deposits",
+				Spec:    a.config.PendingExpirySchedule,
+				Jitter:  time.Minute,
+				Timeout: 5 * time.Minute,
+				Run:     a.expirePendingDeposits,
+			})
+		}
+	}
+
+	for _, job := range jobs {
+		if err := s.Add(job); err != nil {
+			slog.Warn("Could not schedule job", "job", job.Name, "error", err)
+		}
+	}
+
+	return s
+}
+
+// GetJobStatuses reports the state of every background job
+func (a *App) GetJobStatuses() (_ []JobStatus, err error) {
+	_, done := observe("GetJobStatuses")
+	defer done(&err)
+
+	if a.scheduler == nil {
+		return nil, errSchedulerUnavailable
+	}
+
+	statuses := a.scheduler.Status()
+	result := make([]JobStatus, len(statuses))
+	for i, s := range statuses {
+		result[i] = JobStatus{
+			Name:           s.Name,
+			Schedule:       s.Spec,
+			Running:        s.Running,
+			NextRun:        s.NextRun,
+			LastStartedAt:  s.LastStartedAt,
+			LastFinishedAt: s.LastFinishedAt,
+			LastDurationMs: s.LastDurationMs,
+			LastError:      s.LastError,
+			Runs:           s.Runs,
+			Failures:       s.Failures,
+			Skipped:        s.Skipped,
+		}
+	}
+	return result, nil
+}
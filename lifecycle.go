@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pocket-wallet/internal/lifecycle"
+	"pocket-wallet/internal/tracing"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Subsystems the lifecycle manager runs
+const (
+	subsystemTracing   = "tracing"
+	subsystemDatabase  = "mongodb"
+	subsystemServer    = "server"
+	subsystemScheduler = "scheduler"
+)
+
+// shutdownTimeout bounds stopping every subsystem when the window closes
+const shutdownTimeout = 15 * time.Second
+
+// eventSubsystemStatus is sent to the frontend when a subsystem changes
+// state; the payload is the SubsystemStatus
+const eventSubsystemStatus = "subsystem:status"
+
+// subsystems lists what startup brings up, dependencies first. Only the
+// background jobs need MongoDB: they are stopped while it is down and
+// started again once it is back. The server answers without it, refusing
+// database-backed requests until it returns.
+func (a *App) subsystems() []lifecycle.Component {
+	return []lifecycle.Component{
+		{
+			Name: subsystemTracing,
+			Start: func(ctx context.Context) error {
+				// Stays a no-op unless an OTLP collector is configured
+				stop, err := tracing.Setup(ctx, a.config)
+				if err != nil {
+					return err
+				}
+				a.stopTracing = stop
+				return nil
+			},
+			// Sends the spans still buffered
+			Stop: func(ctx context.Context) error { return a.stopTracing(ctx) },
+		},
+		{
+			Name: subsystemDatabase,
+			Start: func(ctx context.Context) error {
+				if a.db == nil {
+					return errors.New("MongoDB is not configured")
+				}
+				return a.db.Setup(ctx)
+			},
+			Health: func(ctx context.Context) error { return a.db.Ping(ctx) },
+			Stop:   func(ctx context.Context) error { return a.db.Close() },
+		},
+		{
+			Name:  subsystemServer,
+			Start: func(ctx context.Context) error { return a.startHTTPServer() },
+			Health: func(ctx context.Context) error {
+				a.serverMu.Lock()
+				defer a.serverMu.Unlock()
+				if !a.serverStatus.Running {
+					return errors.New(a.serverStatus.Error)
+				}
+				return nil
+			},
+			Stop: func(ctx context.Context) error { return a.server.Shutdown(ctx) },
+		},
+		{
+			Name:      subsystemScheduler,
+			DependsOn: []string{subsystemDatabase},
+			Start: func(ctx context.Context) error {
+				if a.scheduler == nil {
+					return errors.New("no database for job state")
+				}
+				a.scheduler.Start()
+				return nil
+			},
+			// Lets running jobs finish before the database goes away
+			Stop: func(ctx context.Context) error { return a.scheduler.Stop(ctx) },
+		},
+	}
+}
+
+// dbAvailable reports whether MongoDB is connected. While it is not, the
+// app runs degraded and database-backed calls fail fast instead of waiting
+// out their timeouts. The command line connects before doing anything, so
+// without a lifecycle the database is there.
+func (a *App) dbAvailable() bool {
+	if a.db == nil {
+		return false
+	}
+	return a.lifecycle == nil || a.lifecycle.Running(subsystemDatabase)
+}
+
+// GetSubsystemStatus reports the state of every subsystem, so the
+// frontend can show what is unavailable
+func (a *App) GetSubsystemStatus() []SubsystemStatus {
+	_, done := observe("GetSubsystemStatus")
+	defer done(nil)
+
+	if a.lifecycle == nil {
+		return []SubsystemStatus{}
+	}
+	statuses := a.lifecycle.Status()
+	result := make([]SubsystemStatus, len(statuses))
+	for i, s := range statuses {
+		result[i] = subsystemStatusFrom(s)
+	}
+	return result
+}
+
+// subsystemChanged passes state changes on to the frontend
+func (a *App) subsystemChanged(status lifecycle.Status) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, eventSubsystemStatus, subsystemStatusFrom(status))
+	}
+}
+
+func subsystemStatusFrom(s lifecycle.Status) SubsystemStatus {
+	return SubsystemStatus{
+		Name:  s.Name,
+		State: string(s.State),
+		Error: s.Error,
+		Since: s.Since,
+	}
+}
@@ -2,6 +2,11 @@ package main
 
 import (
 	"embed"
+	"log/slog"
+	"os"
+
+	"pocket-wallet/internal/logging"
+	"pocket-wallet/pkg/config"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,6 +18,17 @@ var assets embed.FS
 
 // main starts the application
 func main() {
+	// Redact secrets from the first log line on; the configured level and
+	// format are applied once the configuration is loaded
+	logging.Setup("info", "text")
+
+	// Run a command-line subcommand, e.g. "pocket-wallet reconcile"
+	if len(os.Args) > 1 {
+		if code, ok := runCommand(os.Args[1:]); ok {
+			os.Exit(code)
+		}
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -29,6 +45,7 @@ func main() {
 		OnDomReady:       app.OnDomReady,
 		OnBeforeClose:    app.OnBeforeClose,
 		OnShutdown:       app.OnShutdown,
+		ErrorFormatter:   app.formatError,
 		Bind: []interface{}{
 			app,
 		},
@@ -38,3 +55,10 @@ func main() {
 		println("Error:", err.Error())
 	}
 }
+
+// setupLogging applies the configured log level and format
+func setupLogging(cfg *config.Config) {
+	if err := logging.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		slog.Warn("Could not configure logging, using defaults", "error", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+	"pocket-wallet/internal/metrics"
+	"pocket-wallet/internal/tracing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// observe records a bound method call in the metrics and traces it.
+// Bound methods start with
+//
+//	ctx, done := observe("Name")
+//	defer done(&err)
+//
+// err being their named error result, or pass nil when they cannot fail.
+// The returned context carries the span to the calls the method makes;
+// methods the REST API shares keep their body in an unexported twin that
+// takes the request's context instead.
+func observe(method string) (context.Context, func(*error)) {
+	ctx, span := tracing.Start(context.Background(), "App."+method)
+	start := time.Now()
+	return ctx, func(err *error) {
+		code := "OK"
+		if err != nil && *err != nil {
+			code = string(errcode.Of(*err))
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, code)
+		}
+		span.End()
+		metrics.ObserveAppCall(method, code, time.Since(start))
+	}
+}
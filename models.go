@@ -31,19 +31,30 @@ type UserMetaResponse struct {
 
 // BalanceRequest represents the balance update request
 type BalanceRequest struct {
-	UserID           string `json:"user_id"`
-	EncryptedBalance string `json:"encrypted_balance"`
+	UserID           string  `json:"user_id"`
+	EncryptedBalance string  `json:"encrypted_balance"`
+	ExpectedBalance  *string `json:"expected_balance,omitempty"`  // Previous encrypted balance; a mismatch fails with BALANCE_CONFLICT
+	CreditedEventID  int64   `json:"credited_event_id,omitempty"` // payment:succeeded event this update credits; needs expected_balance
 }
 
 // BalanceResponse represents the balance response
 type BalanceResponse struct {
 	EncryptedBalance string `json:"encrypted_balance"`
+	CreditedEventID  int64  `json:"credited_event_id"` // Last payment:succeeded event included in the balance
+}
+
+// PendingCredit is a settled deposit not yet added to the encrypted
+// balance. Credit it with UpdateBalance and its event ID.
+type PendingCredit struct {
+	EventID     int64        `json:"event_id"`
+	Transaction *Transaction `json:"transaction"`
 }
 
 // StripePaymentIntentRequest represents the Stripe payment intent request
 type StripePaymentIntentRequest struct {
-	UserID string `json:"user_id"`
-	Amount int64  `json:"amount"` // Amount in cents
+	UserID   string `json:"user_id"`
+	Amount   int64  `json:"amount"`             // Amount in minor units (cents)
+	Currency string `json:"currency,omitempty"` // ISO-4217 code, defaults to PLN
 }
 
 // StripePaymentIntentResponse represents the Stripe payment intent response
@@ -54,16 +65,28 @@ type StripePaymentIntentResponse struct {
 
 // Transaction represents a transaction in the system
 type Transaction struct {
-	TransactionID string    `json:"transaction_id"`
-	UserID        string    `json:"user_id"`
-	Type          string    `json:"type"` // "deposit", "withdrawal", "payment"
-	Amount        float64   `json:"amount"`
-	Currency      string    `json:"currency"`
-	Status        string    `json:"status"` // "pending", "completed", "failed"
-	Description   string    `json:"description"`
-	PaymentID     string    `json:"payment_id,omitempty"` // Stripe payment ID
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	TransactionID string         `json:"transaction_id"`
+	UserID        string         `json:"user_id"`
+	Type          string         `json:"type"` // "deposit", "withdrawal", "payment", "exchange_in", "exchange_out", "external_in", "external_out"
+	Amount        float64        `json:"amount"`
+	Currency      string         `json:"currency"`
+	Status        string         `json:"status"` // "pending", "completed", "failed", "cancelled"
+	Description   string         `json:"description"`
+	Counterparty  string         `json:"counterparty,omitempty"`
+	Notes         string         `json:"notes,omitempty"`
+	PaymentID     string         `json:"payment_id,omitempty"`     // Stripe payment ID
+	ExchangeID    string         `json:"exchange_id,omitempty"`    // Currency conversion both legs belong to
+	BankReference string         `json:"bank_reference,omitempty"` // Imported bank statement entry
+	StatusHistory []StatusChange `json:"status_history,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// StatusChange records one transition of a transaction's status
+type StatusChange struct {
+	Status    string    `json:"status"`
+	ChangedAt time.Time `json:"changed_at"`
+	Reason    string    `json:"reason,omitempty"`
 }
 
 // TransactionRequest represents a transaction creation request
@@ -79,5 +102,370 @@ type TransactionRequest struct {
 // TransactionListResponse represents a list of transactions
 type TransactionListResponse struct {
 	Transactions []Transaction `json:"transactions"`
-	Total        int           `json:"total"`
+	Total        int           `json:"total"`                 // All matching transactions, not just this page
+	NextCursor   string        `json:"next_cursor,omitempty"` // Pass back to fetch the next page
+}
+
+// TransactionQueryRequest represents a filtered, paginated transaction history query
+type TransactionQueryRequest struct {
+	UserID    string   `json:"user_id"`
+	Types     []string `json:"types,omitempty"`
+	Statuses  []string `json:"statuses,omitempty"`
+	Currency  string   `json:"currency,omitempty"`
+	DateFrom  string   `json:"date_from,omitempty"`  // RFC 3339 or YYYY-MM-DD, inclusive
+	DateTo    string   `json:"date_to,omitempty"`    // RFC 3339 or YYYY-MM-DD, inclusive
+	MinAmount float64  `json:"min_amount,omitempty"` // In major units
+	MaxAmount float64  `json:"max_amount,omitempty"` // In major units
+	Cursor    string   `json:"cursor,omitempty"`     // Opaque value from a previous next_cursor
+	Limit     int      `json:"limit,omitempty"`
+}
+
+// Hold represents funds reserved for a pending outflow
+type Hold struct {
+	HoldID        string    `json:"hold_id"`
+	UserID        string    `json:"user_id"`
+	Type          string    `json:"type"`   // "withdrawal", "payment"
+	Amount        int64     `json:"amount"` // Amount in minor units
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"` // "active", "captured", "released", "expired"
+	Description   string    `json:"description"`
+	TransactionID string    `json:"transaction_id,omitempty"` // Set once captured
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// HoldRequest represents a request to reserve funds
+type HoldRequest struct {
+	UserID           string `json:"user_id"`
+	Type             string `json:"type"`
+	Amount           int64  `json:"amount"` // Amount in minor units
+	Currency         string `json:"currency"`
+	Description      string `json:"description"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds,omitempty"` // Defaults to 24 hours
+}
+
+// HoldActionRequest identifies a hold to capture or release
+type HoldActionRequest struct {
+	UserID string `json:"user_id"`
+	HoldID string `json:"hold_id"`
+	Amount int64  `json:"amount,omitempty"` // Partial capture amount in minor units, 0 captures everything
+}
+
+// AvailableBalanceResponse represents the server-side ledger balance
+type AvailableBalanceResponse struct {
+	Currency  string `json:"currency"`
+	Total     int64  `json:"total"`     // Amount in minor units
+	Held      int64  `json:"held"`      // Amount in minor units
+	Available int64  `json:"available"` // Amount in minor units
+}
+
+// CurrencyBalancesResponse lists the ledger balance of every currency the user holds
+type CurrencyBalancesResponse struct {
+	Balances []AvailableBalanceResponse `json:"balances"`
+}
+
+// CurrencyInfo describes a currency accepted for top-ups
+type CurrencyInfo struct {
+	Code          string `json:"code"`
+	MinorUnits    int    `json:"minor_units"`
+	MinimumAmount int64  `json:"minimum_amount"` // Smallest top-up in minor units
+}
+
+// ExchangeQuoteRequest asks for a price to convert between two sub-balances
+type ExchangeQuoteRequest struct {
+	UserID       string `json:"user_id"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	Amount       int64  `json:"amount"` // Amount to sell in minor units of FromCurrency
+}
+
+// ExchangeQuote represents a time-limited conversion offer
+type ExchangeQuote struct {
+	QuoteID      string    `json:"quote_id"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	SellAmount   int64     `json:"sell_amount"` // Amount in minor units of FromCurrency
+	BuyAmount    int64     `json:"buy_amount"`  // Amount in minor units of ToCurrency
+	MidRate      float64   `json:"mid_rate"`
+	Rate         float64   `json:"rate"`
+	SpreadBps    int64     `json:"spread_bps"`
+	RateSource   string    `json:"rate_source"`
+	RateDate     time.Time `json:"rate_date"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ExchangeExecuteRequest accepts a previously issued quote
+type ExchangeExecuteRequest struct {
+	UserID  string `json:"user_id"`
+	QuoteID string `json:"quote_id"`
+}
+
+// ExchangeConversion represents an executed currency conversion
+type ExchangeConversion struct {
+	ConversionID        string    `json:"conversion_id"`
+	QuoteID             string    `json:"quote_id"`
+	FromCurrency        string    `json:"from_currency"`
+	ToCurrency          string    `json:"to_currency"`
+	SellAmount          int64     `json:"sell_amount"`
+	BuyAmount           int64     `json:"buy_amount"`
+	MidRate             float64   `json:"mid_rate"`
+	Rate                float64   `json:"rate"`
+	SpreadBps           int64     `json:"spread_bps"`
+	RateSource          string    `json:"rate_source"`
+	RateDate            time.Time `json:"rate_date"`
+	DebitTransactionID  string    `json:"debit_transaction_id"`
+	CreditTransactionID string    `json:"credit_transaction_id"`
+	ExecutedAt          time.Time `json:"executed_at"`
+}
+
+// TransactionSearchRequest represents a full-text search over the user's transactions
+type TransactionSearchRequest struct {
+	UserID string `json:"user_id"`
+	Query  string `json:"query"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// TextRange marks a matched term as UTF-16 code unit offsets into a
+// field, the way JavaScript indexes strings
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// TextHighlight lists matched terms in one transaction field
+type TextHighlight struct {
+	Field   string      `json:"field"` // "description", "counterparty", "notes", "payment_id"
+	Text    string      `json:"text"`
+	Matches []TextRange `json:"matches"`
+}
+
+// TransactionSearchResult represents a ranked search hit
+type TransactionSearchResult struct {
+	Transaction Transaction     `json:"transaction"`
+	Score       float64         `json:"score"`
+	Highlights  []TextHighlight `json:"highlights"`
+}
+
+// TransactionSearchResponse represents search results, best match first
+type TransactionSearchResponse struct {
+	Results []TransactionSearchResult `json:"results"`
+}
+
+// TransactionNotesRequest sets the notes on a transaction
+type TransactionNotesRequest struct {
+	UserID        string `json:"user_id"`
+	TransactionID string `json:"transaction_id"`
+	Notes         string `json:"notes"`
+}
+
+// ExportFilter selects which transactions to export and how to render CSV
+type ExportFilter struct {
+	Types     []string `json:"types,omitempty"`
+	Statuses  []string `json:"statuses,omitempty"`   // OFX and QIF default to completed only
+	Currency  string   `json:"currency,omitempty"`   // OFX and QIF cover one currency, defaulting to PLN
+	DateFrom  string   `json:"date_from,omitempty"`  // RFC 3339 or YYYY-MM-DD, inclusive
+	DateTo    string   `json:"date_to,omitempty"`    // RFC 3339 or YYYY-MM-DD, inclusive
+	MinAmount float64  `json:"min_amount,omitempty"` // In major units
+	MaxAmount float64  `json:"max_amount,omitempty"` // In major units
+	Delimiter string   `json:"delimiter,omitempty"`  // CSV only: ",", ";" or "\t"
+	Language  string   `json:"language,omitempty"`   // CSV only: "pl" or "en" headers
+}
+
+// ExportResult describes a written export file
+type ExportResult struct {
+	Path      string `json:"path"`
+	Format    string `json:"format"`
+	Count     int    `json:"count"`
+	Cancelled bool   `json:"cancelled"` // The user closed the save dialog
+}
+
+// ImportLineError reports part of a statement file that could not be imported
+type ImportLineError struct {
+	Line   int    `json:"line"` // Line of the file the problem starts on
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// BankImportResult summarizes a bank statement import
+type BankImportResult struct {
+	Path       string            `json:"path"`
+	Format     string            `json:"format"` // "mt940" or "camt053"
+	AccountID  string            `json:"account_id"`
+	Imported   int               `json:"imported"`
+	Booked     int               `json:"booked"`     // Pending entries imported earlier that are now booked
+	Duplicates int               `json:"duplicates"` // Entries whose bank reference was already imported
+	Errors     []ImportLineError `json:"errors"`
+	Cancelled  bool              `json:"cancelled"` // The user closed the file dialog
+}
+
+// StatementRequest selects the month for a PDF account statement. Balances
+// are encrypted on the client, so it supplies them in major units.
+type StatementRequest struct {
+	Year           int      `json:"year"`
+	Month          int      `json:"month"`                     // 1-12
+	Currency       string   `json:"currency,omitempty"`        // Defaults to PLN
+	OpeningBalance *float64 `json:"opening_balance,omitempty"` // Derived from the closing balance when omitted
+	ClosingBalance *float64 `json:"closing_balance,omitempty"` // Derived from the opening balance when omitted
+}
+
+// StatementResult describes a written statement file
+type StatementResult struct {
+	Path      string `json:"path"`
+	Count     int    `json:"count"`     // Transactions listed on the statement
+	Cancelled bool   `json:"cancelled"` // The user closed the save dialog
+}
+
+// JobStatus describes a background job and its most recent run
+type JobStatus struct {
+	Name           string    `json:"name"`
+	Schedule       string    `json:"schedule"`
+	Running        bool      `json:"running"`
+	NextRun        time.Time `json:"next_run"`
+	LastStartedAt  time.Time `json:"last_started_at"`
+	LastFinishedAt time.Time `json:"last_finished_at"`
+	LastDurationMs int64     `json:"last_duration_ms"`
+	LastError      string    `json:"last_error,omitempty"`
+	Runs           int64     `json:"runs"`
+	Failures       int64     `json:"failures"`
+	Skipped        int64     `json:"skipped"` // Activations dropped because the previous run was still going
+}
+
+// WebhookEvent is a stored webhook delivery and its processing outcome
+type WebhookEvent struct {
+	EventID     string    `json:"event_id"`
+	Provider    string    `json:"provider"`
+	Type        string    `json:"type"`
+	Payload     string    `json:"payload"` // Raw JSON as received
+	Status      string    `json:"status"`  // "received", "processed", "ignored" or "failed"
+	Error       string    `json:"error,omitempty"`
+	Deliveries  int       `json:"deliveries"`
+	Attempts    int       `json:"attempts"`
+	ReceivedAt  time.Time `json:"received_at"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// HealthReport is the status of the app and each component it depends on
+type HealthReport struct {
+	Connected  bool              `json:"connected"` // MongoDB answered a ping
+	Error      string            `json:"error"`     // MongoDB error, if any
+	Status     string            `json:"status"`    // "ok", "degraded" or "down"
+	Components []HealthComponent `json:"components"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+// HealthComponent is the outcome of one health check
+type HealthComponent struct {
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	Critical  bool           `json:"critical"` // Down means the whole app is down
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// LoginSaltParams selects the user whose password salt to return
+type LoginSaltParams struct {
+	Login string `json:"login"`
+}
+
+// LoginSaltResponse carries the salt the client needs to derive its
+// password hash and balance key before logging in
+type LoginSaltResponse struct {
+	Salt string `json:"salt"`
+}
+
+// LoginRequest exchanges a client-derived password hash for a session token
+type LoginRequest struct {
+	Login        string `json:"login"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// LoginResponse is a new API session
+type LoginResponse struct {
+	Token            string    `json:"token"` // Send as "Authorization: Bearer <token>"
+	ExpiresAt        time.Time `json:"expires_at"`
+	UserID           string    `json:"user_id"`
+	Login            string    `json:"login"`
+	EncryptedBalance string    `json:"encrypted_balance"`
+}
+
+// AccountResponse is the public part of a user account
+type AccountResponse struct {
+	UserID    string    `json:"user_id"`
+	Login     string    `json:"login"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BalanceUpdateRequest replaces the session user's encrypted balance
+type BalanceUpdateRequest struct {
+	EncryptedBalance string  `json:"encrypted_balance"`
+	ExpectedBalance  *string `json:"expected_balance,omitempty"`  // Previous encrypted balance; a mismatch fails with BALANCE_CONFLICT
+	CreditedEventID  int64   `json:"credited_event_id,omitempty"` // payment:succeeded event this update credits; needs expected_balance
+}
+
+// TopUpRequest starts a Stripe top-up for the session user
+type TopUpRequest struct {
+	Amount   int64  `json:"amount"`             // Minor units
+	Currency string `json:"currency,omitempty"` // ISO-4217 code, defaults to PLN
+}
+
+// TransactionListParams filters the session user's transaction history
+type TransactionListParams struct {
+	Types     []string `json:"types,omitempty"`    // Comma separated in query strings
+	Statuses  []string `json:"statuses,omitempty"` // Comma separated in query strings
+	Currency  string   `json:"currency,omitempty"`
+	DateFrom  string   `json:"date_from,omitempty"`  // RFC 3339 or YYYY-MM-DD, inclusive
+	DateTo    string   `json:"date_to,omitempty"`    // RFC 3339 or YYYY-MM-DD, inclusive
+	MinAmount float64  `json:"min_amount,omitempty"` // In major units
+	MaxAmount float64  `json:"max_amount,omitempty"` // In major units
+	Cursor    string   `json:"cursor,omitempty"`     // Opaque value from a previous next_cursor
+	Limit     int      `json:"limit,omitempty"`
+}
+
+// ExportParams selects the format and transactions of an API export
+type ExportParams struct {
+	Format string `json:"format"` // "csv", "jsonl", "ofx" or "qif"
+	ExportFilter
+}
+
+// SubsystemStatus is the state of one part of the app: "tracing",
+// "mongodb", "server" or "scheduler"
+type SubsystemStatus struct {
+	Name  string    `json:"name"`
+	State string    `json:"state"` // "pending", "waiting", "running", "unhealthy", "failed" or "stopped"
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// ServerStatus describes the local HTTP server for webhooks and the REST
+// API
+type ServerStatus struct {
+	Running         bool   `json:"running"`
+	Address         string `json:"address"`                    // host:port
+	URL             string `json:"url,omitempty"`              // Base URL clients connect to
+	TLS             string `json:"tls"`                        // "off", "files" or "self-signed"
+	CertFingerprint string `json:"cert_fingerprint,omitempty"` // SHA-256 of a self-signed certificate
+	Error           string `json:"error,omitempty"`            // Why it is not running
+}
+
+// EventStreamParams picks where an event stream starts. Without a last
+// event ID, from the Last-Event-ID header or this parameter, the stream
+// starts with events that happen after connecting.
+type EventStreamParams struct {
+	LastEventID int64 `json:"last_event_id,omitempty"` // For clients that cannot set the header
+}
+
+// APIError is the body of every failed REST API response
+type APIError struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes what went wrong. Bound methods reject with it and
+// the REST API returns it inside APIError.
+type ErrorDetail struct {
+	Code    string `json:"code"`    // Stable, e.g. "LOGIN_TAKEN"
+	Message string `json:"message"` // Localized text for the code
+	Detail  string `json:"detail"`  // English description of this failure
 }
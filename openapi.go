@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"pocket-wallet/internal/export"
+	"pocket-wallet/internal/openapi"
+)
+
+// apiVersion is the version of the REST API, matching apiPrefix
+const apiVersion = "1.0.0"
+
+// openAPIDocument describes the REST API. It is generated from the route
+// table and the handlers' own input and output types, so it cannot
+// describe an endpoint differently from how it is served.
+func (a *App) openAPIDocument() (*openapi.Document, error) {
+	gen := openapi.NewGenerator()
+	errorSchema := gen.Schema(reflect.TypeFor[APIError]())
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info:    openapi.Info{Title: "Pocket Wallet API", Version: apiVersion},
+		Paths:   make(map[string]map[string]*openapi.Operation),
+	}
+
+	for _, route := range a.apiRoutes() {
+		op := &openapi.Operation{
+			OperationID: operationID(route),
+			Summary:     route.Summary,
+			Responses:   make(map[string]*openapi.Response),
+		}
+
+		if route.Input != reflect.TypeFor[noInput]() {
+			if route.Method == http.MethodGet {
+				params, err := queryParameters(gen, route.Input)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", route.Method, route.Path, err)
+				}
+				op.Parameters = params
+			} else {
+				op.RequestBody = &openapi.RequestBody{
+					Required: true,
+					Content:  map[string]*openapi.MediaType{"application/json": {Schema: gen.Schema(route.Input)}},
+				}
+			}
+		}
+
+		op.Responses[strconv.Itoa(route.Status)] = successResponse(gen, route)
+		op.Responses["default"] = &openapi.Response{
+			Description: "Error",
+			Content:     map[string]*openapi.MediaType{"application/json": {Schema: errorSchema}},
+		}
+		if route.Auth {
+			op.Security = []map[string][]string{{"bearer": {}}}
+		}
+
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = make(map[string]*openapi.Operation)
+		}
+		doc.Paths[route.Path][strings.ToLower(route.Method)] = op
+	}
+
+	doc.Components = openapi.Components{
+		Schemas:         gen.Schemas(),
+		SecuritySchemes: map[string]*openapi.SecurityScheme{"bearer": {Type: "http", Scheme: "bearer"}},
+	}
+	return doc, nil
+}
+
+func successResponse(gen *openapi.Generator, route apiRoute) *openapi.Response {
+	response := &openapi.Response{Description: http.StatusText(route.Status)}
+
+	switch route.Output {
+	case reflect.TypeFor[noContent]():
+	case reflect.TypeFor[*fileDownload]():
+		response.Content = make(map[string]*openapi.MediaType)
+		for _, format := range export.Formats {
+			mediaType, _, _ := strings.Cut(format.ContentType(), ";")
+			response.Content[mediaType] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string", Format: "binary"}}
+		}
+	case reflect.TypeFor[*eventStream]():
+		response.Content = map[string]*openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}}
+	default:
+		response.Content = map[string]*openapi.MediaType{"application/json": {Schema: gen.Schema(route.Output)}}
+	}
+	return response
+}
+
+// queryParameters describes the query string decodeQuery accepts for t
+func queryParameters(gen *openapi.Generator, t reflect.Type) ([]*openapi.Parameter, error) {
+	var params []*openapi.Parameter
+	for _, field := range openapi.Fields(t) {
+		param := &openapi.Parameter{
+			Name:     field.Name,
+			In:       "query",
+			Required: !field.OmitEmpty,
+			Schema:   gen.Schema(field.Type),
+		}
+
+		switch kind := field.Type.Kind(); {
+		case kind == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			explode := false
+			param.Style = "form"
+			param.Explode = &explode
+			param.Schema = &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string"}}
+		case kind == reflect.String, kind == reflect.Int, kind == reflect.Int64, kind == reflect.Float64, kind == reflect.Bool:
+		default:
+			return nil, fmt.Errorf("query parameter %s has unsupported type %s", field.Name, field.Type)
+		}
+
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// operationID names a route after its method and path, e.g.
+// "getTransactionsExport" for GET /api/v1/transactions/export
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.Method)
+	for _, segment := range strings.Split(strings.TrimPrefix(route.Path, apiPrefix), "/") {
+		if segment != "" {
+			id += strings.ToUpper(segment[:1]) + segment[1:]
+		}
+	}
+	return id
+}
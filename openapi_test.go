@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPIMatchesRoutes checks that the document, the route table and
+// the mux describe the same endpoints
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	a := &App{}
+	doc, err := a.openAPIDocument()
+	if err != nil {
+		t.Fatalf("openAPIDocument() error = %v", err)
+	}
+	mux := http.NewServeMux()
+	a.registerAPIRoutes(mux)
+
+	routes := a.apiRoutes()
+	documented := 0
+	for _, ops := range doc.Paths {
+		documented += len(ops)
+	}
+	if documented != len(routes) {
+		t.Errorf("document has %d operations, route table has %d", documented, len(routes))
+	}
+
+	operationIDs := make(map[string]string)
+	for _, route := range routes {
+		name := route.Method + " " + route.Path
+
+		op := doc.Paths[route.Path][strings.ToLower(route.Method)]
+		if op == nil {
+			t.Errorf("%s is served but not documented", name)
+			continue
+		}
+		if other, dup := operationIDs[op.OperationID]; dup {
+			t.Errorf("%s and %s share operationId %q", name, other, op.OperationID)
+		}
+		operationIDs[op.OperationID] = name
+
+		if _, ok := op.Responses[strconv.Itoa(route.Status)]; !ok {
+			t.Errorf("%s does not document its %d response", name, route.Status)
+		}
+		if _, ok := op.Responses["default"]; !ok {
+			t.Errorf("%s does not document its error response", name)
+		}
+		if secured := len(op.Security) > 0; secured != route.Auth {
+			t.Errorf("%s documents security %v, route requires auth %v", name, secured, route.Auth)
+		}
+
+		req := httptest.NewRequest(route.Method, route.Path, nil)
+		if _, pattern := mux.Handler(req); pattern != name {
+			t.Errorf("%s is routed to %q", name, pattern)
+		}
+	}
+
+	// Every documented operation must reach its own handler, not the
+	// catch-all that answers unknown endpoints
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			req := httptest.NewRequest(strings.ToUpper(method), path, nil)
+			if _, pattern := mux.Handler(req); pattern != strings.ToUpper(method)+" "+path {
+				t.Errorf("documented %s %s is routed to %q", strings.ToUpper(method), path, pattern)
+			}
+		}
+	}
+}
+
+// TestOpenAPIServed checks the document served at /openapi.json and that
+// every schema reference in it resolves
+func TestOpenAPIServed(t *testing.T) {
+	a := &App{}
+	mux := http.NewServeMux()
+	a.registerAPIRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json status = %d", rec.Code)
+	}
+
+	doc, err := a.openAPIDocument()
+	if err != nil {
+		t.Fatalf("openAPIDocument() error = %v", err)
+	}
+	want, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var served, generated any
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("served document is not JSON: %v", err)
+	}
+	if err := json.Unmarshal(want, &generated); err != nil {
+		t.Fatal(err)
+	}
+	servedJSON, _ := json.Marshal(served)
+	generatedJSON, _ := json.Marshal(generated)
+	if string(servedJSON) != string(generatedJSON) {
+		t.Error("served document differs from openAPIDocument()")
+	}
+
+	var refs []string
+	collectRefs(served, &refs)
+	sort.Strings(refs)
+	for _, ref := range refs {
+		name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+		if !ok {
+			t.Errorf("unexpected reference %q", ref)
+			continue
+		}
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("reference %q has no schema", ref)
+		}
+	}
+}
+
+func collectRefs(v any, refs *[]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+				continue
+			}
+			collectRefs(value, refs)
+		}
+	case []any:
+		for _, value := range v {
+			collectRefs(value, refs)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,30 +15,71 @@ type Config struct {
 	StripePublishableKey string
 	StripeWebhookSecret  string
 	ServerPort           string
+
+	// Local HTTP server for webhooks and the REST API
+	ServerBindAddress   string // Interface to listen on, loopback unless exposed on purpose
+	TLSCertFile         string // PEM certificate; with TLSKeyFile enables HTTPS
+	TLSKeyFile          string
+	TLSSelfSigned       bool // Serve HTTPS with a certificate generated on start
+	ServerReadTimeout   time.Duration
+	ServerWriteTimeout  time.Duration
+	ServerIdleTimeout   time.Duration
+	WebhookMaxBodyBytes int64
+
+	// Currency exchange
+	FXRateSource   string // "static" or "file"
+	FXRatesFile    string // NBP/ECB XML or CSV rate file for the "file" source
+	FXStaticRates  string // "EUR=4.30,USD=4.00" in FXBaseCurrency per unit
+	FXBaseCurrency string
+	FXSpreadBps    int64
+	FXQuoteTTL     time.Duration
+
+	// Payment reconciliation
+	ReconcileSchedule string        // Scheduler spec, empty disables the scheduled run
+	ReconcileWindow   time.Duration // How far back each run looks
+
+	// Abandoned deposits
+	PendingExpirySchedule string        // Scheduler spec, empty disables expiry
+	PendingDepositMaxAge  time.Duration // Age after which a pending deposit is settled
+
+	// REST API
+	APISessionTTL time.Duration
+	APISaltSecret string // Keys the fake salts of unknown logins; random per run when empty
+
+	// Language of error messages in the desktop app, "pl" or "en"
+	UILanguage string
+
+	// Logging
+	LogLevel  string // "debug", "info", "warn" or "error"
+	LogFormat string // "text" or "json"
+
+	// Tracing
+	OTLPEndpoint string // host:port of an OTLP/HTTP collector, empty disables tracing
+	OTLPInsecure bool   // Send spans over plain HTTP
 }
 
 func Load() *Config {
 	// Get current working directory
 	wd, err := os.Getwd()
 	if err != nil {
-		log.Printf("Warning: Could not get working directory: %v", err)
+		slog.Warn("Could not get working directory", "error", err)
 	} else {
-		log.Printf("Current working directory: %s", wd)
+		slog.Debug("Current working directory", "path", wd)
 	}
 
 	// Try to load .env file from current directory
 	err = godotenv.Load()
 	if err != nil {
-		log.Printf("Warning: Error loading .env file from current directory: %v", err)
+		slog.Debug("Could not load .env from current directory", "error", err)
 		// Try to load from parent directory (in case we're in a subdirectory)
 		err = godotenv.Load("../.env")
 		if err != nil {
-			log.Printf("Warning: Error loading .env file from parent directory: %v", err)
+			slog.Warn("Could not load .env file", "error", err)
 		} else {
-			log.Printf("Successfully loaded .env from parent directory")
+			slog.Info("Loaded .env from parent directory")
 		}
 	} else {
-		log.Printf("Successfully loaded .env from current directory")
+		slog.Info("Loaded .env from current directory")
 	}
 
 	config := &Config{
@@ -45,27 +88,93 @@ func Load() *Config {
 		StripePublishableKey: getEnv("STRIPE_PUBLISHABLE_KEY", ""),
 		StripeWebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
 		ServerPort:           getEnv("SERVER_PORT", "8080"),
+
+		ServerBindAddress:   getEnv("SERVER_BIND_ADDRESS", "127.0.0.1"),
+		TLSCertFile:         getEnv("SERVER_TLS_CERT_FILE", ""),
+		TLSKeyFile:          getEnv("SERVER_TLS_KEY_FILE", ""),
+		TLSSelfSigned:       getEnvBool("SERVER_TLS_SELF_SIGNED", false),
+		ServerReadTimeout:   getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerWriteTimeout:  getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		ServerIdleTimeout:   getEnvDuration("SERVER_IDLE_TIMEOUT", 2*time.Minute),
+		WebhookMaxBodyBytes: getEnvInt("WEBHOOK_MAX_BODY_BYTES", 256<<10),
+
+		FXRateSource:   getEnv("FX_RATE_SOURCE", "static"),
+		FXRatesFile:    getEnv("FX_RATES_FILE", ""),
+		FXStaticRates:  getEnv("FX_STATIC_RATES", "EUR=4.30,USD=4.00,GBP=5.05,CHF=4.55"),
+		FXBaseCurrency: getEnv("FX_BASE_CURRENCY", "PLN"),
+		FXSpreadBps:    getEnvInt("FX_SPREAD_BPS", 50),
+		FXQuoteTTL:     getEnvDuration("FX_QUOTE_TTL", 30*time.Second),
+
+		ReconcileSchedule: getEnv("RECONCILE_SCHEDULE", "@hourly"),
+		ReconcileWindow:   getEnvDuration("RECONCILE_WINDOW", 72*time.Hour),
+
+		PendingExpirySchedule: getEnv("PENDING_EXPIRY_SCHEDULE", "*/30 * * * *"),
+		PendingDepositMaxAge:  getEnvDuration("PENDING_DEPOSIT_MAX_AGE", 24*time.Hour),
+
+		APISessionTTL: getEnvDuration("API_SESSION_TTL", 24*time.Hour),
+		APISaltSecret: getEnv("API_SALT_SECRET", ""),
+
+		UILanguage: getEnv("UI_LANGUAGE", "pl"),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "text"),
+
+		OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTLPInsecure: getEnvBool("OTEL_EXPORTER_OTLP_INSECURE", false),
 	}
 
-	// Debug logging
-	log.Printf("Config loaded - MongoDB URI: %s", maskString(config.MongoDBURI))
-	log.Printf("Config loaded - Stripe Secret Key: %s", maskString(config.StripeSecretKey))
-	log.Printf("Config loaded - Stripe Publishable Key: %s", maskString(config.StripePublishableKey))
-	log.Printf("Config loaded - Server Port: %s", config.ServerPort)
+	// Secrets are never logged, only whether they are set
+	slog.Info("Config loaded",
+		"mongodb_uri", config.MongoDBURI,
+		"stripe_secret_key_set", config.StripeSecretKey != "",
+		"stripe_publishable_key_set", config.StripePublishableKey != "",
+		"stripe_webhook_secret_set", config.StripeWebhookSecret != "",
+		"api_salt_secret_set", config.APISaltSecret != "",
+		"server_port", config.ServerPort,
+		"server_bind_address", config.ServerBindAddress,
+		"server_tls", config.TLSCertFile != "" || config.TLSSelfSigned,
+		"fx_rate_source", config.FXRateSource,
+		"otlp_endpoint", config.OTLPEndpoint)
 
 	return config
 }
 
-func maskString(s string) string {
-	if len(s) <= 8 {
-		return s
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
-	return s[:4] + "****" + s[len(s)-4:]
+	return defaultValue
 }
 
-func getEnv(key, defaultValue string) string {
+func getEnvInt(key string, defaultValue int64) int64 {
 	if value := os.Getenv(key); value != "" {
-		return value
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			return parsed
+		}
+		slog.Warn("Invalid config value, using default", "variable", key, "value", value, "default", defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err == nil {
+			return parsed
+		}
+		slog.Warn("Invalid config value, using default", "variable", key, "value", value, "default", defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			return parsed
+		}
+		slog.Warn("Invalid config value, using default", "variable", key, "value", value, "default", defaultValue)
 	}
 	return defaultValue
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"pocket-wallet/internal/reconcile"
+)
+
+// runReconciliation reconciles recent payments with Stripe so a missed
+// webhook does not leave a deposit pending forever, and stores the report
+func (a *App) runReconciliation(ctx context.Context) error {
+	to := time.Now()
+	report, err := a.reconciler.Run(ctx, to.Add(-a.config.ReconcileWindow), to, false)
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.SaveReconciliationReport(ctx, report); err != nil {
+		slog.WarnContext(ctx, "Could not save reconciliation report", "error", err)
+	}
+
+	slog.InfoContext(ctx, "Payment reconciliation finished",
+		"checked", report.Checked, "matched", report.Matched, "fixed", report.Fixed, "unresolved", reconcile.Unresolved(report))
+	return nil
+}
+
+// expirePendingDeposits settles deposits the user abandoned in the Stripe
+// dialog so they stop cluttering the history
+func (a *App) expirePendingDeposits(ctx context.Context) error {
+	result, err := a.reconciler.ExpirePendingDeposits(ctx, a.config.PendingDepositMaxAge)
+	if err != nil {
+		return err
+	}
+
+	if result.Checked > 0 {
+		slog.InfoContext(ctx, "Pending deposit expiry finished",
+			"checked", result.Checked, "cancelled", result.Cancelled, "resolved", result.Resolved, "left_pending", result.Skipped)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"pocket-wallet/internal/database"
+)
+
+// SearchTransactions finds the user's transactions matching a free-text
+// query over description, counterparty, notes and payment ID
+func (a *App) SearchTransactions(req TransactionSearchRequest) (_ *TransactionSearchResponse, err error) {
+	ctx, done := observe("SearchTransactions")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbResults, err := a.wallet.SearchTransactions(ctx, req.UserID, req.Query, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TransactionSearchResult, len(dbResults))
+	for i, dbResult := range dbResults {
+		results[i] = TransactionSearchResult{
+			Transaction: *transactionFromDB(dbResult.Transaction),
+			Score:       dbResult.Score,
+			Highlights:  highlightsFromDB(dbResult.Highlights),
+		}
+	}
+
+	return &TransactionSearchResponse{Results: results}, nil
+}
+
+// UpdateTransactionNotes attaches searchable notes to one of the user's transactions
+func (a *App) UpdateTransactionNotes(req TransactionNotesRequest) (err error) {
+	ctx, done := observe("UpdateTransactionNotes")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return errDatabaseUnavailable
+	}
+
+	return a.wallet.UpdateTransactionNotes(ctx, req.UserID, req.TransactionID, req.Notes)
+}
+
+// highlightsFromDB converts database highlights to the main type
+func highlightsFromDB(dbHighlights []database.Highlight) []TextHighlight {
+	highlights := make([]TextHighlight, len(dbHighlights))
+	for i, h := range dbHighlights {
+		matches := make([]TextRange, len(h.Matches))
+		for j, m := range h.Matches {
+			matches[j] = TextRange{Start: m.Start, End: m.End}
+		}
+		highlights[i] = TextHighlight{Field: h.Field, Text: h.Text, Matches: matches}
+	}
+	return highlights
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"pocket-wallet/internal/certs"
+	"pocket-wallet/pkg/config"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// TLS modes of the local HTTP server
+const (
+	serverTLSOff        = "off"
+	serverTLSFiles      = "files"       // Certificate and key from files
+	serverTLSSelfSigned = "self-signed" // Certificate generated on start
+)
+
+// eventServerFailed is sent to the frontend when the HTTP server stops
+// unexpectedly; the payload is the ServerStatus
+const eventServerFailed = "server:failed"
+
+// GetServerStatus reports whether the HTTP server for webhooks and the
+// REST API is running. Without it payments are never confirmed, so the
+// frontend warns when it is not.
+func (a *App) GetServerStatus() *ServerStatus {
+	_, done := observe("GetServerStatus")
+	defer done(nil)
+
+	a.serverMu.Lock()
+	defer a.serverMu.Unlock()
+	status := a.serverStatus
+	return &status
+}
+
+// listen opens the server's address, so a port in use or a bad bind
+// address is reported while starting instead of from a goroutine
+func (a *App) listen() (net.Listener, error) {
+	cfg := a.config
+	addr := net.JoinHostPort(cfg.ServerBindAddress, cfg.ServerPort)
+
+	tlsConfig, mode, fingerprint, err := serverTLS(cfg)
+	if err != nil {
+		return nil, a.serverFailed(addr, err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, a.serverFailed(addr, err)
+	}
+	a.server.TLSConfig = tlsConfig
+
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	host := cfg.ServerBindAddress
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	a.serverMu.Lock()
+	a.serverStatus = ServerStatus{
+		Running:         true,
+		Address:         listener.Addr().String(),
+		URL:             scheme + "://" + net.JoinHostPort(host, cfg.ServerPort),
+		TLS:             mode,
+		CertFingerprint: fingerprint,
+	}
+	a.serverMu.Unlock()
+
+	slog.Info("HTTP server started", "address", listener.Addr().String(), "tls", mode)
+	if tlsConfig == nil && !isLoopback(cfg.ServerBindAddress) {
+		slog.Warn("HTTP server is reachable from the network without TLS", "address", addr)
+	}
+	return listener, nil
+}
+
+// serve runs the server on an open listener until it is shut down. If it
+// stops for any other reason, the lifecycle manager starts it again.
+func (a *App) serve(server *http.Server, listener net.Listener) {
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serve(listener)
+	}
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		return
+	}
+
+	// Drop the connections still open, so the restart starts clean
+	server.Close()
+	err = a.serverFailed(listener.Addr().String(), err)
+	if a.lifecycle != nil {
+		a.lifecycle.Fail(subsystemServer, err)
+	}
+}
+
+// serverFailed records why the server is not running and tells the
+// frontend. Retries failing the same way are not reported again.
+func (a *App) serverFailed(addr string, err error) error {
+	err = fmt.Errorf("HTTP server on %s: %w", addr, err)
+
+	a.serverMu.Lock()
+	repeated := a.serverStatus.Error == err.Error()
+	a.serverStatus = ServerStatus{Address: addr, TLS: serverTLSOff, Error: err.Error()}
+	status := a.serverStatus
+	a.serverMu.Unlock()
+
+	if repeated {
+		return err
+	}
+	slog.Error("HTTP server not running", "address", addr, "error", err)
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, eventServerFailed, status)
+	}
+	return err
+}
+
+// serverTLS builds the TLS configuration the settings ask for, or nil for
+// plain HTTP. The fingerprint identifies a self-signed certificate.
+func serverTLS(cfg *config.Config) (_ *tls.Config, mode, fingerprint string, err error) {
+	var cert tls.Certificate
+	switch {
+	case cfg.TLSCertFile != "" || cfg.TLSKeyFile != "":
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, "", "", errors.New("SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+		}
+		cert, err = tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		mode = serverTLSFiles
+	case cfg.TLSSelfSigned:
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if ip := net.ParseIP(cfg.ServerBindAddress); cfg.ServerBindAddress != "" && !isLoopback(cfg.ServerBindAddress) && (ip == nil || !ip.IsUnspecified()) {
+			hosts = append(hosts, cfg.ServerBindAddress)
+		}
+		cert, err = certs.SelfSigned(hosts...)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to generate TLS certificate: %w", err)
+		}
+		mode = serverTLSSelfSigned
+		fingerprint = certs.Fingerprint(cert)
+		slog.Info("Generated self-signed TLS certificate", "sha256_fingerprint", fingerprint)
+	default:
+		return nil, serverTLSOff, "", nil
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, mode, fingerprint, nil
+}
+
+// isLoopback reports whether a bind address only accepts local connections
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
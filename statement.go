@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"pocket-wallet/internal/currency"
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/export"
+	"pocket-wallet/internal/statement"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GenerateStatement asks the user where to save and writes a monthly PDF
+// account statement. The transaction table is read from the server; the
+// opening and closing balances come from the client, which holds the
+// decrypted balance.
+func (a *App) GenerateStatement(userID string, req StatementRequest) (_ *StatementResult, err error) {
+	ctx, done := observe("GenerateStatement")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	if _, err := a.validateSession(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// Validate the request before bothering the user with a dialog
+	if err := validateStatementPeriod(req.Year, req.Month, time.Now()); err != nil {
+		return nil, err
+	}
+	code, err := currency.Normalize(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Wyciąg z rachunku",
+		DefaultFilename: fmt.Sprintf("pocket-wallet-wyciag-%04d-%02d-%s.pdf", req.Year, req.Month, code),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "PDF (*.pdf)", Pattern: "*.pdf"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	if path == "" {
+		return &StatementResult{Cancelled: true}, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statement file: %w", err)
+	}
+
+	count, err := a.writeStatement(ctx, file, userID, req.Year, time.Month(req.Month), code, req.OpeningBalance, req.ClosingBalance)
+	if err == nil {
+		err = file.Close()
+		if err != nil {
+			err = fmt.Errorf("failed to write statement file: %w", err)
+		}
+	} else {
+		file.Close()
+	}
+	if err != nil {
+		// Don't leave a truncated PDF behind
+		if removeErr := os.Remove(path); removeErr != nil {
+			slog.Warn("Could not remove incomplete statement file", "path", path, "error", removeErr)
+		}
+		return nil, err
+	}
+
+	slog.Info("Statement generated", "user_id", userID, "year", req.Year, "month", req.Month, "transactions", count)
+	return &StatementResult{Path: path, Count: count}, nil
+}
+
+// validateStatementPeriod accepts months from January 2000 up to the
+// current one; a later month has no transactions to list yet
+func validateStatementPeriod(year, month int, now time.Time) error {
+	if month < 1 || month > 12 {
+		return invalidInput("month must be between 1 and 12")
+	}
+	if year < 2000 {
+		return invalidInput("invalid statement year: %d", year)
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if start.After(current) {
+		return invalidInput("statement month %04d-%02d is in the future", year, month)
+	}
+	return nil
+}
+
+// writeStatement renders the statement for one calendar month into w
+func (a *App) writeStatement(ctx context.Context, w io.Writer, userID string, year int, month time.Month, code string, opening, closing *float64) (int, error) {
+	user, err := a.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	stmt := &statement.Statement{
+		AccountHolder: user.Login,
+		AccountID:     export.AccountID(userID),
+		Currency:      code,
+		From:          from,
+		To:            from.AddDate(0, 1, 0),
+		GeneratedAt:   time.Now(),
+	}
+	if opening != nil {
+		value := currency.ToMinor(*opening, code)
+		stmt.OpeningBalance = &value
+	}
+	if closing != nil {
+		value := currency.ToMinor(*closing, code)
+		stmt.ClosingBalance = &value
+	}
+
+	err = a.db.StreamTransactions(ctx, &database.TransactionFilter{
+		UserID:     userID,
+		Currency:   code,
+		From:       stmt.From,
+		To:         stmt.To,
+		WalletOnly: true,
+	}, func(t *database.Transaction) error {
+		stmt.Add(t)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read transactions: %w", err)
+	}
+
+	if err := stmt.Render(w); err != nil {
+		return 0, err
+	}
+
+	return stmt.Len(), nil
+}
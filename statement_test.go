@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"pocket-wallet/internal/errcode"
+)
+
+func TestValidateStatementPeriod(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name        string
+		year, month int
+		wantErr     bool
+	}{
+		{"current month", 2026, 10, false},
+		{"previous month", 2026, 9, false},
+		{"earlier year", 2024, 12, false},
+		{"first supported month", 2000, 1, false},
+		{"next month", 2026, 11, true},
+		{"later this year", 2026, 12, true},
+		{"next year", 2027, 1, true},
+		{"before 2000", 1999, 12, true},
+		{"month zero", 2026, 0, true},
+		{"month thirteen", 2025, 13, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStatementPeriod(tt.year, tt.month, now)
+			if tt.wantErr && errcode.Of(err) != errcode.InvalidInput {
+				t.Errorf("validateStatementPeriod(%d, %d) error = %v, want INVALID_INPUT", tt.year, tt.month, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateStatementPeriod(%d, %d) error = %v", tt.year, tt.month, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"pocket-wallet/internal/database"
+	"pocket-wallet/internal/logging"
+	"pocket-wallet/internal/metrics"
+	"pocket-wallet/internal/tracing"
+
+	"github.com/stripe/stripe-go/v76"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// processStripeEvent runs the handler for a verified event and records the
+// outcome in the webhook event log
+func (a *App) processStripeEvent(ctx context.Context, event *stripe.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "Webhook "+string(event.Type), trace.WithAttributes(
+		attribute.String("webhook.event_id", event.ID),
+		attribute.String("webhook.event_type", string(event.Type))))
+	defer func() { tracing.End(span, err) }()
+
+	status := database.WebhookStatusIgnored
+
+	switch event.Type {
+	case "payment_intent.succeeded":
+		status = database.WebhookStatusProcessed
+		err = a.handlePaymentSuccess(ctx, event)
+	case "payment_intent.payment_failed":
+		status = database.WebhookStatusProcessed
+		err = a.handlePaymentFailure(ctx, event)
+	}
+
+	if err != nil {
+		status = database.WebhookStatusFailed
+		slog.ErrorContext(ctx, "Could not handle webhook event", "error", err)
+	}
+
+	metrics.WebhookEvents.WithLabelValues(string(event.Type), status).Inc()
+
+	if recordErr := a.db.SetWebhookEventOutcome(ctx, event.ID, status, err); recordErr != nil {
+		slog.WarnContext(ctx, "Could not record webhook outcome", "error", recordErr)
+	}
+
+	return err
+}
+
+// GetFailedWebhookEvents lists the most recent webhook events whose
+// processing failed
+func (a *App) GetFailedWebhookEvents(limit int) (_ []WebhookEvent, err error) {
+	ctx, done := observe("GetFailedWebhookEvents")
+	defer done(&err)
+
+	return a.getWebhookEvents(ctx, database.WebhookStatusFailed, limit)
+}
+
+func (a *App) getWebhookEvents(ctx context.Context, status string, limit int) ([]WebhookEvent, error) {
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	dbEvents, err := a.db.GetWebhookEvents(ctx, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]WebhookEvent, len(dbEvents))
+	for i, e := range dbEvents {
+		events[i] = *webhookEventFromDB(e)
+	}
+	return events, nil
+}
+
+// ReplayWebhookEvent processes a stored webhook event again, as if Stripe
+// had resent it, and returns the event with its new outcome
+func (a *App) ReplayWebhookEvent(eventID string) (_ *WebhookEvent, err error) {
+	ctx, done := observe("ReplayWebhookEvent")
+	defer done(&err)
+
+	if !a.dbAvailable() {
+		return nil, errDatabaseUnavailable
+	}
+
+	stored, err := a.db.GetWebhookEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The payload was verified when it arrived, so it is trusted as stored
+	var event stripe.Event
+	if err := json.Unmarshal([]byte(stored.Payload), &event); err != nil {
+		return nil, fmt.Errorf("stored event payload is invalid: %w", err)
+	}
+
+	ctx = logging.With(ctx, "event_id", event.ID, "event_type", string(event.Type), "replay", true)
+	slog.InfoContext(ctx, "Replaying webhook event")
+	processErr := a.processStripeEvent(ctx, &event)
+
+	stored, err = a.db.GetWebhookEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	result := webhookEventFromDB(stored)
+	if processErr != nil {
+		return result, fmt.Errorf("replay failed: %w", processErr)
+	}
+	return result, nil
+}
+
+// webhookEventFromDB converts a stored webhook event to the main type
+func webhookEventFromDB(e *database.WebhookEvent) *WebhookEvent {
+	return &WebhookEvent{
+		EventID:     e.EventID,
+		Provider:    e.Provider,
+		Type:        e.Type,
+		Payload:     e.Payload,
+		Status:      e.Status,
+		Error:       e.Error,
+		Deliveries:  e.Deliveries,
+		Attempts:    e.Attempts,
+		ReceivedAt:  e.ReceivedAt,
+		ProcessedAt: e.ProcessedAt,
+	}
+}